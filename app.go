@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
 )
 
@@ -20,33 +21,73 @@ func New(cli *CLI) *App {
 func (app *App) Run(ctx context.Context) error {
 	cli := app.CLI
 
-	// Validate directories
-	if err := ValidateModuleDirectory(cli.LeftDir); err != nil {
-		return fmt.Errorf("left directory validation failed: %w", err)
-	}
-
-	if err := ValidateModuleDirectory(cli.RightDir); err != nil {
-		return fmt.Errorf("right directory validation failed: %w", err)
+	// Build comparison config
+	config := ComparisonConfig{
+		Levels:           parseComparisonLevels(cli.Levels),
+		IgnoreArguments:  cli.IgnoreArgs,
+		IgnoreAttributes: cli.IgnoreAttrs,
+		SetAttributes:    cli.SetAttrs,
+		Include:          cli.Include,
+		Exclude:          cli.Exclude,
+		RenameThreshold:  cli.RenameThreshold,
 	}
 
-	// Parse modules
 	parseOptions := ParseOptions{
 		IgnoreFiles: cli.IgnoreFiles,
+		SkipTests:   !comparesTests(config.Levels),
 	}
-	leftModule, err := ParseModuleWithOptions(cli.LeftDir, parseOptions)
-	if err != nil {
-		return fmt.Errorf("failed to parse left module: %w", err)
+	if cli.RenameThreshold < 0 || cli.RenameThreshold > 1 {
+		return fmt.Errorf("--rename-threshold must be between 0 and 1")
+	}
+	if err := validatePathPatterns("attribute", append(append([]string{}, cli.IgnoreAttrs...), cli.SetAttrs...)); err != nil {
+		return err
+	}
+	if err := validatePathPatterns("address", append(append([]string{}, cli.Include...), cli.Exclude...)); err != nil {
+		return err
+	}
+	if cli.Rules != "" {
+		rules, err := ReadRules(cli.Rules)
+		if err != nil {
+			return err
+		}
+		config.Substitutions = rules.Substitutions
+		config.IgnoreAttributes = append(config.IgnoreAttributes, rules.IgnoreAttributes...)
+		config.SetAttributes = append(config.SetAttributes, rules.SetAttributes...)
+		config.Identities = rules.Identities
+		config.ResourceMatches = rules.Matches
+		config.BlockKeys = rules.BlockKeys
+	}
+
+	if cli.Git != "" && (cli.Reference || cli.Recursive) {
+		return fmt.Errorf("--git cannot be combined with --reference or --recursive")
+	}
+	if (cli.Baseline != "" || cli.WriteBaseline != "") && (cli.Reference || cli.Recursive) {
+		return fmt.Errorf("--baseline and --write-baseline cannot be combined with --reference or --recursive")
+	}
+	if cli.Baseline != "" && cli.WriteBaseline != "" {
+		return fmt.Errorf("--baseline cannot be combined with --write-baseline")
+	}
+	if cli.Reference {
+		return app.runReference(parseOptions, config)
+	}
+	if len(cli.Instances) > 0 {
+		return fmt.Errorf("more than two directories can only be compared with --reference")
+	}
+
+	if cli.Recursive {
+		return app.runRecursive(parseOptions, config)
 	}
 
-	rightModule, err := ParseModuleWithOptions(cli.RightDir, parseOptions)
+	leftModule, rightModule, err := app.parseModules(parseOptions)
 	if err != nil {
-		return fmt.Errorf("failed to parse right module: %w", err)
+		return err
 	}
 
-	// Build comparison config
-	config := ComparisonConfig{
-		Levels:          parseComparisonLevels(cli.Levels),
-		IgnoreArguments: cli.IgnoreArgs,
+	if cli.WriteBaseline != "" {
+		return app.writeBaseline(leftModule, rightModule, config)
+	}
+	if cli.Baseline != "" {
+		return app.runBaseline(leftModule, rightModule, config)
 	}
 
 	// Compare modules
@@ -66,6 +107,335 @@ func (app *App) Run(ctx context.Context) error {
 	}
 }
 
+// parseModules parses the left and right modules, either from two directories, archives or
+// snapshots or from one directory at two git revisions
+func (app *App) parseModules(parseOptions ParseOptions) (*ModuleDefinition, *ModuleDefinition, error) {
+	cli := app.CLI
+
+	if cli.Git != "" {
+		if cli.RightDir != "" {
+			return nil, nil, fmt.Errorf("--git compares a single directory, got a right directory %s", cli.RightDir)
+		}
+		leftRevision, rightRevision, err := ParseRevisionRange(cli.Git)
+		if err != nil {
+			return nil, nil, err
+		}
+		leftModule, err := ParseModuleGit(cli.LeftDir, leftRevision, parseOptions)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse left module: %w", err)
+		}
+		rightModule, err := ParseModuleGit(cli.LeftDir, rightRevision, parseOptions)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse right module: %w", err)
+		}
+		return leftModule, rightModule, nil
+	}
+
+	if cli.RightDir == "" {
+		return nil, nil, fmt.Errorf("right directory is required")
+	}
+
+	// Validate directories
+	if err := ValidateModuleSource(cli.LeftDir); err != nil {
+		return nil, nil, fmt.Errorf("left directory validation failed: %w", err)
+	}
+
+	if err := ValidateModuleSource(cli.RightDir); err != nil {
+		return nil, nil, fmt.Errorf("right directory validation failed: %w", err)
+	}
+
+	// Parse modules
+	leftModule, err := ParseModuleSource(cli.LeftDir, parseOptions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse left module: %w", err)
+	}
+
+	rightModule, err := ParseModuleSource(cli.RightDir, parseOptions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse right module: %w", err)
+	}
+
+	return leftModule, rightModule, nil
+}
+
+// writeBaseline records the differences between the left and right modules in the baseline file
+func (app *App) writeBaseline(leftModule, rightModule *ModuleDefinition, config ComparisonConfig) error {
+	cli := app.CLI
+
+	baseline := BuildBaseline(leftModule, rightModule, config)
+
+	file, err := os.Create(cli.WriteBaseline)
+	if err != nil {
+		return fmt.Errorf("failed to create baseline file: %w", err)
+	}
+	if err := WriteBaseline(file, baseline); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if cli.OutputFormat == "text" {
+		fmt.Printf("Recorded %d differences in %s\n", len(baseline.Entries), cli.WriteBaseline)
+	}
+	return nil
+}
+
+// runBaseline reports the differences between the left and right modules that are not
+// accepted by the baseline file
+func (app *App) runBaseline(leftModule, rightModule *ModuleDefinition, config ComparisonConfig) error {
+	cli := app.CLI
+
+	if cli.OutputFormat != "json" && cli.OutputFormat != "text" {
+		return fmt.Errorf("unsupported output format: %s", cli.OutputFormat)
+	}
+
+	baseline, err := ReadBaseline(cli.Baseline)
+	if err != nil {
+		return fmt.Errorf("failed to read baseline: %w", err)
+	}
+
+	result := CheckBaseline(baseline, leftModule, rightModule, config)
+
+	if cli.OutputFormat == "json" {
+		if err := app.outputJSON(result); err != nil {
+			return err
+		}
+	} else {
+		fmt.Print(FormatBaselineOutput(result, cli.NoColor))
+	}
+
+	if unexpected := len(result.New) + len(result.Changed); unexpected > 0 {
+		return &ExitError{
+			Code: ExitCodeUnexpectedDrift,
+			Err:  fmt.Errorf("%d differences are not accepted by the baseline", unexpected),
+		}
+	}
+	return nil
+}
+
+// runRecursive compares every module directory found under the left and right trees
+func (app *App) runRecursive(parseOptions ParseOptions, config ComparisonConfig) error {
+	cli := app.CLI
+
+	if cli.OutputFormat != "json" && cli.OutputFormat != "text" {
+		return fmt.Errorf("unsupported output format: %s", cli.OutputFormat)
+	}
+
+	result, err := CompareTrees(cli.LeftDir, cli.RightDir, parseOptions, config)
+	if err != nil {
+		return err
+	}
+
+	if cli.OutputFormat == "json" {
+		return app.outputJSON(result)
+	}
+	fmt.Print(FormatTreeOutput(result, config, cli.NoColor))
+	return nil
+}
+
+// RunMatrix parses every directory of the matrix command and prints their drift matrix
+func (app *App) RunMatrix(ctx context.Context, cli *MatrixCLI) error {
+	modules, config, err := cli.parseModules("matrix")
+	if err != nil {
+		return err
+	}
+
+	matrix := BuildDriftMatrix(modules, config)
+	if cli.DiffOnly {
+		matrix = FilterDriftMatrix(matrix)
+	}
+
+	if cli.OutputFormat == "json" {
+		return app.outputJSON(matrix)
+	}
+	fmt.Print(FormatMatrixOutput(matrix, cli.NoColor))
+	return nil
+}
+
+// RunConsensus parses every directory of the consensus command and prints the directories
+// that deviate from the majority
+func (app *App) RunConsensus(ctx context.Context, cli *ConsensusCLI) error {
+	modules, config, err := cli.parseModules("consensus")
+	if err != nil {
+		return err
+	}
+
+	result := FindOutliers(modules, config)
+
+	if cli.OutputFormat == "json" {
+		return app.outputJSON(result)
+	}
+	fmt.Print(FormatConsensusOutput(result, cli.NoColor))
+	return nil
+}
+
+// RunMergeBase parses the base, left and right directories and prints their three-way comparison
+func (app *App) RunMergeBase(ctx context.Context, cli *MergeBaseCLI) error {
+	modules, config, err := cli.parseModules([]string{cli.BaseDir, cli.LeftDir, cli.RightDir})
+	if err != nil {
+		return err
+	}
+
+	result := CompareThreeWay(modules[0], modules[1], modules[2], config)
+
+	if cli.OutputFormat == "json" {
+		return app.outputJSON(result)
+	}
+	fmt.Print(FormatThreeWayOutput(result, cli.NoColor))
+	return nil
+}
+
+// RunSnapshot parses a module directory and writes its snapshot to a file or standard output
+func (app *App) RunSnapshot(ctx context.Context, cli *SnapshotCLI) error {
+	parseOptions := ParseOptions{
+		IgnoreFiles: cli.IgnoreFiles,
+	}
+
+	if err := ValidateModuleSource(cli.Dir); err != nil {
+		return fmt.Errorf("directory validation failed: %w", err)
+	}
+	module, err := ParseModuleSource(cli.Dir, parseOptions)
+	if err != nil {
+		return fmt.Errorf("failed to parse module: %w", err)
+	}
+
+	if cli.Output == "" {
+		return WriteSnapshot(os.Stdout, module)
+	}
+	file, err := os.Create(cli.Output)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	if err := WriteSnapshot(file, module); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// RunAPI compares the public interface of two versions of a module and prints each change
+// with its compatibility, and given the previous version, the recommended next version
+func (app *App) RunAPI(ctx context.Context, cli *APICLI) error {
+	if cli.OutputFormat != "json" && cli.OutputFormat != "text" {
+		return fmt.Errorf("unsupported output format: %s", cli.OutputFormat)
+	}
+	if cli.PreviousVersion != "" && !semanticVersionPattern.MatchString(cli.PreviousVersion) {
+		return fmt.Errorf("invalid semantic version %q", cli.PreviousVersion)
+	}
+
+	// Test files are not part of the module interface
+	parseOptions := ParseOptions{
+		IgnoreFiles: cli.IgnoreFiles,
+		SkipTests:   true,
+	}
+
+	var modules []*ModuleDefinition
+	for _, dir := range []string{cli.LeftDir, cli.RightDir} {
+		if err := ValidateModuleSource(dir); err != nil {
+			return fmt.Errorf("directory validation failed for %s: %w", dir, err)
+		}
+		module, err := ParseModuleSource(dir, parseOptions)
+		if err != nil {
+			return fmt.Errorf("failed to parse module %s: %w", dir, err)
+		}
+		modules = append(modules, module)
+	}
+
+	result := APIResult{ComparisonResult: CompareInterfaces(modules[0], modules[1])}
+	if cli.PreviousVersion != "" {
+		recommendation, err := RecommendVersion(result.ComparisonResult, cli.PreviousVersion)
+		if err != nil {
+			return err
+		}
+		result.Recommendation = recommendation
+	}
+
+	if cli.OutputFormat == "json" {
+		return app.outputJSON(result)
+	}
+	fmt.Print(FormatAPIOutput(result.ComparisonResult, cli.NoColor))
+	if result.Recommendation != nil {
+		fmt.Print(FormatVersionRecommendation(result.Recommendation, cli.NoColor))
+	}
+	return nil
+}
+
+// parseModules validates that the command got at least two directories and parses them
+func (o *MultiModuleOptions) parseModules(command string) ([]*ModuleDefinition, ComparisonConfig, error) {
+	if len(o.Dirs) < 2 {
+		return nil, ComparisonConfig{}, fmt.Errorf("%s requires at least two directories, got %d", command, len(o.Dirs))
+	}
+	return o.ComparisonOptions.parseModules(o.Dirs)
+}
+
+// parseModules validates and parses the directories of a command comparing several modules
+func (o *ComparisonOptions) parseModules(dirs []string) ([]*ModuleDefinition, ComparisonConfig, error) {
+	config := ComparisonConfig{
+		Levels:          parseComparisonLevels(o.Levels),
+		IgnoreArguments: o.IgnoreArgs,
+	}
+
+	if o.OutputFormat != "json" && o.OutputFormat != "text" {
+		return nil, config, fmt.Errorf("unsupported output format: %s", o.OutputFormat)
+	}
+
+	parseOptions := ParseOptions{
+		IgnoreFiles: o.IgnoreFiles,
+		SkipTests:   !comparesTests(config.Levels),
+	}
+
+	var modules []*ModuleDefinition
+	for _, dir := range dirs {
+		if err := ValidateModuleSource(dir); err != nil {
+			return nil, config, fmt.Errorf("directory validation failed for %s: %w", dir, err)
+		}
+		module, err := ParseModuleSource(dir, parseOptions)
+		if err != nil {
+			return nil, config, fmt.Errorf("failed to parse module %s: %w", dir, err)
+		}
+		modules = append(modules, module)
+	}
+
+	return modules, config, nil
+}
+
+// runReference compares every instance directory against the reference module in the left directory
+func (app *App) runReference(parseOptions ParseOptions, config ComparisonConfig) error {
+	cli := app.CLI
+
+	if cli.OutputFormat != "json" && cli.OutputFormat != "text" {
+		return fmt.Errorf("unsupported output format: %s", cli.OutputFormat)
+	}
+	if cli.Recursive {
+		return fmt.Errorf("--reference cannot be combined with --recursive")
+	}
+
+	instances, err := ExpandInstancePaths(append([]string{cli.RightDir}, cli.Instances...))
+	if err != nil {
+		return err
+	}
+
+	result, err := CompareReference(cli.LeftDir, instances, parseOptions, config)
+	if err != nil {
+		return err
+	}
+
+	if cli.OutputFormat == "json" {
+		if err := app.outputJSON(result); err != nil {
+			return err
+		}
+	} else {
+		fmt.Print(FormatReferenceOutput(result, config, cli.NoColor))
+	}
+
+	if result.Diverged > 0 {
+		return fmt.Errorf("%d of %d instances diverge from the reference", result.Diverged, len(result.Results))
+	}
+	return nil
+}
+
 func parseComparisonLevels(levels []string) []ComparisonLevel {
 	var result []ComparisonLevel
 
@@ -81,6 +451,16 @@ func parseComparisonLevels(levels []string) []ComparisonLevel {
 			result = append(result, ComparisonLevelDataSources)
 		case "variables":
 			result = append(result, ComparisonLevelVariables)
+		case "required_providers":
+			result = append(result, ComparisonLevelRequiredProviders)
+		case "tests":
+			result = append(result, ComparisonLevelTests)
+		case "components":
+			result = append(result, ComparisonLevelComponents)
+		case "deployments":
+			result = append(result, ComparisonLevelDeployments)
+		case "terragrunt":
+			result = append(result, ComparisonLevelTerragrunt)
 		case "all":
 			result = append(result, ComparisonLevelAll)
 		}
@@ -89,7 +469,13 @@ func parseComparisonLevels(levels []string) []ComparisonLevel {
 	return result
 }
 
-func (app *App) outputJSON(result *ComparisonResult) error {
+// comparesTests reports whether the comparison levels include the tests level, which
+// requires parsing the test files
+func comparesTests(levels []ComparisonLevel) bool {
+	return containsLevel(expandComparisonLevels(levels), ComparisonLevelTests)
+}
+
+func (app *App) outputJSON(result interface{}) error {
 	data, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal JSON: %w", err)
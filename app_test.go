@@ -100,6 +100,85 @@ func TestApp_Run(t *testing.T) {
 			wantErr:     true,
 			errContains: "unsupported output format",
 		},
+		{
+			name: "recursive comparison",
+			cli: CLI{
+				Levels:       []string{"resources"},
+				OutputFormat: "json",
+				Recursive:    true,
+			},
+			setupDirs: func(t *testing.T) (string, string) {
+				tmpDir := t.TempDir()
+				leftDir := filepath.Join(tmpDir, "left")
+				rightDir := filepath.Join(tmpDir, "right")
+
+				createTestModuleWithResource(t, filepath.Join(leftDir, "app"), "aws_instance", "web")
+				createTestModuleWithResource(t, filepath.Join(rightDir, "app"), "aws_instance", "api")
+				createTestModule(t, filepath.Join(rightDir, "network"))
+
+				return leftDir, rightDir
+			},
+			wantErr: false,
+		},
+		{
+			name: "reference comparison with matching instances",
+			cli: CLI{
+				Levels:       []string{"resources"},
+				OutputFormat: "text",
+				Reference:    true,
+			},
+			setupDirs: func(t *testing.T) (string, string) {
+				tmpDir := t.TempDir()
+				referenceDir := filepath.Join(tmpDir, "golden")
+
+				createTestModuleWithResource(t, referenceDir, "aws_instance", "web")
+				createTestModuleWithResource(t, filepath.Join(tmpDir, "teams", "a"), "aws_instance", "web")
+				createTestModuleWithResource(t, filepath.Join(tmpDir, "teams", "b"), "aws_instance", "web")
+
+				return referenceDir, filepath.Join(tmpDir, "teams", "*")
+			},
+			wantErr: false,
+		},
+		{
+			name: "reference comparison with diverging instance",
+			cli: CLI{
+				Levels:       []string{"resources"},
+				OutputFormat: "json",
+				Reference:    true,
+			},
+			setupDirs: func(t *testing.T) (string, string) {
+				tmpDir := t.TempDir()
+				referenceDir := filepath.Join(tmpDir, "golden")
+
+				createTestModuleWithResource(t, referenceDir, "aws_instance", "web")
+				createTestModuleWithResource(t, filepath.Join(tmpDir, "teams", "a"), "aws_instance", "web")
+				createTestModuleWithResource(t, filepath.Join(tmpDir, "teams", "b"), "aws_instance", "api")
+
+				return referenceDir, filepath.Join(tmpDir, "teams", "*")
+			},
+			wantErr:     true,
+			errContains: "1 of 2 instances diverge from the reference",
+		},
+		{
+			name: "extra directories without reference",
+			cli: CLI{
+				Levels:       []string{"resources"},
+				OutputFormat: "text",
+				Instances:    []string{"/tmp"},
+			},
+			setupDirs: func(t *testing.T) (string, string) {
+				tmpDir := t.TempDir()
+				leftDir := filepath.Join(tmpDir, "left")
+				rightDir := filepath.Join(tmpDir, "right")
+
+				createTestModule(t, leftDir)
+				createTestModule(t, rightDir)
+
+				return leftDir, rightDir
+			},
+			wantErr:     true,
+			errContains: "only be compared with --reference",
+		},
 		{
 			name: "comparison with different resources",
 			cli: CLI{
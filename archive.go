@@ -0,0 +1,176 @@
+package tfdiff
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// archiveExtensions are the file extensions of the module archives that can be compared
+var archiveExtensions = []string{".tar.gz", ".tgz", ".zip"}
+
+// SplitArchiveSource splits a module source into an archive path and a subdirectory within
+// the archive, using Terraform's ARCHIVE//SUBDIR syntax. ok is false when the source is not
+// an archive.
+func SplitArchiveSource(source string) (archivePath, subdir string, ok bool) {
+	archivePath = source
+	for i := 0; i < len(source); i++ {
+		if strings.HasPrefix(source[i:], "//") && isArchivePath(source[:i]) {
+			archivePath, subdir = source[:i], source[i+2:]
+			break
+		}
+	}
+	if !isArchivePath(archivePath) {
+		return "", "", false
+	}
+
+	subdir = path.Clean("/" + subdir)[1:]
+	if subdir == "" {
+		subdir = "."
+	}
+	return archivePath, subdir, true
+}
+
+// isArchivePath reports whether a path has the extension of a supported archive
+func isArchivePath(archivePath string) bool {
+	lower := strings.ToLower(archivePath)
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseModuleArchive parses a module directory within a .tar.gz or .zip archive without
+// extracting it to disk. subdir is a slash-separated path within the archive ("." for its
+// root). The module path of the result is ARCHIVE//SUBDIR, or ARCHIVE for the root.
+func ParseModuleArchive(archivePath, subdir string, options ParseOptions) (*ModuleDefinition, error) {
+	files, err := readArchive(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive %s: %w", archivePath, err)
+	}
+
+	modulePath := archivePath
+	if subdir != "." {
+		modulePath = archivePath + "//" + subdir
+	}
+
+	if err := checkFSDirectory(files, subdir); err != nil {
+		return nil, fmt.Errorf("%s: %w", archivePath, err)
+	}
+	module := moduleFS{
+		fsys: files,
+		dir:  subdir,
+		path: modulePath,
+	}
+	found, err := containsModuleFiles(module)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%s does not contain Terraform files", modulePath)
+	}
+
+	def, err := parseModuleFS(module, options)
+	if err != nil {
+		return nil, err
+	}
+	def.Path = modulePath
+	return def, nil
+}
+
+// readArchive reads the regular files of a .tar.gz or .zip archive into memory
+func readArchive(archivePath string) (memFS, error) {
+	if strings.HasSuffix(strings.ToLower(archivePath), ".zip") {
+		return readZipArchive(archivePath)
+	}
+	return readTarGzArchive(archivePath)
+}
+
+// readTarGzArchive reads the regular files of a gzip-compressed tar archive
+func readTarGzArchive(archivePath string) (memFS, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	files := make(memFS)
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name, ok := archiveEntryName(header.Name)
+		if !ok {
+			continue
+		}
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+		files[name] = content
+	}
+	return files, nil
+}
+
+// readZipArchive reads the regular files of a zip archive
+func readZipArchive(archivePath string) (memFS, error) {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	files := make(memFS)
+	for _, entry := range reader.File {
+		if !entry.Mode().IsRegular() {
+			continue
+		}
+		name, ok := archiveEntryName(entry.Name)
+		if !ok {
+			continue
+		}
+		rc, err := entry.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", entry.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.Name, err)
+		}
+		files[name] = content
+	}
+	return files, nil
+}
+
+// archiveEntryName normalizes the name of an archive entry. Entries escaping the archive
+// root are skipped.
+func archiveEntryName(name string) (string, bool) {
+	name = strings.TrimPrefix(path.Clean(strings.ReplaceAll(name, "\\", "/")), "/")
+	if name == "." || !fs.ValidPath(name) {
+		return "", false
+	}
+	return name, true
+}
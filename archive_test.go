@@ -0,0 +1,153 @@
+package tfdiff
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitArchiveSource(t *testing.T) {
+	tests := []struct {
+		source  string
+		archive string
+		subdir  string
+		ok      bool
+	}{
+		{source: "module.tar.gz", archive: "module.tar.gz", subdir: ".", ok: true},
+		{source: "dist/module.tgz//modules/vpc", archive: "dist/module.tgz", subdir: "modules/vpc", ok: true},
+		{source: "module.ZIP//vpc/", archive: "module.ZIP", subdir: "vpc", ok: true},
+		{source: "module.zip//../vpc", archive: "module.zip", subdir: "vpc", ok: true},
+		{source: "/tmp//module.zip", archive: "/tmp//module.zip", subdir: ".", ok: true},
+		{source: "modules/vpc", ok: false},
+		{source: "modules//vpc", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			archive, subdir, ok := SplitArchiveSource(tt.source)
+			if ok != tt.ok {
+				t.Fatalf("expected ok=%v, got %v", tt.ok, ok)
+			}
+			if archive != tt.archive || subdir != tt.subdir {
+				t.Errorf("expected %s//%s, got %s//%s", tt.archive, tt.subdir, archive, subdir)
+			}
+		})
+	}
+}
+
+func TestParseModuleArchive(t *testing.T) {
+	files := map[string]string{
+		"modules/vpc/main.tf": `
+resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+}
+`,
+		"modules/vpc/outputs.tf": `
+output "vpc_id" {
+  value = aws_vpc.main.id
+}
+`,
+		"modules/vpc/tests/vpc.tftest.hcl": `
+run "plan" {
+  command = plan
+}
+`,
+		"README.md": "module",
+	}
+
+	dir := t.TempDir()
+	writeModuleFiles(t, dir, files)
+	tarPath := filepath.Join(dir, "module.tar.gz")
+	writeTarGz(t, tarPath, files)
+	zipPath := filepath.Join(dir, "module.zip")
+	writeZip(t, zipPath, files)
+
+	expected, err := ParseModuleWithOptions(filepath.Join(dir, "modules", "vpc"), ParseOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse directory: %v", err)
+	}
+
+	config := ComparisonConfig{Levels: []ComparisonLevel{ComparisonLevelAll}}
+	for _, archivePath := range []string{tarPath, zipPath} {
+		t.Run(filepath.Base(archivePath), func(t *testing.T) {
+			source := archivePath + "//modules/vpc"
+			if err := ValidateModuleSource(source); err != nil {
+				t.Fatalf("expected archive to be valid: %v", err)
+			}
+			module, err := ParseModuleSource(source, ParseOptions{})
+			if err != nil {
+				t.Fatalf("failed to parse archive: %v", err)
+			}
+			if module.Path != source {
+				t.Errorf("unexpected module path: %s", module.Path)
+			}
+			if result := CompareModules(expected, module, config); len(result.Diffs) != 0 {
+				t.Errorf("expected archive to match the directory, got %+v", result.Diffs)
+			}
+
+			if _, err := ParseModuleSource(archivePath, ParseOptions{}); err == nil {
+				t.Errorf("expected error for archive root without Terraform files")
+			}
+			if _, err := ParseModuleSource(archivePath+"//modules/missing", ParseOptions{}); err == nil {
+				t.Errorf("expected error for missing subdirectory")
+			}
+		})
+	}
+
+	if err := ValidateModuleSource(filepath.Join(dir, "missing.zip")); err == nil {
+		t.Errorf("expected error for missing archive")
+	}
+}
+
+func writeTarGz(t *testing.T, archivePath string, files map[string]string) {
+	t.Helper()
+
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	writer := tar.NewWriter(gz)
+	for name, content := range files {
+		header := &tar.Header{Name: "./" + name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeZip(t *testing.T, archivePath string, files map[string]string) {
+	t.Helper()
+
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	writer := zip.NewWriter(file)
+	for name, content := range files {
+		w, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
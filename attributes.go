@@ -0,0 +1,341 @@
+package tfdiff
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// allComparisonLevels lists every concrete comparison level in the order "all" compares them
+var allComparisonLevels = []ComparisonLevel{
+	ComparisonLevelModuleCalls,
+	ComparisonLevelOutputs,
+	ComparisonLevelResources,
+	ComparisonLevelDataSources,
+	ComparisonLevelVariables,
+	ComparisonLevelRequiredProviders,
+	ComparisonLevelTests,
+	ComparisonLevelComponents,
+	ComparisonLevelDeployments,
+	ComparisonLevelTerragrunt,
+}
+
+// elementAttributes holds one module element and its attribute values flattened to dotted paths
+type elementAttributes struct {
+	Level      string
+	Element    string
+	Attributes map[string]interface{}
+}
+
+// expandComparisonLevels resolves "all" into the concrete comparison levels
+func expandComparisonLevels(levels []ComparisonLevel) []ComparisonLevel {
+	if containsLevel(levels, ComparisonLevelAll) {
+		return allComparisonLevels
+	}
+	return levels
+}
+
+// flattenModule flattens the elements of a module selected by the configured levels.
+// Elements are keyed with the same identity rules as CompareModules and sorted by key
+// within each level. Attributes are left out and lists ordered by the attribute ignore and
+// set patterns, like CompareModules compares them.
+func flattenModule(def *ModuleDefinition, config ComparisonConfig) []elementAttributes {
+	var elements []elementAttributes
+
+	for _, level := range expandComparisonLevels(config.Levels) {
+		var levelElements []elementAttributes
+
+		switch level {
+		case ComparisonLevelModuleCalls:
+			for _, mc := range def.ModuleCalls {
+				attrs := make(map[string]interface{})
+				setAttribute(attrs, "source", mc.Source)
+				setAttribute(attrs, "version", mc.Version)
+				if !config.IgnoreArguments {
+					flattenValues(attrs, "args", mc.Args)
+				}
+				levelElements = append(levelElements, elementAttributes{"module_call", mc.Name, attrs})
+			}
+		case ComparisonLevelOutputs:
+			for _, output := range def.Outputs {
+				attrs := make(map[string]interface{})
+				setAttribute(attrs, "description", output.Description)
+				setAttribute(attrs, "value", output.Value)
+				if output.Sensitive {
+					attrs["sensitive"] = "true"
+				}
+				levelElements = append(levelElements, elementAttributes{"output", output.Name, attrs})
+			}
+		case ComparisonLevelResources:
+			for _, r := range def.Resources {
+				attrs := make(map[string]interface{})
+				if !config.IgnoreArguments {
+					flattenConfig(attrs, "", r.Config)
+				}
+				levelElements = append(levelElements, elementAttributes{"resource", resourceKey(r), attrs})
+			}
+		case ComparisonLevelDataSources:
+			for _, ds := range def.DataSources {
+				attrs := make(map[string]interface{})
+				if !config.IgnoreArguments {
+					flattenConfig(attrs, "", ds.Config)
+				}
+				levelElements = append(levelElements, elementAttributes{"data_source", dataSourceKey(ds), attrs})
+			}
+		case ComparisonLevelVariables:
+			for _, v := range def.Variables {
+				attrs := make(map[string]interface{})
+				setAttribute(attrs, "type", v.Type)
+				setAttribute(attrs, "description", v.Description)
+				if v.DefaultValue != nil {
+					attrs["default"] = v.DefaultValue.Interface()
+				}
+				if !v.IsNullable() {
+					attrs["nullable"] = "false"
+				}
+				if v.Sensitive {
+					attrs["sensitive"] = "true"
+				}
+				for i, validation := range v.Validations {
+					attrs[fmt.Sprintf("validation[%d].condition", i)] = validation.Condition
+					setAttribute(attrs, fmt.Sprintf("validation[%d].error_message", i), validation.ErrorMessage)
+				}
+				levelElements = append(levelElements, elementAttributes{"variable", v.Name, attrs})
+			}
+		case ComparisonLevelRequiredProviders:
+			for _, p := range def.RequiredProviders {
+				attrs := make(map[string]interface{})
+				setAttribute(attrs, "source", p.Source)
+				setAttribute(attrs, "version", p.Version)
+				if len(p.ConfigurationAliases) > 0 {
+					attrs["configuration_aliases"] = strings.Join(p.ConfigurationAliases, ", ")
+				}
+				levelElements = append(levelElements, elementAttributes{"required_provider", p.Name, attrs})
+			}
+		case ComparisonLevelTests:
+			for _, tf := range def.TestFiles {
+				attrs := make(map[string]interface{})
+				flattenValues(attrs, "variables", tf.Variables)
+				for _, provider := range tf.Providers {
+					attrs["provider."+formatTestProvider(provider)] = "true"
+				}
+				levelElements = append(levelElements, elementAttributes{"test_file", tf.Path, attrs})
+
+				for _, run := range tf.Runs {
+					attrs := make(map[string]interface{})
+					setAttribute(attrs, "command", run.Command)
+					setAttribute(attrs, "module", run.Module)
+					flattenValues(attrs, "variables", run.Variables)
+					if len(run.ExpectFailures) > 0 {
+						attrs["expect_failures"] = strings.Join(run.ExpectFailures, ", ")
+					}
+					for i, assertion := range run.Assertions {
+						attrs[fmt.Sprintf("assert[%d].condition", i)] = assertion.Condition
+						setAttribute(attrs, fmt.Sprintf("assert[%d].error_message", i), assertion.ErrorMessage)
+					}
+					levelElements = append(levelElements, elementAttributes{"test_run", testRunKey(tf.Path, run.Name), attrs})
+				}
+			}
+		case ComparisonLevelComponents:
+			for _, c := range def.Components {
+				attrs := make(map[string]interface{})
+				setAttribute(attrs, "source", c.Source)
+				setAttribute(attrs, "version", c.Version)
+				setAttribute(attrs, "for_each", c.ForEach)
+				if !config.IgnoreArguments {
+					flattenValues(attrs, "inputs", c.Inputs)
+				}
+				flattenValues(attrs, "providers", c.Providers)
+				levelElements = append(levelElements, elementAttributes{"component", c.Name, attrs})
+			}
+		case ComparisonLevelDeployments:
+			for _, d := range def.Deployments {
+				attrs := make(map[string]interface{})
+				if !config.IgnoreArguments {
+					flattenValues(attrs, "inputs", d.Inputs)
+				}
+				levelElements = append(levelElements, elementAttributes{"deployment", d.Name, attrs})
+			}
+			for _, r := range def.OrchestrateRules {
+				attrs := make(map[string]interface{})
+				for i, check := range r.Checks {
+					attrs[fmt.Sprintf("check[%d].condition", i)] = check.Condition
+					setAttribute(attrs, fmt.Sprintf("check[%d].reason", i), check.Reason)
+				}
+				levelElements = append(levelElements, elementAttributes{"orchestrate_rule", orchestrateRuleKey(r), attrs})
+			}
+			for _, token := range def.IdentityTokens {
+				attrs := make(map[string]interface{})
+				setAttribute(attrs, "audience", token.Audience)
+				levelElements = append(levelElements, elementAttributes{"identity_token", token.Name, attrs})
+			}
+		case ComparisonLevelTerragrunt:
+			levelElements = flattenTerragrunt(def.Terragrunt, config)
+		}
+
+		for i := range levelElements {
+			levelElements[i].Attributes = applyAttributePatterns(levelElements[i], config)
+		}
+
+		sort.Slice(levelElements, func(i, j int) bool {
+			if levelElements[i].Level != levelElements[j].Level {
+				return levelElements[i].Level < levelElements[j].Level
+			}
+			return levelElements[i].Element < levelElements[j].Element
+		})
+		elements = append(elements, levelElements...)
+	}
+
+	return elements
+}
+
+// blockIndexPattern matches the index of a nested block in a flattened attribute path
+var blockIndexPattern = regexp.MustCompile(`\[\d+\]$`)
+
+// applyAttributePatterns applies the attribute ignore and set patterns to the flattened
+// attributes of an element. Flattened paths are matched like CompareModules matches them:
+// module arguments without their args prefix and nested blocks by block type.
+func applyAttributePatterns(element elementAttributes, config ComparisonConfig) map[string]interface{} {
+	base := newAttributePath(config, element.Level, element.Element)
+	if !base.active() {
+		return element.Attributes
+	}
+
+	result := make(map[string]interface{}, len(element.Attributes))
+	for key, value := range element.Attributes {
+		at, ignored := base, false
+		for i, segment := range strings.Split(key, ".") {
+			if i == 0 && element.Level == "module_call" && segment == "args" {
+				continue
+			}
+			at = at.child(blockIndexPattern.ReplaceAllString(segment, ""))
+			if at.ignored() {
+				ignored = true
+				break
+			}
+		}
+		if !ignored {
+			result[key] = applyValuePatterns(value, at)
+		}
+	}
+	return result
+}
+
+// applyValuePatterns returns a copy of a flattened collection value without its ignored map
+// keys, with the lists compared as sets in a canonical order
+func applyValuePatterns(value interface{}, at attributePath) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if !at.child(key).ignored() {
+				result[key] = applyValuePatterns(item, at.child(key))
+			}
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			result = append(result, applyValuePatterns(item, at))
+		}
+		if at.isSet() {
+			sort.SliceStable(result, func(i, j int) bool {
+				return formatChangeValue(result[i]) < formatChangeValue(result[j])
+			})
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// flattenTerragrunt flattens a Terragrunt configuration into elements named like the
+// elements of compareTerragrunt
+func flattenTerragrunt(tg *TerragruntConfig, config ComparisonConfig) []elementAttributes {
+	if tg == nil {
+		return nil
+	}
+
+	var elements []elementAttributes
+
+	terraform := make(map[string]interface{})
+	setAttribute(terraform, "source", tg.Source)
+	elements = append(elements, elementAttributes{"terragrunt", "terraform", terraform})
+
+	if !config.IgnoreArguments && len(tg.Inputs) > 0 {
+		inputs := make(map[string]interface{})
+		flattenValues(inputs, "", tg.Inputs)
+		elements = append(elements, elementAttributes{"terragrunt", "inputs", inputs})
+	}
+
+	for _, dependency := range tg.Dependencies {
+		attrs := map[string]interface{}{"config_path": dependency.ConfigPath}
+		elements = append(elements, elementAttributes{"terragrunt", "dependency." + dependency.Name, attrs})
+	}
+
+	for _, include := range tg.Includes {
+		element := "include"
+		if include.Name != "" {
+			element = "include." + include.Name
+		}
+		attrs := map[string]interface{}{"path": include.Path}
+		elements = append(elements, elementAttributes{"terragrunt", element, attrs})
+	}
+
+	if tg.RemoteState != nil {
+		attrs := make(map[string]interface{})
+		setAttribute(attrs, "backend", tg.RemoteState.Backend)
+		flattenValues(attrs, "config", tg.RemoteState.Config)
+		elements = append(elements, elementAttributes{"terragrunt", "remote_state", attrs})
+	}
+
+	return elements
+}
+
+// setAttribute records a string attribute unless it is empty
+func setAttribute(attrs map[string]interface{}, path, value string) {
+	if value != "" {
+		attrs[path] = value
+	}
+}
+
+// flattenValues records every entry of a value map under prefix.key as plain data
+func flattenValues(attrs map[string]interface{}, prefix string, values map[string]Value) {
+	for key, value := range values {
+		attrs[joinAttributePath(prefix, key)] = value.Interface()
+	}
+}
+
+// flattenConfig records the attributes of a resource or data source config as plain data.
+// Nested maps are flattened to dotted paths and nested blocks to type[index] paths.
+func flattenConfig(attrs map[string]interface{}, prefix string, config map[string]interface{}) {
+	for key, value := range config {
+		switch v := value.(type) {
+		case map[string][]map[string]interface{}:
+			if key != "_blocks" {
+				attrs[joinAttributePath(prefix, key)] = v
+				continue
+			}
+			for blockType, blocks := range v {
+				for i, block := range blocks {
+					flattenConfig(attrs, joinAttributePath(prefix, fmt.Sprintf("%s[%d]", blockType, i)), block)
+				}
+			}
+		case map[string]interface{}:
+			flattenConfig(attrs, joinAttributePath(prefix, key), v)
+		case Value:
+			attrs[joinAttributePath(prefix, key)] = v.Interface()
+		default:
+			attrs[joinAttributePath(prefix, key)] = value
+		}
+	}
+}
+
+// joinAttributePath joins an attribute path prefix and a key with a dot
+func joinAttributePath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,161 @@
+package tfdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// BaselineSchemaVersion is the version of the baseline file format written by WriteBaseline
+const BaselineSchemaVersion = 2
+
+// BuildBaseline records every difference between two modules as an accepted difference
+func BuildBaseline(left, right *ModuleDefinition, config ComparisonConfig) *Baseline {
+	return &Baseline{
+		SchemaVersion: BaselineSchemaVersion,
+		LeftPath:      left.Path,
+		RightPath:     right.Path,
+		Entries:       baselineEntries(left, right, config),
+	}
+}
+
+// CheckBaseline compares two modules and classifies their differences against a baseline.
+// Differences recorded with the same values are accepted; differences missing from the
+// baseline are new, differences recorded with other values are changed, and recorded
+// differences that no longer exist are resolved.
+func CheckBaseline(baseline *Baseline, left, right *ModuleDefinition, config ComparisonConfig) *BaselineResult {
+	result := &BaselineResult{
+		LeftPath:  left.Path,
+		RightPath: right.Path,
+		New:       []BaselineEntry{},
+		Changed:   []BaselineChange{},
+		Resolved:  []BaselineEntry{},
+	}
+
+	recorded := make(map[string]BaselineEntry)
+	for _, entry := range baseline.Entries {
+		recorded[baselineKey(entry)] = entry
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range baselineEntries(left, right, config) {
+		key := baselineKey(entry)
+		seen[key] = true
+
+		accepted, ok := recorded[key]
+		switch {
+		case !ok:
+			result.New = append(result.New, entry)
+		case matrixValuesEqual([]interface{}{entry.Left, accepted.Left}) && matrixValuesEqual([]interface{}{entry.Right, accepted.Right}):
+			result.Accepted++
+		default:
+			result.Changed = append(result.Changed, BaselineChange{Current: entry, Baseline: accepted})
+		}
+	}
+
+	for _, entry := range baseline.Entries {
+		if !seen[baselineKey(entry)] {
+			result.Resolved = append(result.Resolved, entry)
+		}
+	}
+
+	return result
+}
+
+// baselineEntries lists the differences CompareModules reports between two modules, so that
+// identity rules, substitutions and rename detection pair elements the same way. Elements on
+// one side only are listed without an attribute, and modified or renamed elements with each
+// of their attribute changes.
+func baselineEntries(left, right *ModuleDefinition, config ComparisonConfig) []BaselineEntry {
+	diffs := append([]Diff(nil), CompareModules(left, right, config).Diffs...)
+	SortDiffs(diffs)
+
+	entries := []BaselineEntry{}
+	for _, diff := range diffs {
+		entry := BaselineEntry{Level: diff.Level, Element: diff.Element, RenamedFrom: diff.RenamedFrom}
+		switch diff.Type {
+		case DiffTypeAdded:
+			entry.Right = elementPresent
+			entries = append(entries, entry)
+			continue
+		case DiffTypeRemoved:
+			entry.Left = elementPresent
+			entries = append(entries, entry)
+			continue
+		}
+
+		// A rename is a difference even when no attribute changed
+		if diff.Type == DiffTypeRenamed || len(diff.Changes) == 0 {
+			element := entry
+			element.Left, element.Right = elementPresent, elementPresent
+			entries = append(entries, element)
+		}
+		for _, change := range diff.Changes {
+			attribute := entry
+			attribute.Attribute = change.Path
+			attribute.Left = normalizeBaselineValue(plainValue(change.Before))
+			attribute.Right = normalizeBaselineValue(plainValue(change.After))
+			entries = append(entries, attribute)
+		}
+	}
+
+	return entries
+}
+
+// baselineKey identifies a difference by level, element, previous name and attribute path
+func baselineKey(entry BaselineEntry) string {
+	return entry.Level + "\x00" + entry.Element + "\x00" + entry.RenamedFrom + "\x00" + entry.Attribute
+}
+
+// normalizeBaselineValue converts a value to the types it has after a JSON round trip, so
+// that values read from a baseline file compare equal to freshly parsed ones
+func normalizeBaselineValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return value
+	}
+	return normalized
+}
+
+// WriteBaseline writes a baseline as JSON
+func WriteBaseline(w io.Writer, baseline *Baseline) error {
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal baseline: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
+
+// ReadBaseline reads a baseline file
+func ReadBaseline(baselinePath string) (*Baseline, error) {
+	data, err := os.ReadFile(baselinePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var baseline Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", baselinePath, err)
+	}
+	if baseline.SchemaVersion == 0 {
+		return nil, fmt.Errorf("%s is not a tfdiff baseline", baselinePath)
+	}
+	if baseline.SchemaVersion < BaselineSchemaVersion {
+		return nil, fmt.Errorf("unsupported baseline schema version %d in %s (supported: %d): regenerate it with --write-baseline", baseline.SchemaVersion, baselinePath, BaselineSchemaVersion)
+	}
+	if baseline.SchemaVersion != BaselineSchemaVersion {
+		return nil, fmt.Errorf("unsupported baseline schema version %d in %s (supported: %d)", baseline.SchemaVersion, baselinePath, BaselineSchemaVersion)
+	}
+	return &baseline, nil
+}
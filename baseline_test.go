@@ -0,0 +1,255 @@
+package tfdiff
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckBaseline(t *testing.T) {
+	staging := parseTestModule(t, map[string]string{
+		"main.tf": `
+resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+  tags = {
+    Environment = "staging"
+  }
+}
+
+resource "aws_s3_bucket" "debug" {
+}
+
+output "url" {
+  value       = "https://staging.example.com"
+  description = "Staging URL"
+}
+`,
+	})
+	production := parseTestModule(t, map[string]string{
+		"main.tf": `
+resource "aws_instance" "web" {
+  instance_type = "t3.large"
+  tags = {
+    Environment = "production"
+  }
+}
+
+output "url" {
+  value       = "https://example.com"
+  description = "Production URL"
+}
+`,
+	})
+
+	config := ComparisonConfig{Levels: []ComparisonLevel{ComparisonLevelResources, ComparisonLevelOutputs}}
+	baseline := BuildBaseline(staging, production, config)
+	if len(baseline.Entries) != 5 {
+		t.Fatalf("expected 5 baseline entries, got %+v", baseline.Entries)
+	}
+
+	// Round trip through a baseline file
+	baselinePath := filepath.Join(t.TempDir(), "baseline.json")
+	file, err := os.Create(baselinePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteBaseline(file, baseline); err != nil {
+		t.Fatalf("failed to write baseline: %v", err)
+	}
+	file.Close()
+	baseline, err = ReadBaseline(baselinePath)
+	if err != nil {
+		t.Fatalf("failed to read baseline: %v", err)
+	}
+
+	result := CheckBaseline(baseline, staging, production, config)
+	if len(result.New) != 0 || len(result.Changed) != 0 || len(result.Resolved) != 0 || result.Accepted != 5 {
+		t.Errorf("expected all differences to be accepted, got %+v", result)
+	}
+
+	// Production drifts: a new attribute difference, a changed value and a resolved element
+	drifted := parseTestModule(t, map[string]string{
+		"main.tf": `
+resource "aws_instance" "web" {
+  instance_type = "t3.xlarge"
+  monitoring    = true
+  tags = {
+    Environment = "production"
+  }
+}
+
+resource "aws_s3_bucket" "debug" {
+}
+
+output "url" {
+  value       = "https://example.com"
+  description = "Production URL"
+}
+`,
+	})
+
+	result = CheckBaseline(baseline, staging, drifted, config)
+	if len(result.New) != 1 || result.New[0].Attribute != "monitoring" {
+		t.Errorf("expected monitoring to be new, got %+v", result.New)
+	}
+	if len(result.Changed) != 1 || result.Changed[0].Current.Attribute != "instance_type" || result.Changed[0].Baseline.Right != "t3.large" {
+		t.Errorf("expected instance_type to be changed, got %+v", result.Changed)
+	}
+	if len(result.Resolved) != 1 || result.Resolved[0].Element != "aws_s3_bucket.debug" {
+		t.Errorf("expected the debug bucket to be resolved, got %+v", result.Resolved)
+	}
+	if result.Accepted != 3 {
+		t.Errorf("expected 3 accepted differences, got %d", result.Accepted)
+	}
+}
+
+func TestCheckBaseline_AttributePatterns(t *testing.T) {
+	staging := parseTestModule(t, map[string]string{
+		"main.tf": `
+resource "aws_instance" "web" {
+  instance_type          = "t3.micro"
+  vpc_security_group_ids = ["sg-1", "sg-2"]
+  tags = {
+    Environment = "staging"
+    Team        = "web"
+  }
+}
+`,
+	})
+	production := parseTestModule(t, map[string]string{
+		"main.tf": `
+resource "aws_instance" "web" {
+  instance_type          = "t3.large"
+  vpc_security_group_ids = ["sg-2", "sg-1"]
+  tags = {
+    Environment = "production"
+    Team        = "web"
+  }
+}
+`,
+	})
+
+	config := ComparisonConfig{
+		Levels:           []ComparisonLevel{ComparisonLevelResources},
+		IgnoreAttributes: []string{"resource.aws_instance.*.tags.Environment"},
+		SetAttributes:    []string{"resource.*.*.vpc_security_group_ids"},
+	}
+	baseline := BuildBaseline(staging, production, config)
+	if len(baseline.Entries) != 1 || baseline.Entries[0].Attribute != "instance_type" {
+		t.Fatalf("expected only instance_type in the baseline, got %+v", baseline.Entries)
+	}
+
+	// Drift of an ignored attribute is not unexpected
+	drifted := parseTestModule(t, map[string]string{
+		"main.tf": `
+resource "aws_instance" "web" {
+  instance_type          = "t3.large"
+  vpc_security_group_ids = ["sg-1", "sg-2"]
+  tags = {
+    Environment = "prod"
+    Team        = "web"
+  }
+}
+`,
+	})
+	result := CheckBaseline(baseline, staging, drifted, config)
+	if len(result.New) != 0 || len(result.Changed) != 0 || len(result.Resolved) != 0 || result.Accepted != 1 {
+		t.Errorf("expected the baseline to be accepted, got %+v", result)
+	}
+}
+
+func TestCheckBaseline_Identities(t *testing.T) {
+	staging := parseTestModule(t, map[string]string{
+		"main.tf": `
+resource "aws_s3_bucket" "stg_logs" {
+  bucket = "logs-stg"
+  tags = {
+    Team = "platform"
+  }
+}
+
+resource "aws_iam_role" "stg_deployer" {
+  name = "deployer"
+}
+`,
+	})
+	production := parseTestModule(t, map[string]string{
+		"main.tf": `
+resource "aws_s3_bucket" "prd_logs" {
+  bucket = "logs-prd"
+  tags = {
+    Team = "security"
+  }
+}
+
+resource "aws_iam_role" "prd_deployer" {
+  name = "deployer"
+}
+`,
+	})
+
+	// Resources are paired like CompareModules pairs them, and substituted values that
+	// compare equal are not differences
+	config := ComparisonConfig{
+		Levels:          []ComparisonLevel{ComparisonLevelResources},
+		Substitutions:   []Substitution{{From: "-prd", To: "-stg"}},
+		Identities:      []IdentityRule{{Type: "aws_s3_bucket", Attribute: "bucket"}},
+		ResourceMatches: []ResourceMatch{{Left: "aws_iam_role.stg_deployer", Right: "aws_iam_role.prd_deployer"}},
+	}
+	baseline := BuildBaseline(staging, production, config)
+	if len(baseline.Entries) != 1 || baseline.Entries[0].Element != "aws_s3_bucket.stg_logs" || baseline.Entries[0].Attribute != "tags.Team" {
+		t.Fatalf("expected only tags.Team of the matched bucket in the baseline, got %+v", baseline.Entries)
+	}
+
+	result := CheckBaseline(baseline, staging, production, config)
+	if len(result.New) != 0 || len(result.Changed) != 0 || len(result.Resolved) != 0 || result.Accepted != 1 {
+		t.Errorf("expected the baseline to be accepted, got %+v", result)
+	}
+}
+
+func TestApp_RunBaseline(t *testing.T) {
+	tmpDir := t.TempDir()
+	leftDir := filepath.Join(tmpDir, "left")
+	rightDir := filepath.Join(tmpDir, "right")
+	createTestModuleWithResource(t, leftDir, "aws_instance", "web")
+	createTestModuleWithResource(t, rightDir, "aws_instance", "api")
+	baselinePath := filepath.Join(tmpDir, "baseline.json")
+
+	run := func(cli CLI) error {
+		cli.LeftDir = leftDir
+		cli.RightDir = rightDir
+		cli.Levels = []string{"resources"}
+		cli.OutputFormat = "text"
+		cli.NoColor = true
+		return New(&cli).Run(context.Background())
+	}
+
+	if err := run(CLI{WriteBaseline: baselinePath}); err != nil {
+		t.Fatalf("failed to write baseline: %v", err)
+	}
+	if err := run(CLI{Baseline: baselinePath}); err != nil {
+		t.Errorf("expected accepted differences to pass, got %v", err)
+	}
+
+	createTestModuleWithResource(t, rightDir, "aws_instance", "worker")
+	err := run(CLI{Baseline: baselinePath})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != ExitCodeUnexpectedDrift {
+		t.Errorf("expected exit code %d for unexpected drift, got %v", ExitCodeUnexpectedDrift, err)
+	}
+}
+
+// parseTestModule writes files to a temporary module directory and parses it
+func parseTestModule(t *testing.T, files map[string]string) *ModuleDefinition {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeModuleFiles(t, dir, files)
+	module, err := ParseModule(dir)
+	if err != nil {
+		t.Fatalf("failed to parse module: %v", err)
+	}
+	return module
+}
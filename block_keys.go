@@ -0,0 +1,68 @@
+package tfdiff
+
+// BlockKey pairs repeated nested blocks of a type by the values of key attributes, so that a
+// changed block is shown as modified instead of as a removed and an added block. An empty
+// attribute list disables pairing for the block type.
+type BlockKey struct {
+	Type       string   `hcl:"type,label" json:"type"`
+	Attributes []string `hcl:"attributes" json:"attributes"`
+}
+
+// defaultBlockKeys are the key attributes of repeated nested blocks of common AWS, Google
+// Cloud and Azure resources
+var defaultBlockKeys = map[string][]string{
+	// aws_security_group, aws_network_acl, aws_default_security_group
+	"ingress": {"from_port", "to_port", "protocol"},
+	"egress":  {"from_port", "to_port", "protocol"},
+	// aws_elastic_beanstalk_environment
+	"setting": {"namespace", "name"},
+	// aws_autoscaling_group
+	"tag": {"key"},
+	// aws_iam_policy_document
+	"statement": {"sid"},
+	// aws_s3_bucket_lifecycle_configuration
+	"rule": {"id"},
+	// aws_dynamodb_table, aws_glue_catalog_table
+	"attribute":              {"name"},
+	"global_secondary_index": {"name"},
+	// google_compute_firewall
+	"allow": {"protocol"},
+	"deny":  {"protocol"},
+	// google_sql_database_instance
+	"database_flags": {"name"},
+	// azurerm_network_security_group, azurerm_virtual_network
+	"security_rule": {"name"},
+	"subnet":        {"name"},
+}
+
+// blockKeys returns the key attributes by block type, with configured keys overriding the
+// defaults
+func blockKeys(config ComparisonConfig) map[string][]string {
+	if len(config.BlockKeys) == 0 {
+		return defaultBlockKeys
+	}
+	keys := make(map[string][]string, len(defaultBlockKeys)+len(config.BlockKeys))
+	for blockType, attributes := range defaultBlockKeys {
+		keys[blockType] = attributes
+	}
+	for _, key := range config.BlockKeys {
+		keys[key.Type] = key.Attributes
+	}
+	return keys
+}
+
+// sameBlockKey reports whether two blocks have equal values for all key attributes. Blocks
+// missing a key attribute are never paired.
+func sameBlockKey(attributes []string, a, b map[string]interface{}) bool {
+	if len(attributes) == 0 {
+		return false
+	}
+	for _, attribute := range attributes {
+		aVal, aOk := a[attribute]
+		bVal, bOk := b[attribute]
+		if !aOk || !bOk || !valuesEqual(aVal, bVal) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package tfdiff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatTextOutput_KeyedNestedBlocks(t *testing.T) {
+	left := parseTestModule(t, map[string]string{
+		"main.tf": `
+resource "aws_security_group" "web" {
+  name = "web"
+
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["10.0.0.0/8"]
+  }
+
+  ingress {
+    from_port   = 22
+    to_port     = 22
+    protocol    = "tcp"
+    cidr_blocks = ["10.1.0.0/16"]
+  }
+}
+
+resource "aws_elastic_beanstalk_environment" "app" {
+  name = "app"
+
+  setting {
+    namespace = "aws:autoscaling:asg"
+    name      = "MinSize"
+    value     = "1"
+  }
+}
+`,
+	})
+	right := parseTestModule(t, map[string]string{
+		"main.tf": `
+resource "aws_security_group" "web" {
+  name = "web"
+
+  ingress {
+    from_port   = 22
+    to_port     = 22
+    protocol    = "tcp"
+    cidr_blocks = ["10.1.0.0/16"]
+  }
+
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+}
+
+resource "aws_elastic_beanstalk_environment" "app" {
+  name = "app"
+
+  setting {
+    namespace = "aws:autoscaling:asg"
+    name      = "MinSize"
+    value     = "2"
+  }
+}
+`,
+	})
+
+	tests := []struct {
+		name      string
+		blockKeys []BlockKey
+		want      []string
+		unwanted  []string
+	}{
+		{
+			name: "default keys",
+			want: []string{
+				"  ingress {",
+				`      from_port = 443`,
+				"cidr_blocks",
+				"  setting {",
+				`      name = "MinSize"`,
+			},
+			unwanted: []string{"- ingress {", "+ ingress {", `from_port = "22"`, "- setting {"},
+		},
+		{
+			name:      "configured keys override defaults",
+			blockKeys: []BlockKey{{Type: "ingress"}, {Type: "setting", Attributes: []string{"name"}}},
+			want:      []string{"- ingress {", "+ ingress {", "  setting {"},
+			unwanted:  []string{"  ingress {"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := ComparisonConfig{Levels: []ComparisonLevel{ComparisonLevelResources}, BlockKeys: tt.blockKeys}
+			output := FormatTextOutput(CompareModules(left, right, config), config, true)
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("expected output to contain %q, got:\n%s", want, output)
+				}
+			}
+			for _, unwanted := range tt.unwanted {
+				if strings.Contains(output, unwanted) {
+					t.Errorf("expected output not to contain %q, got:\n%s", unwanted, output)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,464 @@
+package tfdiff
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// attachChanges computes the attribute changes of the modified and renamed diffs
+func attachChanges(diffs []Diff, config ComparisonConfig) {
+	for i := range diffs {
+		if diffs[i].Type == DiffTypeModified || diffs[i].Type == DiffTypeRenamed {
+			diffs[i].Changes = elementChanges(diffs[i], config)
+		}
+	}
+}
+
+// elementChanges lists the attribute changes between the before and the after element of a
+// diff. Paths are attribute names as written in the configuration, extended by map keys, list
+// indices and nested blocks (ingress[0].cidr_blocks); removed list elements and blocks are
+// indexed on the left side and added ones on the right side.
+func elementChanges(diff Diff, config ComparisonConfig) []AttributeChange {
+	at := newAttributePath(config, diff.Level, diff.Element)
+
+	switch before := diff.Before.(type) {
+	case ModuleCall:
+		after, ok := diff.After.(ModuleCall)
+		if !ok {
+			return nil
+		}
+		changes := scalarChange("source", before.Source, after.Source, at)
+		changes = append(changes, scalarChange("version", before.Version, after.Version, at)...)
+		if !config.IgnoreArguments {
+			changes = append(changes, valueMapChanges("", before.Args, after.Args, at)...)
+		}
+		return changes
+	case Output:
+		after, ok := diff.After.(Output)
+		if !ok {
+			return nil
+		}
+		changes := scalarChange("description", before.Description, after.Description, at)
+		if before.Sensitive != after.Sensitive && !at.child("sensitive").ignored() {
+			changes = append(changes, AttributeChange{Path: "sensitive", Action: DiffTypeModified, Before: before.Sensitive, After: after.Sensitive})
+		}
+		return append(changes, scalarChange("value", before.Value, after.Value, at)...)
+	case Variable:
+		after, ok := diff.After.(Variable)
+		if !ok {
+			return nil
+		}
+		changes := scalarChange("type", before.Type, after.Type, at)
+		changes = append(changes, scalarChange("description", before.Description, after.Description, at)...)
+		if !at.child("default").ignored() {
+			changes = append(changes, optionalValueChanges("default", before.DefaultValue, after.DefaultValue, at.child("default"))...)
+		}
+		if before.IsNullable() != after.IsNullable() && !at.child("nullable").ignored() {
+			changes = append(changes, AttributeChange{Path: "nullable", Action: DiffTypeModified, Before: before.IsNullable(), After: after.IsNullable()})
+		}
+		if before.Sensitive != after.Sensitive && !at.child("sensitive").ignored() {
+			changes = append(changes, AttributeChange{Path: "sensitive", Action: DiffTypeModified, Before: before.Sensitive, After: after.Sensitive})
+		}
+		return append(changes, unorderedBlockChanges("validation", validationList(before.Validations), validationList(after.Validations), at)...)
+	case RequiredProvider:
+		after, ok := diff.After.(RequiredProvider)
+		if !ok {
+			return nil
+		}
+		changes := scalarChange("source", before.Source, after.Source, at)
+		changes = append(changes, scalarChange("version", before.Version, after.Version, at)...)
+		if !at.child("configuration_aliases").ignored() {
+			changes = append(changes, attributeChanges("configuration_aliases", stringList(before.ConfigurationAliases), stringList(after.ConfigurationAliases),
+				len(before.ConfigurationAliases) > 0, len(after.ConfigurationAliases) > 0, at.child("configuration_aliases"))...)
+		}
+		return changes
+	case Resource:
+		after, ok := diff.After.(Resource)
+		if !ok || config.IgnoreArguments {
+			return nil
+		}
+		return configChanges("", before.Config, after.Config, at, blockKeys(config))
+	case DataSource:
+		after, ok := diff.After.(DataSource)
+		if !ok || config.IgnoreArguments {
+			return nil
+		}
+		return configChanges("", before.Config, after.Config, at, blockKeys(config))
+	case Component:
+		after, ok := diff.After.(Component)
+		if !ok {
+			return nil
+		}
+		changes := scalarChange("source", before.Source, after.Source, at)
+		changes = append(changes, scalarChange("version", before.Version, after.Version, at)...)
+		changes = append(changes, scalarChange("for_each", before.ForEach, after.ForEach, at)...)
+		if !config.IgnoreArguments {
+			changes = append(changes, valueMapChanges("inputs", before.Inputs, after.Inputs, at.child("inputs"))...)
+		}
+		return append(changes, valueMapChanges("providers", before.Providers, after.Providers, at.child("providers"))...)
+	case Deployment:
+		after, ok := diff.After.(Deployment)
+		if !ok {
+			return nil
+		}
+		if config.IgnoreArguments {
+			return nil
+		}
+		return valueMapChanges("inputs", before.Inputs, after.Inputs, at.child("inputs"))
+	case IdentityToken:
+		after, ok := diff.After.(IdentityToken)
+		if !ok {
+			return nil
+		}
+		return scalarChange("audience", before.Audience, after.Audience, at)
+	case OrchestrateRule:
+		after, ok := diff.After.(OrchestrateRule)
+		if !ok || at.child("check").ignored() {
+			return nil
+		}
+		return listChanges("check", checkList(before.Checks), checkList(after.Checks), at.child("check"))
+	case TestFile:
+		after, ok := diff.After.(TestFile)
+		if !ok {
+			return nil
+		}
+		changes := valueMapChanges("variables", before.Variables, after.Variables, at.child("variables"))
+		return append(changes, testProviderChanges(before.Providers, after.Providers, at)...)
+	case TestRun:
+		after, ok := diff.After.(TestRun)
+		if !ok {
+			return nil
+		}
+		changes := scalarChange("command", before.Command, after.Command, at)
+		changes = append(changes, scalarChange("module", before.Module, after.Module, at)...)
+		changes = append(changes, valueMapChanges("variables", before.Variables, after.Variables, at.child("variables"))...)
+		if !at.child("expect_failures").ignored() {
+			changes = append(changes, attributeChanges("expect_failures", stringList(before.ExpectFailures), stringList(after.ExpectFailures),
+				len(before.ExpectFailures) > 0, len(after.ExpectFailures) > 0, at.child("expect_failures"))...)
+		}
+		return append(changes, assertionChanges(before.Assertions, after.Assertions, at)...)
+	case TerragruntDependency:
+		after, ok := diff.After.(TerragruntDependency)
+		if !ok {
+			return nil
+		}
+		return scalarChange("config_path", before.ConfigPath, after.ConfigPath, at)
+	case TerragruntInclude:
+		after, ok := diff.After.(TerragruntInclude)
+		if !ok {
+			return nil
+		}
+		changes := scalarChange("path", before.Path, after.Path, at)
+		if before.Resolved != after.Resolved && !at.child("resolved").ignored() {
+			changes = append(changes, AttributeChange{Path: "resolved", Action: DiffTypeModified, Before: before.Resolved, After: after.Resolved})
+		}
+		return changes
+	case TerragruntRemoteState:
+		after, ok := diff.After.(TerragruntRemoteState)
+		if !ok {
+			return nil
+		}
+		changes := scalarChange("backend", before.Backend, after.Backend, at)
+		return append(changes, valueMapChanges("config", before.Config, after.Config, at.child("config"))...)
+	case string, Value:
+		// Terragrunt sources and inputs are elements of their own, named terraform.source and
+		// inputs.NAME; the change is named by the attribute or input name
+		if diff.After == nil || diff.Level != "terragrunt" {
+			return nil
+		}
+		name := diff.Element[strings.Index(diff.Element, ".")+1:]
+		return attributeChanges(name, before, diff.After, true, true, at)
+	default:
+		return nil
+	}
+}
+
+// checkList converts the check blocks of an orchestrate rule to a list of block values
+func checkList(checks []OrchestrateCheck) []interface{} {
+	list := make([]interface{}, 0, len(checks))
+	for _, check := range checks {
+		block := map[string]interface{}{"condition": check.Condition}
+		if check.Reason != "" {
+			block["reason"] = check.Reason
+		}
+		list = append(list, block)
+	}
+	return list
+}
+
+// assertionChanges lists the assert blocks of a test run removed or added regardless of
+// their order, like testRunsEqual compares them
+func assertionChanges(before, after []TestAssertion, at attributePath) []AttributeChange {
+	toList := func(assertions []TestAssertion) []interface{} {
+		list := make([]interface{}, 0, len(assertions))
+		for _, assertion := range assertions {
+			block := map[string]interface{}{"condition": assertion.Condition}
+			if assertion.ErrorMessage != "" {
+				block["error_message"] = assertion.ErrorMessage
+			}
+			list = append(list, block)
+		}
+		return list
+	}
+	return unorderedBlockChanges("assert", toList(before), toList(after), at)
+}
+
+// validationList converts the validation blocks of a variable to a list of block values
+func validationList(validations []VariableValidation) []interface{} {
+	list := make([]interface{}, 0, len(validations))
+	for _, validation := range validations {
+		block := map[string]interface{}{"condition": validation.Condition}
+		if validation.ErrorMessage != "" {
+			block["error_message"] = validation.ErrorMessage
+		}
+		list = append(list, block)
+	}
+	return list
+}
+
+// unorderedBlockChanges lists the blocks of a type removed or added regardless of their
+// order, such as assert and validation blocks
+func unorderedBlockChanges(blockType string, before, after []interface{}, at attributePath) []AttributeChange {
+	blockAt := at.child(blockType)
+	if blockAt.ignored() {
+		return nil
+	}
+
+	var changes []AttributeChange
+	for _, i := range unmatchedElements(before, after, blockAt) {
+		changes = append(changes, AttributeChange{Path: fmt.Sprintf("%s[%d]", blockType, i), Action: DiffTypeRemoved, Before: before[i]})
+	}
+	for _, i := range unmatchedElements(after, before, blockAt) {
+		changes = append(changes, AttributeChange{Path: fmt.Sprintf("%s[%d]", blockType, i), Action: DiffTypeAdded, After: after[i]})
+	}
+	return changes
+}
+
+// testProviderChanges lists the changes of the provider blocks of a test file, keyed by name
+// and alias like testFilesEqual compares them
+func testProviderChanges(before, after []TestProvider, at attributePath) []AttributeChange {
+	if at.child("provider").ignored() {
+		return nil
+	}
+	beforeProviders, afterProviders := testProvidersByKey(before), testProvidersByKey(after)
+	keys := make(map[string]bool)
+	for key := range beforeProviders {
+		keys[key] = true
+	}
+	for key := range afterProviders {
+		keys[key] = true
+	}
+
+	var changes []AttributeChange
+	for _, key := range sortedKeys(keys) {
+		beforeProvider, beforeOk := beforeProviders[key]
+		afterProvider, afterOk := afterProviders[key]
+		path := joinAttributePath("provider", key)
+		switch {
+		case !afterOk:
+			changes = append(changes, AttributeChange{Path: path, Action: DiffTypeRemoved, Before: beforeProvider})
+		case !beforeOk:
+			changes = append(changes, AttributeChange{Path: path, Action: DiffTypeAdded, After: afterProvider})
+		case !reflect.DeepEqual(beforeProvider, afterProvider):
+			changes = append(changes, AttributeChange{Path: path, Action: DiffTypeModified, Before: beforeProvider, After: afterProvider})
+		}
+	}
+	return changes
+}
+
+// scalarChange lists the change of a string attribute, where an empty string is unset
+func scalarChange(name, before, after string, at attributePath) []AttributeChange {
+	if before == after || at.child(name).ignored() {
+		return nil
+	}
+	switch {
+	case before == "":
+		return []AttributeChange{{Path: name, Action: DiffTypeAdded, After: after}}
+	case after == "":
+		return []AttributeChange{{Path: name, Action: DiffTypeRemoved, Before: before}}
+	default:
+		return []AttributeChange{{Path: name, Action: DiffTypeModified, Before: before, After: after}}
+	}
+}
+
+// attributeChanges lists the changes of an attribute that may be unset on either side.
+// Collection values are diffed structurally.
+func attributeChanges(path string, before, after interface{}, beforeOk, afterOk bool, at attributePath) []AttributeChange {
+	switch {
+	case !beforeOk && !afterOk:
+		return nil
+	case !afterOk:
+		return []AttributeChange{{Path: path, Action: DiffTypeRemoved, Before: before}}
+	case !beforeOk:
+		return []AttributeChange{{Path: path, Action: DiffTypeAdded, After: after}}
+	case valuesEqualAt(before, after, at):
+		return nil
+	case isCollectionPair(decodeCollection(before), decodeCollection(after)):
+		return collectionChanges(path, before, after, at)
+	default:
+		return []AttributeChange{{Path: path, Action: DiffTypeModified, Before: before, After: after}}
+	}
+}
+
+// optionalValueChanges lists the changes of an optional value, such as a variable default
+func optionalValueChanges(path string, before, after *Value, at attributePath) []AttributeChange {
+	var beforeVal, afterVal interface{}
+	if before != nil {
+		beforeVal = *before
+	}
+	if after != nil {
+		afterVal = *after
+	}
+	return attributeChanges(path, beforeVal, afterVal, before != nil, after != nil, at)
+}
+
+// stringList converts a string slice to a list value
+func stringList(values []string) []interface{} {
+	list := make([]interface{}, len(values))
+	for i, value := range values {
+		list[i] = value
+	}
+	return list
+}
+
+// valueMapChanges lists the changes of the entries of a value map, such as module call
+// arguments or component inputs, named under a prefix
+func valueMapChanges(prefix string, before, after map[string]Value, at attributePath) []AttributeChange {
+	keys := make(map[string]bool)
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+
+	var changes []AttributeChange
+	for _, key := range sortedKeys(keys) {
+		if at.child(key).ignored() {
+			continue
+		}
+		beforeVal, beforeOk := before[key]
+		afterVal, afterOk := after[key]
+		changes = append(changes, attributeChanges(joinAttributePath(prefix, key), beforeVal, afterVal, beforeOk, afterOk, at.child(key))...)
+	}
+	return changes
+}
+
+// configChanges lists the changes between two resource, data source or nested block configs.
+// Nested blocks come first, then attributes in order.
+func configChanges(prefix string, before, after map[string]interface{}, at attributePath, keys map[string][]string) []AttributeChange {
+	changes := blockChanges(prefix, configBlocks(before), configBlocks(after), at, keys)
+
+	names := make(map[string]bool)
+	for name := range before {
+		names[name] = true
+	}
+	for name := range after {
+		names[name] = true
+	}
+	for _, name := range sortedKeys(names) {
+		if name == "_blocks" || name == "_labels" || at.child(name).ignored() {
+			continue
+		}
+		beforeVal, beforeOk := before[name]
+		afterVal, afterOk := after[name]
+		changes = append(changes, attributeChanges(joinAttributePath(prefix, name), beforeVal, afterVal, beforeOk, afterOk, at.child(name))...)
+	}
+	return changes
+}
+
+// configBlocks returns the nested blocks of a config by block type
+func configBlocks(config map[string]interface{}) map[string][]map[string]interface{} {
+	blocks, _ := config["_blocks"].(map[string][]map[string]interface{})
+	return blocks
+}
+
+// blockChanges lists the changes between the nested blocks of two configs. Equal blocks are
+// paired regardless of order; the remaining blocks are paired by key attributes and diffed
+// attribute by attribute, and blocks left unpaired are removed or added.
+func blockChanges(prefix string, before, after map[string][]map[string]interface{}, at attributePath, keys map[string][]string) []AttributeChange {
+	blockTypes := make(map[string]bool)
+	for blockType := range before {
+		blockTypes[blockType] = true
+	}
+	for blockType := range after {
+		blockTypes[blockType] = true
+	}
+
+	var changes []AttributeChange
+	for _, blockType := range sortedKeys(blockTypes) {
+		blockAt := at.child(blockType)
+		if blockAt.ignored() {
+			continue
+		}
+		beforeList := withoutIgnoredBlocks(before[blockType], blockAt)
+		afterList := withoutIgnoredBlocks(after[blockType], blockAt)
+		blockPath := func(i int) string {
+			return joinAttributePath(prefix, fmt.Sprintf("%s[%d]", blockType, i))
+		}
+
+		pairs := pairBlocks(beforeList, afterList, blockAt, keys[blockType])
+		pairedAfter := make(map[int]bool)
+		for _, j := range pairs {
+			if j >= 0 {
+				pairedAfter[j] = true
+			}
+		}
+
+		for i, j := range pairs {
+			if j >= 0 && !configsEqualAt(beforeList[i], afterList[j], blockAt) {
+				changes = append(changes, configChanges(blockPath(i), beforeList[i], afterList[j], blockAt, keys)...)
+			}
+		}
+		for i, j := range pairs {
+			if j < 0 {
+				changes = append(changes, AttributeChange{Path: blockPath(i), Action: DiffTypeRemoved, Before: beforeList[i]})
+			}
+		}
+		for j, block := range afterList {
+			if !pairedAfter[j] {
+				changes = append(changes, AttributeChange{Path: blockPath(j), Action: DiffTypeAdded, After: block})
+			}
+		}
+	}
+	return changes
+}
+
+// pairBlocks pairs each before block with an equal after block, or else with an after block
+// of the same key attribute values. The result holds the index of the paired after block for
+// each before block, or -1.
+func pairBlocks(before, after []map[string]interface{}, at attributePath, keyAttributes []string) []int {
+	pairs := make([]int, len(before))
+	paired := make([]bool, len(after))
+	for i, block := range before {
+		pairs[i] = -1
+		for j, candidate := range after {
+			if !paired[j] && configsEqualAt(block, candidate, at) {
+				pairs[i], paired[j] = j, true
+				break
+			}
+		}
+	}
+	for i, block := range before {
+		if pairs[i] >= 0 {
+			continue
+		}
+		for j, candidate := range after {
+			if !paired[j] && sameBlockKey(keyAttributes, block, candidate) {
+				pairs[i], paired[j] = j, true
+				break
+			}
+		}
+	}
+	return pairs
+}
+
+// pairedBlock returns the after block paired with a before block
+func pairedBlock(before, after []map[string]interface{}, index int, at attributePath, keyAttributes []string) map[string]interface{} {
+	pairs := pairBlocks(before, after, at, keyAttributes)
+	if index >= len(pairs) || pairs[index] < 0 {
+		return nil
+	}
+	return after[pairs[index]]
+}
@@ -0,0 +1,418 @@
+package tfdiff
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCompareModules_AttributeChanges(t *testing.T) {
+	left := parseTestModule(t, map[string]string{
+		"main.tf": `
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.0.0"
+  name    = "main"
+}
+
+output "id" {
+  description = "VPC ID"
+  value       = "vpc-1"
+}
+
+variable "zones" {
+  default = ["a", "b"]
+}
+
+resource "aws_security_group" "web" {
+  name        = "web"
+  description = "old"
+
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["10.0.0.0/8"]
+  }
+
+  ingress {
+    from_port = 80
+    to_port   = 80
+    protocol  = "tcp"
+  }
+}
+`,
+	})
+	right := parseTestModule(t, map[string]string{
+		"main.tf": `
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.1.0"
+  name    = "main"
+  extra   = "yes"
+}
+
+output "id" {
+  description = "VPC ID"
+  value       = "vpc-1"
+  sensitive   = true
+}
+
+variable "zones" {
+  default = ["a", "c"]
+}
+
+resource "aws_security_group" "web" {
+  name = "web"
+
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+
+  ingress {
+    from_port = 22
+    to_port   = 22
+    protocol  = "tcp"
+  }
+}
+`,
+	})
+
+	config := ComparisonConfig{Levels: []ComparisonLevel{ComparisonLevelAll}}
+	result := CompareModules(left, right, config)
+
+	want := map[string][]string{
+		"vpc": {
+			"modified version",
+			"added extra",
+		},
+		"id": {
+			"modified sensitive",
+		},
+		"zones": {
+			"removed default[1]",
+			"added default[1]",
+		},
+		"aws_security_group.web": {
+			"removed ingress[0].cidr_blocks[0]",
+			"added ingress[0].cidr_blocks[0]",
+			"removed ingress[1]",
+			"added ingress[1]",
+			"removed description",
+		},
+	}
+	if len(result.Diffs) != len(want) {
+		t.Fatalf("expected %d diffs, got %d", len(want), len(result.Diffs))
+	}
+	for _, diff := range result.Diffs {
+		var got []string
+		for _, change := range diff.Changes {
+			got = append(got, string(change.Action)+" "+change.Path)
+		}
+		if strings.Join(got, ",") != strings.Join(want[diff.Element], ",") {
+			t.Errorf("%s: expected changes %v, got %v", diff.Element, want[diff.Element], got)
+		}
+	}
+
+	// The text output is rendered from the same changes
+	output := FormatTextOutput(result, config, true)
+	for _, line := range []string{
+		`+  extra = "yes"`,
+		`+  sensitive = true`,
+		`  -   [1] = "b"`,
+		`      from_port = 443`,
+		`    -   [0] = "10.0.0.0/8"`,
+		`+   from_port = 22`,
+		`  - description = "old"`,
+	} {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+}
+
+func TestCompareModules_AttributeChangesJSON(t *testing.T) {
+	left := parseTestModule(t, map[string]string{
+		"main.tf": `
+resource "aws_s3_bucket_lifecycle_configuration" "logs" {
+  bucket = "logs"
+
+  rule {
+    id = "expire"
+
+    expiration {
+      days = 30
+    }
+  }
+}
+`,
+	})
+	right := parseTestModule(t, map[string]string{
+		"main.tf": `
+resource "aws_s3_bucket_lifecycle_configuration" "logs" {
+  bucket = "logs"
+
+  rule {
+    id = "expire"
+
+    expiration {
+      days = 60
+    }
+  }
+}
+`,
+	})
+
+	result := CompareModules(left, right, ComparisonConfig{Levels: []ComparisonLevel{ComparisonLevelResources}})
+	data, err := json.Marshal(result.Diffs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var diffs []struct {
+		Changes []struct {
+			Path   string                 `json:"path"`
+			Action string                 `json:"action"`
+			Before map[string]interface{} `json:"before"`
+			After  map[string]interface{} `json:"after"`
+		} `json:"changes"`
+	}
+	if err := json.Unmarshal(data, &diffs); err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 || len(diffs[0].Changes) != 2 {
+		t.Fatalf("expected 1 diff with 2 changes, got %s", data)
+	}
+
+	removed, added := diffs[0].Changes[0], diffs[0].Changes[1]
+	if removed.Path != "rule[0].expiration[0]" || removed.Action != "removed" || removed.Before["days"] != float64(30) {
+		t.Errorf("unexpected removed change %+v", removed)
+	}
+	if added.Path != "rule[0].expiration[0]" || added.Action != "added" || added.After["days"] != float64(60) {
+		t.Errorf("unexpected added change %+v", added)
+	}
+}
+
+func TestCompareModules_AttributeChangesIgnoreArguments(t *testing.T) {
+	left := parseTestModule(t, map[string]string{
+		"main.tf": `
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.0.0"
+  name    = "main"
+}
+`,
+	})
+	right := parseTestModule(t, map[string]string{
+		"main.tf": `
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.1.0"
+  name    = "other"
+}
+`,
+	})
+
+	result := CompareModules(left, right, ComparisonConfig{
+		Levels:          []ComparisonLevel{ComparisonLevelModuleCalls},
+		IgnoreArguments: true,
+	})
+	if len(result.Diffs) != 1 {
+		t.Fatalf("expected 1 diff, got %d", len(result.Diffs))
+	}
+	changes := result.Diffs[0].Changes
+	if len(changes) != 1 || changes[0].Path != "version" || changes[0].Before != "5.0.0" || changes[0].After != "5.1.0" {
+		t.Errorf("expected only the version change, got %+v", changes)
+	}
+}
+
+func TestCompareModules_AttributeChangesOtherLevels(t *testing.T) {
+	left := &ModuleDefinition{
+		Path: "left",
+		TestFiles: []TestFile{{
+			Path:      "tests/main.tftest.hcl",
+			Variables: map[string]Value{"prefix": StringValue("test")},
+			Providers: []TestProvider{{Name: "aws"}, {Name: "aws", Alias: "east"}},
+			Runs: []TestRun{{
+				Name:    "check",
+				Command: "plan",
+				Assertions: []TestAssertion{
+					{Condition: "a == 1"},
+					{Condition: "b == 2", ErrorMessage: "b"},
+				},
+			}},
+		}},
+		OrchestrateRules: []OrchestrateRule{{
+			Type:   "auto_approve",
+			Name:   "safe",
+			Checks: []OrchestrateCheck{{Condition: "context.plan.changes.remove == 0", Reason: "no removals"}},
+		}},
+		Terragrunt: &TerragruntConfig{
+			Source:       "git::example.com/modules//vpc?ref=v1.0.0",
+			Inputs:       map[string]Value{"region": StringValue("us-east-1")},
+			Dependencies: []TerragruntDependency{{Name: "vpc", ConfigPath: "../vpc"}},
+			Includes:     []TerragruntInclude{{Name: "root", Path: "../root.hcl", Resolved: true}},
+			RemoteState:  &TerragruntRemoteState{Backend: "s3", Config: map[string]Value{"bucket": StringValue("state"), "key": StringValue("a")}},
+		},
+	}
+	right := &ModuleDefinition{
+		Path: "right",
+		TestFiles: []TestFile{{
+			Path:      "tests/main.tftest.hcl",
+			Variables: map[string]Value{"prefix": StringValue("prod")},
+			Providers: []TestProvider{{Name: "aws", Alias: "east", Mock: true}, {Name: "aws"}, {Name: "random"}},
+			Runs: []TestRun{{
+				Name:           "check",
+				Command:        "apply",
+				ExpectFailures: []string{"var.name"},
+				Assertions: []TestAssertion{
+					{Condition: "b == 2", ErrorMessage: "b"},
+					{Condition: "c == 3"},
+				},
+			}},
+		}},
+		OrchestrateRules: []OrchestrateRule{{
+			Type: "auto_approve",
+			Name: "safe",
+			Checks: []OrchestrateCheck{
+				{Condition: "context.plan.changes.remove == 0", Reason: "no removals"},
+				{Condition: "context.plan.applyable"},
+			},
+		}},
+		Terragrunt: &TerragruntConfig{
+			Source:       "git::example.com/modules//vpc?ref=v1.1.0",
+			Inputs:       map[string]Value{"region": StringValue("us-west-2")},
+			Dependencies: []TerragruntDependency{{Name: "vpc", ConfigPath: "../network"}},
+			Includes:     []TerragruntInclude{{Name: "root", Path: "../../root.hcl", Resolved: true}},
+			RemoteState:  &TerragruntRemoteState{Backend: "s3", Config: map[string]Value{"bucket": StringValue("state"), "key": StringValue("b")}},
+		},
+	}
+
+	config := ComparisonConfig{Levels: []ComparisonLevel{ComparisonLevelTests, ComparisonLevelDeployments, ComparisonLevelTerragrunt}}
+	result := CompareModules(left, right, config)
+
+	got := make(map[string][]string)
+	for _, diff := range result.Diffs {
+		for _, change := range diff.Changes {
+			got[diff.Level+" "+diff.Element] = append(got[diff.Level+" "+diff.Element], change.Path+" "+string(change.Action))
+		}
+	}
+	want := map[string][]string{
+		"test_file tests/main.tftest.hcl": {"variables.prefix modified", "provider.aws.east modified", "provider.random added"},
+		"test_run tests/main.tftest.hcl:run.check": {
+			"command modified", "expect_failures added", "assert[0] removed", "assert[1] added",
+		},
+		"orchestrate_rule auto_approve.safe": {"check[1] added"},
+		"terragrunt terraform.source":        {"source modified"},
+		"terragrunt inputs.region":           {"region modified"},
+		"terragrunt dependency.vpc":          {"config_path modified"},
+		"terragrunt include.root":            {"path modified"},
+		"terragrunt remote_state":            {"config.key modified"},
+	}
+	for element, paths := range want {
+		if strings.Join(got[element], ", ") != strings.Join(paths, ", ") {
+			t.Errorf("%s: expected changes %v, got %v", element, paths, got[element])
+		}
+	}
+	for _, diff := range result.Diffs {
+		if diff.Type == DiffTypeModified && len(diff.Changes) == 0 {
+			t.Errorf("%s %s: expected attribute changes", diff.Level, diff.Element)
+		}
+	}
+
+	// Block values are encoded as plain JSON objects
+	data, err := json.Marshal(result.Diffs)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `{"path":"assert[1]","action":"added","after":{"condition":"c == 3"}}`) {
+		t.Errorf("expected the added assertion in JSON, got %s", data)
+	}
+	// Text output renders the same changes
+	output := FormatTextOutput(result, config, true)
+	for _, want := range []string{
+		"-  command = plan\n+  command = apply",
+		`+   [1] = {"condition":"c == 3"}`,
+		`+   [1] = {"condition":"context.plan.applyable"}`,
+		" remote_state {\n    config = {\n  -   key = \"a\"\n  +   key = \"b\"\n    }\n }",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+	for _, unchanged := range []string{"no removals", "bucket", "b == 2"} {
+		if strings.Contains(output, unchanged) {
+			t.Errorf("expected unchanged %q to be hidden, got:\n%s", unchanged, output)
+		}
+	}
+
+	// Attributes without changes are not shown
+	token := IdentityToken{Name: "aws", Audience: `["aws.workload.identity"]`}
+	lines := formatAttributeDiff(Diff{Type: DiffTypeModified, Level: "identity_token", Element: "aws", Before: token, After: token}, config)
+	if strings.Contains(strings.Join(lines, "\n"), "audience") {
+		t.Errorf("expected the unchanged audience to be hidden, got %v", lines)
+	}
+}
+
+func TestCompareModules_VariableChanges(t *testing.T) {
+	left := parseTestModule(t, map[string]string{"variables.tf": `
+variable "env" {
+  type     = string
+  nullable = true
+
+  validation {
+    condition     = length(var.env) > 0
+    error_message = "env must not be empty."
+  }
+}
+`})
+	right := parseTestModule(t, map[string]string{"variables.tf": `
+variable "env" {
+  type      = string
+  nullable  = false
+  sensitive = true
+
+  validation {
+    condition     = contains(["dev", "prod"], var.env)
+    error_message = "env must be dev or prod."
+  }
+
+  validation {
+    condition     = length(var.env) > 0
+    error_message = "env must not be empty."
+  }
+}
+`})
+
+	env := right.Variables[0]
+	if env.IsNullable() || !env.Sensitive || len(env.Validations) != 2 {
+		t.Fatalf("expected a sensitive, non-nullable variable with 2 validations, got %+v", env)
+	}
+	if env.Validations[0].Condition != `contains(["dev", "prod"], var.env)` || env.Validations[0].ErrorMessage != "env must be dev or prod." {
+		t.Errorf("unexpected validation %+v", env.Validations[0])
+	}
+
+	config := ComparisonConfig{Levels: []ComparisonLevel{ComparisonLevelVariables}}
+	result := CompareModules(left, right, config)
+	if len(result.Diffs) != 1 {
+		t.Fatalf("expected 1 diff, got %d", len(result.Diffs))
+	}
+	var got []string
+	for _, change := range result.Diffs[0].Changes {
+		got = append(got, change.Path+" "+string(change.Action))
+	}
+	want := []string{"nullable modified", "sensitive modified", "validation[0] added"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("expected changes %v, got %v", want, got)
+	}
+
+	output := FormatTextOutput(result, config, true)
+	for _, line := range []string{"nullable = false", "sensitive = true", "var.env"} {
+		if !strings.Contains(output, line) {
+			t.Errorf("expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+}
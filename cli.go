@@ -13,15 +13,65 @@ var Revision = "HEAD"
 type GlobalOptions struct {
 }
 
+// RootCLI is the top-level command line; running tfdiff without a command compares two directories
+type RootCLI struct {
+	Version   VersionFlag  `name:"version" help:"show version"`
+	Compare   CLI          `cmd:"" default:"withargs" help:"compare two Terraform module directories (default)"`
+	Matrix    MatrixCLI    `cmd:"" help:"compare N module directories as a drift matrix"`
+	Consensus ConsensusCLI `cmd:"" help:"report directories that deviate from the majority of N module directories"`
+	MergeBase MergeBaseCLI `cmd:"" name:"merge-base" help:"compare two directories forked from a common base directory"`
+	Snapshot  SnapshotCLI  `cmd:"" help:"save the parsed module definition of a directory as a snapshot to compare against later"`
+	API       APICLI       `cmd:"" name:"api" help:"compare the public interface (variables, outputs, required_providers) of two versions of a module and classify each change as breaking, compatible or patch"`
+}
+
+// CLI holds the options of the compare command
 type CLI struct {
-	Version      VersionFlag `name:"version" help:"show version"`
-	LeftDir      string      `arg:"" name:"left" help:"path to left Terraform module directory"`
-	RightDir     string      `arg:"" name:"right" help:"path to right Terraform module directory"`
-	Levels       []string    `short:"l" name:"level" help:"comparison levels: module_calls, outputs, resources, data_sources, variables, all" default:"module_calls,outputs,resources,data_sources"`
-	IgnoreArgs   bool        `name:"ignore-args" help:"ignore argument differences" default:"false"`
-	IgnoreFiles  []string    `short:"i" name:"ignore-files" help:"ignore file patterns (repeatable)"`
-	OutputFormat string      `short:"o" name:"output" help:"output format: text, json" default:"text"`
-	NoColor      bool        `name:"no-color" help:"disable colored output"`
+	LeftDir         string   `arg:"" name:"left" help:"path to left Terraform module directory, .tar.gz/.zip archive (ARCHIVE//SUBDIR) or .json snapshot"`
+	RightDir        string   `arg:"" optional:"" name:"right" help:"path to right Terraform module directory, .tar.gz/.zip archive (ARCHIVE//SUBDIR) or .json snapshot"`
+	Levels          []string `short:"l" name:"level" help:"comparison levels: module_calls, outputs, resources, data_sources, variables, required_providers, tests, components, deployments, terragrunt, all" default:"module_calls,outputs,resources,data_sources"`
+	IgnoreArgs      bool     `name:"ignore-args" help:"ignore argument differences" default:"false"`
+	IgnoreFiles     []string `short:"i" name:"ignore-files" help:"ignore file patterns (repeatable)"`
+	OutputFormat    string   `short:"o" name:"output" help:"output format: text, json" default:"text"`
+	NoColor         bool     `name:"no-color" help:"disable colored output"`
+	Recursive       bool     `short:"r" name:"recursive" help:"discover module directories under left and right recursively and compare them by relative path"`
+	Reference       bool     `name:"reference" help:"treat left as a reference module and compare every right directory (paths or globs) against it"`
+	Instances       []string `arg:"" optional:"" name:"instances" help:"additional directories or globs compared against the reference with --reference"`
+	Git             string   `name:"git" placeholder:"REV1..REV2" help:"compare the left directory as it exists at two git revisions"`
+	Baseline        string   `name:"baseline" placeholder:"FILE" help:"hide the differences accepted in a baseline file and report only new, changed or resolved ones"`
+	WriteBaseline   string   `name:"write-baseline" placeholder:"FILE" help:"record the current differences as accepted differences in a baseline file"`
+	Rules           string   `name:"rules" placeholder:"FILE" help:"rules file declaring substitutions applied to right-side values before comparison and ignored attribute paths"`
+	IgnoreAttrs     []string `name:"ignore-attribute" placeholder:"PATH" help:"ignore an attribute path such as resource.aws_instance.*.tags.Environment or module.*.version (repeatable)"`
+	SetAttrs        []string `name:"set-attribute" placeholder:"PATH" help:"compare a list attribute path such as *.cidr_blocks regardless of element order (repeatable)"`
+	Include         []string `name:"include" placeholder:"ADDRESS" help:"compare only elements whose address matches a glob such as aws_iam_*.*, module.vpc or data.aws_ami.* (repeatable); elements without an address, such as tests and required providers, are left out"`
+	Exclude         []string `name:"exclude" placeholder:"ADDRESS" help:"skip elements whose address matches a glob (repeatable)"`
+	RenameThreshold float64  `name:"rename-threshold" placeholder:"SIMILARITY" help:"report a removed and an added element at least this similar (0 to 1) as renamed; 0 disables rename detection" default:"0"`
+}
+
+// ComparisonOptions holds the comparison and output options shared by the commands comparing
+// more than two directories
+type ComparisonOptions struct {
+	Levels       []string `short:"l" name:"level" help:"comparison levels: module_calls, outputs, resources, data_sources, variables, required_providers, tests, components, deployments, terragrunt, all" default:"module_calls,outputs,resources,data_sources"`
+	IgnoreArgs   bool     `name:"ignore-args" help:"ignore argument differences" default:"false"`
+	IgnoreFiles  []string `short:"i" name:"ignore-files" help:"ignore file patterns (repeatable)"`
+	OutputFormat string   `short:"o" name:"output" help:"output format: text, json" default:"text"`
+	NoColor      bool     `name:"no-color" help:"disable colored output"`
+}
+
+// MultiModuleOptions holds the options shared by commands comparing N module directories
+type MultiModuleOptions struct {
+	Dirs              []string `arg:"" name:"dirs" help:"paths to Terraform module directories, one per environment"`
+	ComparisonOptions `embed:""`
+}
+
+// MatrixCLI holds the options of the matrix command
+type MatrixCLI struct {
+	MultiModuleOptions `embed:""`
+	DiffOnly           bool `name:"diff-only" help:"keep only elements and attributes that differ between environments"`
+}
+
+// ConsensusCLI holds the options of the consensus command
+type ConsensusCLI struct {
+	MultiModuleOptions `embed:""`
 }
 
 type VersionFlag string
@@ -34,19 +84,70 @@ func (v VersionFlag) BeforeApply(app *kong.Kong, vars kong.Vars) error {
 	return nil
 }
 
+// MergeBaseCLI holds the options of the merge-base command
+type MergeBaseCLI struct {
+	BaseDir           string `arg:"" name:"base" help:"path to the common base Terraform module directory"`
+	LeftDir           string `arg:"" name:"left" help:"path to left Terraform module directory"`
+	RightDir          string `arg:"" name:"right" help:"path to right Terraform module directory"`
+	ComparisonOptions `embed:""`
+}
+
+// SnapshotCLI holds the options of the snapshot command
+type SnapshotCLI struct {
+	Dir         string   `arg:"" name:"dir" help:"path to Terraform module directory or .tar.gz/.zip archive (ARCHIVE//SUBDIR)"`
+	Output      string   `short:"o" name:"output" help:"path of the snapshot file to write (default: standard output)"`
+	IgnoreFiles []string `short:"i" name:"ignore-files" help:"ignore file patterns (repeatable)"`
+}
+
+// APICLI holds the options of the api command
+type APICLI struct {
+	LeftDir         string   `arg:"" name:"left" help:"path to the previous version of the module: directory, .tar.gz/.zip archive (ARCHIVE//SUBDIR) or .json snapshot"`
+	RightDir        string   `arg:"" name:"right" help:"path to the new version of the module: directory, .tar.gz/.zip archive (ARCHIVE//SUBDIR) or .json snapshot"`
+	IgnoreFiles     []string `short:"i" name:"ignore-files" help:"ignore file patterns (repeatable)"`
+	OutputFormat    string   `short:"o" name:"output" help:"output format: text, json" default:"text"`
+	NoColor         bool     `name:"no-color" help:"disable colored output"`
+	PreviousVersion string   `name:"previous-version" help:"semantic version of the left module; recommends the next version (major, minor or patch bump) from the interface changes"`
+}
+
+// ExitCodeUnexpectedDrift is the exit status when differences missing from the baseline are found
+const ExitCodeUnexpectedDrift = 2
+
+// ExitError is an error that requests a specific process exit status
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+func (e *ExitError) Unwrap() error { return e.Err }
+
 func RunCLI(ctx context.Context, args []string) error {
-	cli := CLI{
+	cli := RootCLI{
 		Version: VersionFlag("0.1.0"),
 	}
 	parser, err := kong.New(&cli)
 	if err != nil {
 		return fmt.Errorf("error creating CLI parser: %w", err)
 	}
-	_, err = parser.Parse(args)
+	kctx, err := parser.Parse(args)
 	if err != nil {
 		fmt.Printf("error parsing CLI: %v\n", err)
 		return fmt.Errorf("error parsing CLI: %w", err)
 	}
-	app := New(&cli)
-	return app.Run(ctx)
+
+	app := New(&cli.Compare)
+	switch kctx.Selected().Name {
+	case "matrix":
+		return app.RunMatrix(ctx, &cli.Matrix)
+	case "consensus":
+		return app.RunConsensus(ctx, &cli.Consensus)
+	case "merge-base":
+		return app.RunMergeBase(ctx, &cli.MergeBase)
+	case "snapshot":
+		return app.RunSnapshot(ctx, &cli.Snapshot)
+	case "api":
+		return app.RunAPI(ctx, &cli.API)
+	default:
+		return app.Run(ctx)
+	}
 }
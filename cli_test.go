@@ -58,6 +58,37 @@ func TestCLI_ParseArguments(t *testing.T) {
 				OutputFormat: "text",
 			},
 		},
+		{
+			name: "explicit compare command",
+			args: []string{"compare", "/path/left", "/path/right"},
+			expected: CLI{
+				LeftDir:      "/path/left",
+				RightDir:     "/path/right",
+				Levels:       []string{"module_calls", "outputs", "resources", "data_sources"},
+				OutputFormat: "text",
+			},
+		},
+		{
+			name: "matrix command",
+			args: []string{"matrix", "/path/left", "/path/right", "--diff-only"},
+		},
+		{
+			name: "consensus command",
+			args: []string{"consensus", "/path/left", "/path/right", "--output", "json"},
+		},
+		{
+			name: "merge-base command",
+			args: []string{"merge-base", "/path/left", "/path/left", "/path/right"},
+		},
+		{
+			name: "snapshot command",
+			args: []string{"snapshot", "/path/left"},
+		},
+		{
+			name:    "matrix with a single directory",
+			args:    []string{"matrix", "/path/left"},
+			wantErr: true,
+		},
 		{
 			name:    "missing arguments",
 			args:    []string{},
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
 	"os/signal"
@@ -13,8 +14,8 @@ var Version = "dev"
 var Revision = "HEAD"
 
 func init() {
-	Version = Version
-	Revision = Revision
+	tfdiff.Version = Version
+	tfdiff.Revision = Revision
 }
 
 func main() {
@@ -23,6 +24,10 @@ func main() {
 	defer stop()
 	if err := tfdiff.RunCLI(ctx, os.Args[1:]); err != nil {
 		log.Printf("error: %v", err)
+		var exitErr *tfdiff.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 }
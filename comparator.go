@@ -9,6 +9,11 @@ import (
 
 // CompareModules compares two module definitions and returns differences
 func CompareModules(left, right *ModuleDefinition, config ComparisonConfig) *ComparisonResult {
+	left, right = filterModule(left, config), filterModule(right, config)
+	if len(config.Substitutions) > 0 {
+		return compareWithSubstitutions(left, right, resolveIdentities(left, right, config))
+	}
+
 	result := &ComparisonResult{
 		LeftPath:  left.Path,
 		RightPath: right.Path,
@@ -31,6 +36,21 @@ func CompareModules(left, right *ModuleDefinition, config ComparisonConfig) *Com
 		
 		diffs = compareVariables(left.Variables, right.Variables)
 		result.Diffs = append(result.Diffs, diffs...)
+
+		diffs = compareRequiredProviders(left.RequiredProviders, right.RequiredProviders)
+		result.Diffs = append(result.Diffs, diffs...)
+
+		diffs = compareTestFiles(left.TestFiles, right.TestFiles)
+		result.Diffs = append(result.Diffs, diffs...)
+
+		diffs = compareComponents(left.Components, right.Components, config)
+		result.Diffs = append(result.Diffs, diffs...)
+
+		diffs = compareDeploymentConfigs(left, right, config)
+		result.Diffs = append(result.Diffs, diffs...)
+
+		diffs = compareTerragrunt(left.Terragrunt, right.Terragrunt, config)
+		result.Diffs = append(result.Diffs, diffs...)
 	} else {
 		// Compare based on configured levels
 		for _, level := range config.Levels {
@@ -50,24 +70,57 @@ func CompareModules(left, right *ModuleDefinition, config ComparisonConfig) *Com
 			case ComparisonLevelVariables:
 				diffs := compareVariables(left.Variables, right.Variables)
 				result.Diffs = append(result.Diffs, diffs...)
+			case ComparisonLevelRequiredProviders:
+				diffs := compareRequiredProviders(left.RequiredProviders, right.RequiredProviders)
+				result.Diffs = append(result.Diffs, diffs...)
+			case ComparisonLevelTests:
+				diffs := compareTestFiles(left.TestFiles, right.TestFiles)
+				result.Diffs = append(result.Diffs, diffs...)
+			case ComparisonLevelComponents:
+				diffs := compareComponents(left.Components, right.Components, config)
+				result.Diffs = append(result.Diffs, diffs...)
+			case ComparisonLevelDeployments:
+				diffs := compareDeploymentConfigs(left, right, config)
+				result.Diffs = append(result.Diffs, diffs...)
+			case ComparisonLevelTerragrunt:
+				diffs := compareTerragrunt(left.Terragrunt, right.Terragrunt, config)
+				result.Diffs = append(result.Diffs, diffs...)
 			}
 		}
 	}
 
+	if config.RenameThreshold > 0 {
+		result.Diffs = detectRenames(result.Diffs, config.RenameThreshold)
+	}
+
+	// Compute the attribute changes once for all output formats
+	attachChanges(result.Diffs, config)
+	classifyDiffs(result.Diffs)
+
 	// Calculate summary
-	for _, diff := range result.Diffs {
+	result.Summary = summarizeDiffs(result.Diffs)
+	result.Compatibility = overallCompatibility(result.Diffs)
+
+	return result
+}
+
+// summarizeDiffs counts diffs by type
+func summarizeDiffs(diffs []Diff) DiffSummary {
+	var summary DiffSummary
+	for _, diff := range diffs {
 		switch diff.Type {
 		case DiffTypeAdded:
-			result.Summary.Added++
+			summary.Added++
 		case DiffTypeRemoved:
-			result.Summary.Removed++
+			summary.Removed++
 		case DiffTypeModified:
-			result.Summary.Modified++
+			summary.Modified++
+		case DiffTypeRenamed:
+			summary.Renamed++
 		}
 	}
-	result.Summary.Total = len(result.Diffs)
-
-	return result
+	summary.Total = len(diffs)
+	return summary
 }
 
 // compareModuleCalls compares module calls between two modules
@@ -188,61 +241,45 @@ func compareOutputs(left, right []Output) []Diff {
 	return diffs
 }
 
-// compareResources compares resources between two modules
+// compareResources compares resources between two modules. Resources are paired by
+// matchResources, so a resource may be compared with a right resource of another name.
 func compareResources(left, right []Resource, config ComparisonConfig) []Diff {
 	var diffs []Diff
 
-	leftMap := make(map[string]Resource)
-	rightMap := make(map[string]Resource)
-
-	for _, r := range left {
-		key := fmt.Sprintf("%s.%s", r.Type, r.Name)
-		leftMap[key] = r
-	}
-	for _, r := range right {
-		key := fmt.Sprintf("%s.%s", r.Type, r.Name)
-		rightMap[key] = r
-	}
-
-	// Find added resources
-	for key, rightResource := range rightMap {
-		if _, exists := leftMap[key]; !exists {
+	for _, pair := range matchResources(left, right, config) {
+		switch {
+		case pair.left == nil:
+			key := resourceKey(*pair.right)
 			diffs = append(diffs, Diff{
 				Type:    DiffTypeAdded,
 				Level:   "resource",
 				Element: key,
-				After:   rightResource,
+				After:   *pair.right,
 				Message: fmt.Sprintf("Resource '%s' was added", key),
 			})
-		}
-	}
-
-	// Find removed resources
-	for key, leftResource := range leftMap {
-		if _, exists := rightMap[key]; !exists {
+		case pair.right == nil:
+			key := resourceKey(*pair.left)
 			diffs = append(diffs, Diff{
 				Type:    DiffTypeRemoved,
 				Level:   "resource",
 				Element: key,
-				Before:  leftResource,
+				Before:  *pair.left,
 				Message: fmt.Sprintf("Resource '%s' was removed", key),
 			})
-		}
-	}
-
-	// Find modified resources
-	for key, leftResource := range leftMap {
-		if rightResource, exists := rightMap[key]; exists {
-			if !resourcesEqual(leftResource, rightResource, config) {
-				diffs = append(diffs, Diff{
-					Type:    DiffTypeModified,
-					Level:   "resource",
-					Element: key,
-					Before:  leftResource,
-					After:   rightResource,
-					Message: fmt.Sprintf("Resource '%s' was modified", key),
-				})
+		case !resourcesEqual(*pair.left, *pair.right, config):
+			key := resourceKey(*pair.left)
+			message := fmt.Sprintf("Resource '%s' was modified", key)
+			if rightKey := resourceKey(*pair.right); rightKey != key {
+				message = fmt.Sprintf("Resource '%s' (matched with '%s') was modified", key, rightKey)
 			}
+			diffs = append(diffs, Diff{
+				Type:    DiffTypeModified,
+				Level:   "resource",
+				Element: key,
+				Before:  *pair.left,
+				After:   *pair.right,
+				Message: message,
+			})
 		}
 	}
 
@@ -257,11 +294,11 @@ func compareDataSources(left, right []DataSource, config ComparisonConfig) []Dif
 	rightMap := make(map[string]DataSource)
 
 	for _, ds := range left {
-		key := fmt.Sprintf("%s.%s", ds.Type, ds.Name)
+		key := dataSourceKey(ds)
 		leftMap[key] = ds
 	}
 	for _, ds := range right {
-		key := fmt.Sprintf("%s.%s", ds.Type, ds.Name)
+		key := dataSourceKey(ds)
 		rightMap[key] = ds
 	}
 
@@ -369,17 +406,605 @@ func compareVariables(left, right []Variable) []Diff {
 	return diffs
 }
 
+// compareRequiredProviders compares the provider requirements between two modules
+func compareRequiredProviders(left, right []RequiredProvider) []Diff {
+	var diffs []Diff
+
+	leftMap := make(map[string]RequiredProvider)
+	rightMap := make(map[string]RequiredProvider)
+
+	for _, p := range left {
+		leftMap[p.Name] = p
+	}
+	for _, p := range right {
+		rightMap[p.Name] = p
+	}
+
+	// Find added providers
+	for name, rightProvider := range rightMap {
+		if _, exists := leftMap[name]; !exists {
+			diffs = append(diffs, Diff{
+				Type:    DiffTypeAdded,
+				Level:   "required_provider",
+				Element: name,
+				After:   rightProvider,
+				Message: fmt.Sprintf("Required provider '%s' was added", name),
+			})
+		}
+	}
+
+	// Find removed providers
+	for name, leftProvider := range leftMap {
+		if _, exists := rightMap[name]; !exists {
+			diffs = append(diffs, Diff{
+				Type:    DiffTypeRemoved,
+				Level:   "required_provider",
+				Element: name,
+				Before:  leftProvider,
+				Message: fmt.Sprintf("Required provider '%s' was removed", name),
+			})
+		}
+	}
+
+	// Find modified providers
+	for name, leftProvider := range leftMap {
+		if rightProvider, exists := rightMap[name]; exists {
+			if !requiredProvidersEqual(leftProvider, rightProvider) {
+				diffs = append(diffs, Diff{
+					Type:    DiffTypeModified,
+					Level:   "required_provider",
+					Element: name,
+					Before:  leftProvider,
+					After:   rightProvider,
+					Message: fmt.Sprintf("Required provider '%s' was modified", name),
+				})
+			}
+		}
+	}
+
+	return diffs
+}
+
+// compareTestFiles compares Terraform test files and their run blocks between two modules
+func compareTestFiles(left, right []TestFile) []Diff {
+	var diffs []Diff
+
+	leftMap := make(map[string]TestFile)
+	rightMap := make(map[string]TestFile)
+
+	for _, tf := range left {
+		leftMap[tf.Path] = tf
+	}
+	for _, tf := range right {
+		rightMap[tf.Path] = tf
+	}
+
+	// Find added test files
+	for path, rightFile := range rightMap {
+		if _, exists := leftMap[path]; !exists {
+			diffs = append(diffs, Diff{
+				Type:    DiffTypeAdded,
+				Level:   "test_file",
+				Element: path,
+				After:   rightFile,
+				Message: fmt.Sprintf("Test file '%s' was added", path),
+			})
+		}
+	}
+
+	// Find removed test files
+	for path, leftFile := range leftMap {
+		if _, exists := rightMap[path]; !exists {
+			diffs = append(diffs, Diff{
+				Type:    DiffTypeRemoved,
+				Level:   "test_file",
+				Element: path,
+				Before:  leftFile,
+				Message: fmt.Sprintf("Test file '%s' was removed", path),
+			})
+		}
+	}
+
+	// Find modified test files and compare their runs
+	for path, leftFile := range leftMap {
+		rightFile, exists := rightMap[path]
+		if !exists {
+			continue
+		}
+		if !testFilesEqual(leftFile, rightFile) {
+			diffs = append(diffs, Diff{
+				Type:    DiffTypeModified,
+				Level:   "test_file",
+				Element: path,
+				Before:  leftFile,
+				After:   rightFile,
+				Message: fmt.Sprintf("Test file '%s' was modified", path),
+			})
+		}
+		diffs = append(diffs, compareTestRuns(path, leftFile.Runs, rightFile.Runs)...)
+	}
+
+	return diffs
+}
+
+// compareTestRuns compares run blocks of the same test file
+func compareTestRuns(path string, left, right []TestRun) []Diff {
+	var diffs []Diff
+
+	leftMap := make(map[string]TestRun)
+	rightMap := make(map[string]TestRun)
+
+	for _, run := range left {
+		leftMap[run.Name] = run
+	}
+	for _, run := range right {
+		rightMap[run.Name] = run
+	}
+
+	// Find added runs
+	for name, rightRun := range rightMap {
+		if _, exists := leftMap[name]; !exists {
+			key := testRunKey(path, name)
+			diffs = append(diffs, Diff{
+				Type:    DiffTypeAdded,
+				Level:   "test_run",
+				Element: key,
+				After:   rightRun,
+				Message: fmt.Sprintf("Test run '%s' was added", key),
+			})
+		}
+	}
+
+	// Find removed runs
+	for name, leftRun := range leftMap {
+		if _, exists := rightMap[name]; !exists {
+			key := testRunKey(path, name)
+			diffs = append(diffs, Diff{
+				Type:    DiffTypeRemoved,
+				Level:   "test_run",
+				Element: key,
+				Before:  leftRun,
+				Message: fmt.Sprintf("Test run '%s' was removed", key),
+			})
+		}
+	}
+
+	// Find modified runs
+	for name, leftRun := range leftMap {
+		if rightRun, exists := rightMap[name]; exists {
+			if !testRunsEqual(leftRun, rightRun) {
+				key := testRunKey(path, name)
+				diffs = append(diffs, Diff{
+					Type:    DiffTypeModified,
+					Level:   "test_run",
+					Element: key,
+					Before:  leftRun,
+					After:   rightRun,
+					Message: fmt.Sprintf("Test run '%s' was modified", key),
+				})
+			}
+		}
+	}
+
+	return diffs
+}
+
+// resourceKey returns the identity key of a resource (type.name)
+func resourceKey(r Resource) string {
+	return fmt.Sprintf("%s.%s", r.Type, r.Name)
+}
+
+// dataSourceKey returns the identity key of a data source (type.name)
+func dataSourceKey(ds DataSource) string {
+	return fmt.Sprintf("%s.%s", ds.Type, ds.Name)
+}
+
+// orchestrateRuleKey returns the identity key of an orchestrate rule (type.name)
+func orchestrateRuleKey(r OrchestrateRule) string {
+	return fmt.Sprintf("%s.%s", r.Type, r.Name)
+}
+
+// testRunKey returns the element key of a run block, qualified by its test file
+func testRunKey(path, name string) string {
+	return fmt.Sprintf("%s:run.%s", path, name)
+}
+
+// compareComponents compares Terraform Stacks components between two stacks
+func compareComponents(left, right []Component, config ComparisonConfig) []Diff {
+	var diffs []Diff
+
+	leftMap := make(map[string]Component)
+	rightMap := make(map[string]Component)
+
+	for _, c := range left {
+		leftMap[c.Name] = c
+	}
+	for _, c := range right {
+		rightMap[c.Name] = c
+	}
+
+	// Find added components
+	for name, rightComponent := range rightMap {
+		if _, exists := leftMap[name]; !exists {
+			diffs = append(diffs, Diff{
+				Type:    DiffTypeAdded,
+				Level:   "component",
+				Element: name,
+				After:   rightComponent,
+				Message: fmt.Sprintf("Component '%s' was added", name),
+			})
+		}
+	}
+
+	// Find removed components
+	for name, leftComponent := range leftMap {
+		if _, exists := rightMap[name]; !exists {
+			diffs = append(diffs, Diff{
+				Type:    DiffTypeRemoved,
+				Level:   "component",
+				Element: name,
+				Before:  leftComponent,
+				Message: fmt.Sprintf("Component '%s' was removed", name),
+			})
+		}
+	}
+
+	// Find modified components
+	for name, leftComponent := range leftMap {
+		if rightComponent, exists := rightMap[name]; exists {
+			if !componentsEqual(leftComponent, rightComponent, config) {
+				diffs = append(diffs, Diff{
+					Type:    DiffTypeModified,
+					Level:   "component",
+					Element: name,
+					Before:  leftComponent,
+					After:   rightComponent,
+					Message: fmt.Sprintf("Component '%s' was modified", name),
+				})
+			}
+		}
+	}
+
+	return diffs
+}
+
+// compareDeploymentConfigs compares deployments, orchestrate rules and identity tokens
+// defined in the Terraform Stacks deployment files of two stacks
+func compareDeploymentConfigs(left, right *ModuleDefinition, config ComparisonConfig) []Diff {
+	var diffs []Diff
+	diffs = append(diffs, compareDeployments(left.Deployments, right.Deployments, config)...)
+	diffs = append(diffs, compareOrchestrateRules(left.OrchestrateRules, right.OrchestrateRules)...)
+	diffs = append(diffs, compareIdentityTokens(left.IdentityTokens, right.IdentityTokens)...)
+	return diffs
+}
+
+// compareDeployments compares Terraform Stacks deployments between two stacks
+func compareDeployments(left, right []Deployment, config ComparisonConfig) []Diff {
+	var diffs []Diff
+
+	leftMap := make(map[string]Deployment)
+	rightMap := make(map[string]Deployment)
+
+	for _, d := range left {
+		leftMap[d.Name] = d
+	}
+	for _, d := range right {
+		rightMap[d.Name] = d
+	}
+
+	// Find added deployments
+	for name, rightDeployment := range rightMap {
+		if _, exists := leftMap[name]; !exists {
+			diffs = append(diffs, Diff{
+				Type:    DiffTypeAdded,
+				Level:   "deployment",
+				Element: name,
+				After:   rightDeployment,
+				Message: fmt.Sprintf("Deployment '%s' was added", name),
+			})
+		}
+	}
+
+	// Find removed deployments
+	for name, leftDeployment := range leftMap {
+		if _, exists := rightMap[name]; !exists {
+			diffs = append(diffs, Diff{
+				Type:    DiffTypeRemoved,
+				Level:   "deployment",
+				Element: name,
+				Before:  leftDeployment,
+				Message: fmt.Sprintf("Deployment '%s' was removed", name),
+			})
+		}
+	}
+
+	// Find modified deployments
+	for name, leftDeployment := range leftMap {
+		if rightDeployment, exists := rightMap[name]; exists {
+			if !deploymentsEqual(leftDeployment, rightDeployment, config) {
+				diffs = append(diffs, Diff{
+					Type:    DiffTypeModified,
+					Level:   "deployment",
+					Element: name,
+					Before:  leftDeployment,
+					After:   rightDeployment,
+					Message: fmt.Sprintf("Deployment '%s' was modified", name),
+				})
+			}
+		}
+	}
+
+	return diffs
+}
+
+// compareOrchestrateRules compares Terraform Stacks orchestrate rules between two stacks
+func compareOrchestrateRules(left, right []OrchestrateRule) []Diff {
+	var diffs []Diff
+
+	leftMap := make(map[string]OrchestrateRule)
+	rightMap := make(map[string]OrchestrateRule)
+
+	for _, r := range left {
+		key := orchestrateRuleKey(r)
+		leftMap[key] = r
+	}
+	for _, r := range right {
+		key := orchestrateRuleKey(r)
+		rightMap[key] = r
+	}
+
+	// Find added orchestrate rules
+	for key, rightRule := range rightMap {
+		if _, exists := leftMap[key]; !exists {
+			diffs = append(diffs, Diff{
+				Type:    DiffTypeAdded,
+				Level:   "orchestrate_rule",
+				Element: key,
+				After:   rightRule,
+				Message: fmt.Sprintf("Orchestrate rule '%s' was added", key),
+			})
+		}
+	}
+
+	// Find removed orchestrate rules
+	for key, leftRule := range leftMap {
+		if _, exists := rightMap[key]; !exists {
+			diffs = append(diffs, Diff{
+				Type:    DiffTypeRemoved,
+				Level:   "orchestrate_rule",
+				Element: key,
+				Before:  leftRule,
+				Message: fmt.Sprintf("Orchestrate rule '%s' was removed", key),
+			})
+		}
+	}
+
+	// Find modified orchestrate rules
+	for key, leftRule := range leftMap {
+		if rightRule, exists := rightMap[key]; exists {
+			if !reflect.DeepEqual(leftRule.Checks, rightRule.Checks) {
+				diffs = append(diffs, Diff{
+					Type:    DiffTypeModified,
+					Level:   "orchestrate_rule",
+					Element: key,
+					Before:  leftRule,
+					After:   rightRule,
+					Message: fmt.Sprintf("Orchestrate rule '%s' was modified", key),
+				})
+			}
+		}
+	}
+
+	return diffs
+}
+
+// compareIdentityTokens compares Terraform Stacks identity tokens between two stacks
+func compareIdentityTokens(left, right []IdentityToken) []Diff {
+	var diffs []Diff
+
+	leftMap := make(map[string]IdentityToken)
+	rightMap := make(map[string]IdentityToken)
+
+	for _, t := range left {
+		leftMap[t.Name] = t
+	}
+	for _, t := range right {
+		rightMap[t.Name] = t
+	}
+
+	// Find added identity tokens
+	for name, rightToken := range rightMap {
+		if _, exists := leftMap[name]; !exists {
+			diffs = append(diffs, Diff{
+				Type:    DiffTypeAdded,
+				Level:   "identity_token",
+				Element: name,
+				After:   rightToken,
+				Message: fmt.Sprintf("Identity token '%s' was added", name),
+			})
+		}
+	}
+
+	// Find removed identity tokens
+	for name, leftToken := range leftMap {
+		if _, exists := rightMap[name]; !exists {
+			diffs = append(diffs, Diff{
+				Type:    DiffTypeRemoved,
+				Level:   "identity_token",
+				Element: name,
+				Before:  leftToken,
+				Message: fmt.Sprintf("Identity token '%s' was removed", name),
+			})
+		}
+	}
+
+	// Find modified identity tokens
+	for name, leftToken := range leftMap {
+		if rightToken, exists := rightMap[name]; exists {
+			if !valuesEqual(leftToken.Audience, rightToken.Audience) {
+				diffs = append(diffs, Diff{
+					Type:    DiffTypeModified,
+					Level:   "identity_token",
+					Element: name,
+					Before:  leftToken,
+					After:   rightToken,
+					Message: fmt.Sprintf("Identity token '%s' was modified", name),
+				})
+			}
+		}
+	}
+
+	return diffs
+}
+
+// compareTerragrunt compares two Terragrunt configurations. The terraform source, each input,
+// each dependency, each include and the remote state are reported as separate elements.
+func compareTerragrunt(left, right *TerragruntConfig, config ComparisonConfig) []Diff {
+	if left == nil && right == nil {
+		return nil
+	}
+	if left == nil {
+		left = &TerragruntConfig{}
+	}
+	if right == nil {
+		right = &TerragruntConfig{}
+	}
+
+	var diffs []Diff
+
+	addDiff := func(element string, before, after interface{}, beforeExists, afterExists, equal bool) {
+		switch {
+		case !beforeExists && afterExists:
+			diffs = append(diffs, Diff{
+				Type:    DiffTypeAdded,
+				Level:   "terragrunt",
+				Element: element,
+				After:   after,
+				Message: fmt.Sprintf("Terragrunt '%s' was added", element),
+			})
+		case beforeExists && !afterExists:
+			diffs = append(diffs, Diff{
+				Type:    DiffTypeRemoved,
+				Level:   "terragrunt",
+				Element: element,
+				Before:  before,
+				Message: fmt.Sprintf("Terragrunt '%s' was removed", element),
+			})
+		case beforeExists && afterExists && !equal:
+			diffs = append(diffs, Diff{
+				Type:    DiffTypeModified,
+				Level:   "terragrunt",
+				Element: element,
+				Before:  before,
+				After:   after,
+				Message: fmt.Sprintf("Terragrunt '%s' was modified", element),
+			})
+		}
+	}
+
+	// Compare terraform source
+	addDiff("terraform.source", left.Source, right.Source, left.Source != "", right.Source != "", left.Source == right.Source)
+
+	// Compare inputs key by key
+	if !config.IgnoreArguments {
+		allKeys := make(map[string]bool)
+		for key := range left.Inputs {
+			allKeys[key] = true
+		}
+		for key := range right.Inputs {
+			allKeys[key] = true
+		}
+		for key := range allKeys {
+			leftValue, leftExists := left.Inputs[key]
+			rightValue, rightExists := right.Inputs[key]
+			addDiff("inputs."+key, leftValue, rightValue, leftExists, rightExists, valuesEqual(leftValue, rightValue))
+		}
+	}
+
+	// Compare dependencies by name
+	leftDeps := make(map[string]TerragruntDependency)
+	rightDeps := make(map[string]TerragruntDependency)
+	for _, dep := range left.Dependencies {
+		leftDeps[dep.Name] = dep
+	}
+	for _, dep := range right.Dependencies {
+		rightDeps[dep.Name] = dep
+	}
+	allDeps := make(map[string]bool)
+	for name := range leftDeps {
+		allDeps[name] = true
+	}
+	for name := range rightDeps {
+		allDeps[name] = true
+	}
+	for name := range allDeps {
+		leftDep, leftExists := leftDeps[name]
+		rightDep, rightExists := rightDeps[name]
+		addDiff("dependency."+name, leftDep, rightDep, leftExists, rightExists, leftDep.ConfigPath == rightDep.ConfigPath)
+	}
+
+	// Compare includes by name
+	leftIncludes := make(map[string]TerragruntInclude)
+	rightIncludes := make(map[string]TerragruntInclude)
+	for _, include := range left.Includes {
+		leftIncludes[include.Name] = include
+	}
+	for _, include := range right.Includes {
+		rightIncludes[include.Name] = include
+	}
+	allIncludes := make(map[string]bool)
+	for name := range leftIncludes {
+		allIncludes[name] = true
+	}
+	for name := range rightIncludes {
+		allIncludes[name] = true
+	}
+	for name := range allIncludes {
+		leftInclude, leftExists := leftIncludes[name]
+		rightInclude, rightExists := rightIncludes[name]
+		element := "include"
+		if name != "" {
+			element = "include." + name
+		}
+		addDiff(element, leftInclude, rightInclude, leftExists, rightExists, leftInclude.Path == rightInclude.Path)
+	}
+
+	// Compare remote state
+	remoteStateEqual := left.RemoteState != nil && right.RemoteState != nil &&
+		left.RemoteState.Backend == right.RemoteState.Backend &&
+		valueMapsEqual(left.RemoteState.Config, right.RemoteState.Config, attributePath{})
+	var leftRemoteState, rightRemoteState interface{}
+	if left.RemoteState != nil {
+		leftRemoteState = *left.RemoteState
+	}
+	if right.RemoteState != nil {
+		rightRemoteState = *right.RemoteState
+	}
+	addDiff("remote_state", leftRemoteState, rightRemoteState, left.RemoteState != nil, right.RemoteState != nil, remoteStateEqual)
+
+	return diffs
+}
+
 // Equality comparison functions
 
 func moduleCallsEqual(left, right ModuleCall, config ComparisonConfig) bool {
-	if left.Name != right.Name || left.Source != right.Source || left.Version != right.Version {
+	at := newAttributePath(config, "module_call", left.Name)
+	if left.Name != right.Name {
 		return false
 	}
-
-	if !config.IgnoreArguments && !reflect.DeepEqual(left.Args, right.Args) {
+	if left.Source != right.Source && !at.child("source").ignored() {
+		return false
+	}
+	if left.Version != right.Version && !at.child("version").ignored() {
 		return false
 	}
 
+	if !config.IgnoreArguments {
+		if !valueMapsEqual(left.Args, right.Args, at) {
+			return false
+		}
+	}
+
 	return true
 }
 
@@ -392,11 +1017,12 @@ func outputsEqual(left, right Output) bool {
 }
 
 func resourcesEqual(left, right Resource, config ComparisonConfig) bool {
-	if left.Type != right.Type || left.Name != right.Name {
+	// Names are not compared, as resources may be matched by identity rules
+	if left.Type != right.Type {
 		return false
 	}
 
-	if !config.IgnoreArguments && !configsEqual(left.Config, right.Config) {
+	if !config.IgnoreArguments && !configsEqualAt(left.Config, right.Config, newAttributePath(config, "resource", resourceKey(left))) {
 		return false
 	}
 
@@ -405,6 +1031,12 @@ func resourcesEqual(left, right Resource, config ComparisonConfig) bool {
 
 // configsEqual compares two config maps, handling nested blocks and JSON strings semantically
 func configsEqual(left, right map[string]interface{}) bool {
+	return configsEqualAt(left, right, attributePath{})
+}
+
+// configsEqualAt compares two config maps at an attribute path, skipping ignored attributes
+func configsEqualAt(left, right map[string]interface{}, at attributePath) bool {
+	left, right = withoutIgnored(left, at), withoutIgnored(right, at)
 	if len(left) != len(right) {
 		return false
 	}
@@ -415,7 +1047,12 @@ func configsEqual(left, right map[string]interface{}) bool {
 			return false
 		}
 
-		if !valuesEqual(leftValue, rightValue) {
+		// Nested block types extend the path without the _blocks key
+		child := at
+		if key != "_blocks" {
+			child = at.child(key)
+		}
+		if !valuesEqualAt(leftValue, rightValue, child) {
 			return false
 		}
 	}
@@ -425,7 +1062,25 @@ func configsEqual(left, right map[string]interface{}) bool {
 
 // valuesEqual compares two interface{} values recursively
 func valuesEqual(left, right interface{}) bool {
+	return valuesEqualAt(left, right, attributePath{})
+}
+
+// valuesEqualAt compares two values at an attribute path. With ignore patterns, JSON
+// strings are compared key by key so that ignored keys within them are skipped.
+func valuesEqualAt(left, right interface{}, at attributePath) bool {
 	switch leftVal := left.(type) {
+	case Value:
+		rightVal, ok := right.(Value)
+		if !ok {
+			return false
+		}
+		// Collections are compared element by element when keys are ignored or lists
+		// compared as sets
+		if at.active() && isCollectionPair(leftVal.Interface(), rightVal.Interface()) {
+			return valuesEqualAt(leftVal.Interface(), rightVal.Interface(), at)
+		}
+		return leftVal.Equal(rightVal)
+		
 	case string:
 		rightVal, ok := right.(string)
 		if !ok {
@@ -433,6 +1088,12 @@ func valuesEqual(left, right interface{}) bool {
 		}
 		// Try to parse as JSON for semantic comparison
 		if isJSON(leftVal) && isJSON(rightVal) {
+			if at.active() {
+				var leftData, rightData interface{}
+				json.Unmarshal([]byte(leftVal), &leftData)
+				json.Unmarshal([]byte(rightVal), &rightData)
+				return valuesEqualAt(leftData, rightData, at)
+			}
 			return jsonEqual(leftVal, rightVal)
 		}
 		return leftVal == rightVal
@@ -442,7 +1103,34 @@ func valuesEqual(left, right interface{}) bool {
 		if !ok {
 			return false
 		}
-		return configsEqual(leftVal, rightVal)
+		return configsEqualAt(leftVal, rightVal, at)
+		
+	case map[string][]map[string]interface{}:
+		rightVal, ok := right.(map[string][]map[string]interface{})
+		if !ok {
+			return false
+		}
+		if !at.active() {
+			return reflect.DeepEqual(left, right)
+		}
+		blockTypes := make(map[string]bool)
+		for blockType := range leftVal {
+			blockTypes[blockType] = true
+		}
+		for blockType := range rightVal {
+			blockTypes[blockType] = true
+		}
+		for blockType := range blockTypes {
+			if at.child(blockType).ignored() {
+				continue
+			}
+			leftBlocks, leftExists := leftVal[blockType]
+			rightBlocks, rightExists := rightVal[blockType]
+			if leftExists != rightExists || !sliceMapsEqualAt(leftBlocks, rightBlocks, at.child(blockType)) {
+				return false
+			}
+		}
+		return true
 		
 	case []map[string]interface{}:
 		rightVal, ok := right.([]map[string]interface{})
@@ -454,7 +1142,7 @@ func valuesEqual(left, right interface{}) bool {
 		}
 		// For nested blocks, we need to compare them in a way that's order-independent
 		// for blocks that can be reordered (like security group rules)
-		return sliceMapsEqual(leftVal, rightVal)
+		return sliceMapsEqualAt(leftVal, rightVal, at)
 		
 	case []interface{}:
 		rightVal, ok := right.([]interface{})
@@ -464,8 +1152,11 @@ func valuesEqual(left, right interface{}) bool {
 		if len(leftVal) != len(rightVal) {
 			return false
 		}
+		if at.isSet() {
+			return len(unmatchedElements(leftVal, rightVal, at)) == 0
+		}
 		for i, leftItem := range leftVal {
-			if !valuesEqual(leftItem, rightVal[i]) {
+			if !valuesEqualAt(leftItem, rightVal[i], at) {
 				return false
 			}
 		}
@@ -479,6 +1170,11 @@ func valuesEqual(left, right interface{}) bool {
 
 // sliceMapsEqual compares two slices of maps, handling order-independent comparison
 func sliceMapsEqual(left, right []map[string]interface{}) bool {
+	return sliceMapsEqualAt(left, right, attributePath{})
+}
+
+// sliceMapsEqualAt compares two slices of maps at an attribute path, handling order-independent comparison
+func sliceMapsEqualAt(left, right []map[string]interface{}, at attributePath) bool {
 	if len(left) != len(right) {
 		return false
 	}
@@ -489,7 +1185,7 @@ func sliceMapsEqual(left, right []map[string]interface{}) bool {
 	for _, leftItem := range left {
 		found := false
 		for j, rightItem := range right {
-			if !matched[j] && configsEqual(leftItem, rightItem) {
+			if !matched[j] && configsEqualAt(leftItem, rightItem, at) {
 				matched[j] = true
 				found = true
 				break
@@ -529,7 +1225,7 @@ func dataSourcesEqual(left, right DataSource, config ComparisonConfig) bool {
 		return false
 	}
 
-	if !config.IgnoreArguments && !configsEqual(left.Config, right.Config) {
+	if !config.IgnoreArguments && !configsEqualAt(left.Config, right.Config, newAttributePath(config, "data_source", dataSourceKey(left))) {
 		return false
 	}
 
@@ -541,17 +1237,110 @@ func variablesEqual(left, right Variable) bool {
 		return false
 	}
 
-	// Handle DefaultValue comparison
-	leftVal := left.DefaultValue
-	rightVal := right.DefaultValue
+	if left.IsNullable() != right.IsNullable() || left.Sensitive != right.Sensitive {
+		return false
+	}
 
-	// If both are JSON strings, parse and compare structurally
-	if isJSON(leftVal) && isJSON(rightVal) {
-		return jsonEqual(leftVal, rightVal)
+	// Validations are compared regardless of their order
+	if len(left.Validations) != len(right.Validations) {
+		return false
 	}
+	matched := make([]bool, len(right.Validations))
+	for _, leftValidation := range left.Validations {
+		found := false
+		for j, rightValidation := range right.Validations {
+			if !matched[j] && leftValidation == rightValidation {
+				matched[j] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return optionalValuesEqual(left.DefaultValue, right.DefaultValue)
+}
+
+func requiredProvidersEqual(left, right RequiredProvider) bool {
+	return left.Name == right.Name && left.Source == right.Source && left.Version == right.Version &&
+		reflect.DeepEqual(left.ConfigurationAliases, right.ConfigurationAliases)
+}
+
+func testFilesEqual(left, right TestFile) bool {
+	if left.Path != right.Path || !reflect.DeepEqual(left.Variables, right.Variables) {
+		return false
+	}
+
+	return reflect.DeepEqual(testProvidersByKey(left.Providers), testProvidersByKey(right.Providers))
+}
+
+// testProvidersByKey indexes the provider blocks of a test file by name and alias, so that
+// reordered blocks compare equal
+func testProvidersByKey(providers []TestProvider) map[string]TestProvider {
+	result := make(map[string]TestProvider, len(providers))
+	for _, provider := range providers {
+		key := provider.Name
+		if provider.Alias != "" {
+			key += "." + provider.Alias
+		}
+		result[key] = provider
+	}
+	return result
+}
+
+func testRunsEqual(left, right TestRun) bool {
+	if left.Name != right.Name || left.Command != right.Command || left.Module != right.Module {
+		return false
+	}
+
+	if !reflect.DeepEqual(left.Variables, right.Variables) || !reflect.DeepEqual(left.ExpectFailures, right.ExpectFailures) {
+		return false
+	}
+
+	// Assertions are compared regardless of their order
+	if len(left.Assertions) != len(right.Assertions) {
+		return false
+	}
+	matched := make([]bool, len(right.Assertions))
+	for _, leftAssert := range left.Assertions {
+		found := false
+		for j, rightAssert := range right.Assertions {
+			if !matched[j] && leftAssert == rightAssert {
+				matched[j] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func componentsEqual(left, right Component, config ComparisonConfig) bool {
+	if left.Name != right.Name || left.Source != right.Source || left.Version != right.Version || left.ForEach != right.ForEach {
+		return false
+	}
+
+	if !valueMapsEqual(left.Providers, right.Providers, attributePath{}) {
+		return false
+	}
+
+	if !config.IgnoreArguments && !valueMapsEqual(left.Inputs, right.Inputs, attributePath{}) {
+		return false
+	}
+
+	return true
+}
 
-	// Otherwise, use simple string comparison
-	return leftVal == rightVal
+// deploymentsEqual compares two deployments. Their inputs are arguments, ignored like those of
+// components.
+func deploymentsEqual(left, right Deployment, config ComparisonConfig) bool {
+	return left.Name == right.Name && (config.IgnoreArguments || valueMapsEqual(left.Inputs, right.Inputs, attributePath{}))
 }
 
 // containsLevel checks if a slice of ComparisonLevel contains a specific level
@@ -196,12 +196,12 @@ func TestVariableComparison_ArrayDifference(t *testing.T) {
 			left: Variable{
 				Name:         "availability_zones",
 				Type:         "list(string)",
-				DefaultValue: `["us-west-2a", "us-west-2b"]`,
+				DefaultValue: mustParseValue(`["us-west-2a", "us-west-2b"]`),
 			},
 			right: Variable{
 				Name:         "availability_zones",
 				Type:         "list(string)",
-				DefaultValue: `["us-west-2a", "us-west-2b"]`,
+				DefaultValue: mustParseValue(`["us-west-2a", "us-west-2b"]`),
 			},
 			expected: true,
 		},
@@ -210,12 +210,12 @@ func TestVariableComparison_ArrayDifference(t *testing.T) {
 			left: Variable{
 				Name:         "availability_zones",
 				Type:         "list(string)",
-				DefaultValue: `["us-west-2a", "us-west-2b"]`,
+				DefaultValue: mustParseValue(`["us-west-2a", "us-west-2b"]`),
 			},
 			right: Variable{
 				Name:         "availability_zones",
 				Type:         "list(string)",
-				DefaultValue: `["us-west-2a", "us-west-2b", "us-west-2c"]`,
+				DefaultValue: mustParseValue(`["us-west-2a", "us-west-2b", "us-west-2c"]`),
 			},
 			expected: false,
 		},
@@ -224,12 +224,12 @@ func TestVariableComparison_ArrayDifference(t *testing.T) {
 			left: Variable{
 				Name:         "availability_zones",
 				Type:         "list(string)",
-				DefaultValue: `["us-west-2a", "us-west-2b"]`,
+				DefaultValue: mustParseValue(`["us-west-2a", "us-west-2b"]`),
 			},
 			right: Variable{
 				Name:         "availability_zones",
 				Type:         "list(string)",
-				DefaultValue: `["us-west-2b", "us-west-2a"]`,
+				DefaultValue: mustParseValue(`["us-west-2b", "us-west-2a"]`),
 			},
 			expected: false, // Arrays should preserve order
 		},
@@ -238,12 +238,12 @@ func TestVariableComparison_ArrayDifference(t *testing.T) {
 			left: Variable{
 				Name:         "environment",
 				Type:         "string",
-				DefaultValue: `"production"`,
+				DefaultValue: mustParseValue(`"production"`),
 			},
 			right: Variable{
 				Name:         "environment",
 				Type:         "string",
-				DefaultValue: `"staging"`,
+				DefaultValue: mustParseValue(`"staging"`),
 			},
 			expected: false,
 		},
@@ -264,12 +264,12 @@ func TestCompareVariables_WithArrayDifference(t *testing.T) {
 		{
 			Name:         "availability_zones",
 			Type:         "list(string)",
-			DefaultValue: `["us-west-2a", "us-west-2b"]`,
+			DefaultValue: mustParseValue(`["us-west-2a", "us-west-2b"]`),
 		},
 		{
 			Name:         "environment",
 			Type:         "string", 
-			DefaultValue: "production",
+			DefaultValue: mustParseValue(`"production"`),
 		},
 	}
 
@@ -277,12 +277,12 @@ func TestCompareVariables_WithArrayDifference(t *testing.T) {
 		{
 			Name:         "availability_zones",
 			Type:         "list(string)",
-			DefaultValue: `["us-west-2a", "us-west-2b", "us-west-2c"]`,
+			DefaultValue: mustParseValue(`["us-west-2a", "us-west-2b", "us-west-2c"]`),
 		},
 		{
 			Name:         "environment",
 			Type:         "string",
-			DefaultValue: "staging",
+			DefaultValue: mustParseValue(`"staging"`),
 		},
 	}
 
@@ -0,0 +1,237 @@
+package tfdiff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Compatibility classifies the effect of a difference on the callers of a module
+type Compatibility string
+
+const (
+	// CompatibilityBreaking changes the module interface in a way callers may have to adapt to
+	CompatibilityBreaking Compatibility = "breaking"
+	// CompatibilityCompatible extends the module interface without affecting existing callers
+	CompatibilityCompatible Compatibility = "compatible"
+	// CompatibilityPatch leaves the module interface unchanged
+	CompatibilityPatch Compatibility = "patch"
+)
+
+// interfaceLevels lists the comparison levels of the public interface of a module
+var interfaceLevels = []ComparisonLevel{
+	ComparisonLevelVariables,
+	ComparisonLevelOutputs,
+	ComparisonLevelRequiredProviders,
+}
+
+// CompareInterfaces compares the public interface of two versions of a module: the
+// variables, the outputs and the required providers. Each diff is classified by its
+// compatibility, and the result by the strictest one.
+func CompareInterfaces(previous, current *ModuleDefinition) *ComparisonResult {
+	result := CompareModules(previous, current, ComparisonConfig{Levels: interfaceLevels})
+
+	// Variables, outputs and required providers, each by name
+	rank := map[string]int{"variable": 0, "output": 1, "required_provider": 2}
+	sort.SliceStable(result.Diffs, func(i, j int) bool {
+		left, right := result.Diffs[i], result.Diffs[j]
+		if left.Level != right.Level {
+			return rank[left.Level] < rank[right.Level]
+		}
+		return left.Element < right.Element
+	})
+	return result
+}
+
+// rank orders compatibilities from the least to the most strict
+func (c Compatibility) rank() int {
+	switch c {
+	case CompatibilityPatch:
+		return 1
+	case CompatibilityCompatible:
+		return 2
+	case CompatibilityBreaking:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// classifyDiffs sets the compatibility of each diff
+func classifyDiffs(diffs []Diff) {
+	for i := range diffs {
+		diffs[i].Compatibility, diffs[i].CompatibilityReason = classifyDiff(diffs[i])
+	}
+}
+
+// overallCompatibility returns the strictest compatibility of the diffs, or an empty one
+// without diffs
+func overallCompatibility(diffs []Diff) Compatibility {
+	var overall Compatibility
+	for _, diff := range diffs {
+		if diff.Compatibility.rank() > overall.rank() {
+			overall = diff.Compatibility
+		}
+	}
+	return overall
+}
+
+// classification collects the reasons of the strictest compatibility found so far
+type classification struct {
+	compatibility Compatibility
+	reasons       []string
+}
+
+// add records a reason, keeping only the reasons of the strictest compatibility
+func (c *classification) add(compatibility Compatibility, reason string) {
+	switch {
+	case compatibility.rank() > c.compatibility.rank():
+		c.compatibility, c.reasons = compatibility, []string{reason}
+	case compatibility == c.compatibility:
+		for _, existing := range c.reasons {
+			if existing == reason {
+				return
+			}
+		}
+		c.reasons = append(c.reasons, reason)
+	}
+}
+
+// result returns the compatibility and its reasons, or fallback as a patch without any
+func (c *classification) result(fallback string) (Compatibility, string) {
+	if c.compatibility == "" {
+		return CompatibilityPatch, fallback
+	}
+	return c.compatibility, strings.Join(c.reasons, "; ")
+}
+
+// classifyDiff classifies a diff by its effect on the public interface of a module: the
+// variables callers set, the outputs they read and the providers they pass. Differences
+// of other elements leave the interface unchanged. Modified elements are classified by
+// their attribute changes, so that ignored attributes do not count.
+func classifyDiff(diff Diff) (Compatibility, string) {
+	switch diff.Level {
+	case "variable":
+		return classifyVariableDiff(diff)
+	case "output":
+		return classifyOutputDiff(diff)
+	case "required_provider":
+		return classifyRequiredProviderDiff(diff)
+	default:
+		return CompatibilityPatch, "not part of the module interface"
+	}
+}
+
+// classifyVariableDiff classifies a variable diff. Callers must set new variables without a
+// default and stop setting removed ones, and the values they pass may be rejected by a new
+// validation or when null is no longer accepted.
+func classifyVariableDiff(diff Diff) (Compatibility, string) {
+	switch diff.Type {
+	case DiffTypeAdded:
+		if after, ok := diff.After.(Variable); ok && after.DefaultValue == nil {
+			return CompatibilityBreaking, "new variable without a default is required"
+		}
+		return CompatibilityCompatible, "new variable has a default"
+	case DiffTypeRemoved:
+		return CompatibilityBreaking, "variable was removed"
+	case DiffTypeRenamed:
+		return CompatibilityBreaking, fmt.Sprintf("variable was renamed from %s", diff.RenamedFrom)
+	}
+
+	var c classification
+	for _, change := range diff.Changes {
+		switch {
+		case change.Path == "type":
+			if after, _ := change.After.(string); after == "" || after == "any" {
+				c.add(CompatibilityCompatible, "type was widened to any")
+			} else {
+				c.add(CompatibilityBreaking, "type changed")
+			}
+		case change.Path == "default" && change.Action == DiffTypeRemoved:
+			c.add(CompatibilityBreaking, "default was removed, so the variable is required")
+		case change.Path == "default" && change.Action == DiffTypeAdded:
+			c.add(CompatibilityCompatible, "default was added, so the variable is optional")
+		case change.Path == "default" || strings.HasPrefix(change.Path, "default.") || strings.HasPrefix(change.Path, "default["):
+			c.add(CompatibilityCompatible, "default value changed")
+		case change.Path == "description":
+			c.add(CompatibilityPatch, "description changed")
+		case change.Path == "nullable":
+			if nullable, _ := change.After.(bool); nullable {
+				c.add(CompatibilityCompatible, "variable accepts null")
+			} else {
+				c.add(CompatibilityBreaking, "variable no longer accepts null")
+			}
+		case change.Path == "sensitive":
+			if sensitive, _ := change.After.(bool); sensitive {
+				c.add(CompatibilityBreaking, "variable became sensitive")
+			} else {
+				c.add(CompatibilityCompatible, "variable is no longer sensitive")
+			}
+		case strings.HasPrefix(change.Path, "validation[") && change.Action == DiffTypeAdded:
+			c.add(CompatibilityBreaking, "new validation may reject values callers pass")
+		case strings.HasPrefix(change.Path, "validation["):
+			c.add(CompatibilityCompatible, "validation was removed")
+		}
+	}
+	return c.result("variable was modified")
+}
+
+// classifyOutputDiff classifies an output diff. Callers reading a removed output break, and
+// so do callers using an output that became sensitive where sensitive values are not allowed.
+func classifyOutputDiff(diff Diff) (Compatibility, string) {
+	switch diff.Type {
+	case DiffTypeAdded:
+		return CompatibilityCompatible, "new output"
+	case DiffTypeRemoved:
+		return CompatibilityBreaking, "output was removed"
+	case DiffTypeRenamed:
+		return CompatibilityBreaking, fmt.Sprintf("output was renamed from %s", diff.RenamedFrom)
+	}
+
+	var c classification
+	for _, change := range diff.Changes {
+		switch change.Path {
+		case "sensitive":
+			if sensitive, _ := change.After.(bool); sensitive {
+				c.add(CompatibilityBreaking, "output became sensitive")
+			} else {
+				c.add(CompatibilityCompatible, "output is no longer sensitive")
+			}
+		case "value":
+			c.add(CompatibilityPatch, "value changed")
+		case "description":
+			c.add(CompatibilityPatch, "description changed")
+		}
+	}
+	return c.result("output was modified")
+}
+
+// classifyRequiredProviderDiff classifies a required provider diff. A changed source or
+// version constraint may no longer match the provider the callers use, and callers must pass
+// a provider configuration for each configuration alias.
+func classifyRequiredProviderDiff(diff Diff) (Compatibility, string) {
+	switch diff.Type {
+	case DiffTypeAdded:
+		if after, ok := diff.After.(RequiredProvider); ok && len(after.ConfigurationAliases) > 0 {
+			return CompatibilityBreaking, "new required provider has configuration aliases callers must pass"
+		}
+		return CompatibilityCompatible, "new required provider"
+	case DiffTypeRemoved:
+		return CompatibilityCompatible, "provider is no longer required"
+	}
+
+	var c classification
+	for _, change := range diff.Changes {
+		switch {
+		case change.Path == "source":
+			c.add(CompatibilityBreaking, "provider source changed")
+		case change.Path == "version":
+			c.add(CompatibilityBreaking, "version constraint changed")
+		case strings.HasPrefix(change.Path, "configuration_aliases") && change.Action == DiffTypeAdded:
+			c.add(CompatibilityBreaking, "new configuration alias callers must pass")
+		case strings.HasPrefix(change.Path, "configuration_aliases"):
+			c.add(CompatibilityCompatible, "configuration alias is no longer required")
+		}
+	}
+	return c.result("required provider was modified")
+}
@@ -0,0 +1,272 @@
+package tfdiff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompareInterfaces(t *testing.T) {
+	previous := parseTestModule(t, map[string]string{
+		"versions.tf": `
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = ">= 4.0"
+    }
+    random = ">= 3.0"
+  }
+}
+`,
+		"variables.tf": `
+variable "name" {
+  type = string
+}
+
+variable "region" {
+  type        = string
+  description = "Region"
+  default     = "us-east-1"
+}
+
+variable "zones" {
+  type    = list(string)
+  default = ["a"]
+}
+
+variable "old" {
+  default = "x"
+}
+
+variable "env" {
+  type = string
+}
+
+variable "password" {
+  type = string
+}
+
+variable "size" {
+  type     = number
+  default  = 1
+  nullable = false
+}
+`,
+		"outputs.tf": `
+output "id" {
+  value = aws_vpc.main.id
+}
+
+output "arn" {
+  value = aws_vpc.main.arn
+}
+`,
+		"main.tf": `
+resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+}
+`,
+	})
+	current := parseTestModule(t, map[string]string{
+		"versions.tf": `
+terraform {
+  required_providers {
+    aws = {
+      source                = "hashicorp/aws"
+      version               = ">= 4.0"
+      configuration_aliases = [aws.east]
+    }
+  }
+}
+`,
+		"variables.tf": `
+variable "name" {
+  type = number
+}
+
+variable "region" {
+  type        = string
+  description = "AWS region"
+  default     = "us-east-1"
+}
+
+variable "zones" {
+  type    = list(string)
+  default = ["a", "b"]
+}
+
+variable "tags" {
+  type    = map(string)
+  default = {}
+}
+
+variable "cidr" {
+  type = string
+}
+
+variable "env" {
+  type = string
+
+  validation {
+    condition     = contains(["dev", "prod"], var.env)
+    error_message = "env must be dev or prod."
+  }
+}
+
+variable "password" {
+  type      = string
+  sensitive = true
+}
+
+variable "size" {
+  type    = number
+  default = 1
+}
+`,
+		"outputs.tf": `
+output "id" {
+  value     = aws_vpc.main.id
+  sensitive = true
+}
+
+output "vpc" {
+  value = aws_vpc.main
+}
+`,
+		"main.tf": `
+resource "aws_vpc" "main" {
+  cidr_block = "10.1.0.0/16"
+}
+`,
+	})
+
+	result := CompareInterfaces(previous, current)
+
+	want := []string{
+		"variable cidr: breaking",
+		"variable env: breaking",
+		"variable name: breaking",
+		"variable old: breaking",
+		"variable password: breaking",
+		"variable region: patch",
+		"variable size: compatible",
+		"variable tags: compatible",
+		"variable zones: compatible",
+		"output arn: breaking",
+		"output id: breaking",
+		"output vpc: compatible",
+		"required_provider aws: breaking",
+		"required_provider random: compatible",
+	}
+	var got []string
+	for _, diff := range result.Diffs {
+		got = append(got, diff.Level+" "+diff.Element+": "+string(diff.Compatibility))
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected classifications\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+	if result.Compatibility != CompatibilityBreaking {
+		t.Errorf("expected the result to be breaking, got %q", result.Compatibility)
+	}
+
+	output := FormatAPIOutput(result, true)
+	for _, line := range []string{
+		`  variable "cidr": new variable without a default is required`,
+		`  required_provider "aws": new configuration alias callers must pass`,
+		`  variable "zones": default value changed`,
+		`  variable "env": new validation may reject values callers pass`,
+		`  variable "password": variable became sensitive`,
+		`Compatibility: breaking`,
+	} {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+}
+
+func TestClassifyDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		diff Diff
+		want Compatibility
+	}{
+		{
+			name: "optional variable became required",
+			diff: Diff{Type: DiffTypeModified, Level: "variable", Changes: []AttributeChange{
+				{Path: "default", Action: DiffTypeRemoved, Before: StringValue("a")},
+			}},
+			want: CompatibilityBreaking,
+		},
+		{
+			name: "required variable became optional",
+			diff: Diff{Type: DiffTypeModified, Level: "variable", Changes: []AttributeChange{
+				{Path: "default", Action: DiffTypeAdded, After: StringValue("a")},
+			}},
+			want: CompatibilityCompatible,
+		},
+		{
+			name: "type widened to any",
+			diff: Diff{Type: DiffTypeModified, Level: "variable", Changes: []AttributeChange{
+				{Path: "type", Action: DiffTypeModified, Before: "string", After: "any"},
+			}},
+			want: CompatibilityCompatible,
+		},
+		{
+			name: "variable no longer accepts null",
+			diff: Diff{Type: DiffTypeModified, Level: "variable", Changes: []AttributeChange{
+				{Path: "nullable", Action: DiffTypeModified, Before: true, After: false},
+			}},
+			want: CompatibilityBreaking,
+		},
+		{
+			name: "variable validation removed",
+			diff: Diff{Type: DiffTypeModified, Level: "variable", Changes: []AttributeChange{
+				{Path: "validation[0]", Action: DiffTypeRemoved, Before: map[string]interface{}{"condition": "length(var.name) > 0"}},
+			}},
+			want: CompatibilityCompatible,
+		},
+		{
+			name: "output no longer sensitive",
+			diff: Diff{Type: DiffTypeModified, Level: "output", Changes: []AttributeChange{
+				{Path: "sensitive", Action: DiffTypeModified, Before: true, After: false},
+			}},
+			want: CompatibilityCompatible,
+		},
+		{
+			name: "output value",
+			diff: Diff{Type: DiffTypeModified, Level: "output", Changes: []AttributeChange{
+				{Path: "value", Action: DiffTypeModified, Before: "a", After: "b"},
+			}},
+			want: CompatibilityPatch,
+		},
+		{
+			name: "renamed output",
+			diff: Diff{Type: DiffTypeRenamed, Level: "output", RenamedFrom: "old"},
+			want: CompatibilityBreaking,
+		},
+		{
+			name: "provider source",
+			diff: Diff{Type: DiffTypeModified, Level: "required_provider", Changes: []AttributeChange{
+				{Path: "source", Action: DiffTypeModified, Before: "hashicorp/aws", After: "example/aws"},
+			}},
+			want: CompatibilityBreaking,
+		},
+		{
+			name: "removed resource",
+			diff: Diff{Type: DiffTypeRemoved, Level: "resource"},
+			want: CompatibilityPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := classifyDiff(tt.diff)
+			if got != tt.want {
+				t.Errorf("expected %s, got %s (%s)", tt.want, got, reason)
+			}
+			if reason == "" {
+				t.Error("expected a reason")
+			}
+		})
+	}
+}
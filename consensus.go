@@ -0,0 +1,110 @@
+package tfdiff
+
+// elementPresent is the value of an element-level outlier for an element that exists
+const elementPresent = "(present)"
+
+// FindOutliers computes the majority value of every element and attribute across several
+// modules and reports the modules that deviate from it. Elements and attributes without a
+// strict majority are reported as conflicts instead.
+func FindOutliers(modules []*ModuleDefinition, config ComparisonConfig) *ConsensusResult {
+	matrix := BuildDriftMatrix(modules, config)
+
+	result := &ConsensusResult{
+		Directories: matrix.Environments,
+		Outliers:    []Outlier{},
+		NoConsensus: []ConsensusConflict{},
+	}
+
+	for _, element := range matrix.Elements {
+		if !element.Differs {
+			continue
+		}
+
+		presence := make([]interface{}, len(element.Present))
+		for i, present := range element.Present {
+			if present {
+				presence[i] = elementPresent
+			}
+		}
+		consensus, ok := majorityValue(presence)
+		if !ok {
+			result.NoConsensus = append(result.NoConsensus, ConsensusConflict{
+				Level:   element.Level,
+				Element: element.Element,
+			})
+			continue
+		}
+		for i, value := range presence {
+			if !matrixValuesEqual([]interface{}{consensus, value}) {
+				result.Outliers = append(result.Outliers, Outlier{
+					Directory: matrix.Environments[i],
+					Level:     element.Level,
+					Element:   element.Element,
+					Value:     value,
+					Consensus: consensus,
+				})
+			}
+		}
+		if consensus == nil {
+			// The element is extra in the outlying directories; its attributes are not compared
+			continue
+		}
+
+		for _, attribute := range element.Attributes {
+			if !attribute.Differs {
+				continue
+			}
+
+			// Only directories that have the element take part in the vote on its attributes
+			var values []interface{}
+			var directories []string
+			for i, value := range attribute.Values {
+				if element.Present[i] {
+					values = append(values, value)
+					directories = append(directories, matrix.Environments[i])
+				}
+			}
+
+			consensus, ok := majorityValue(values)
+			if !ok {
+				result.NoConsensus = append(result.NoConsensus, ConsensusConflict{
+					Level:     element.Level,
+					Element:   element.Element,
+					Attribute: attribute.Path,
+				})
+				continue
+			}
+			for i, value := range values {
+				if !matrixValuesEqual([]interface{}{consensus, value}) {
+					result.Outliers = append(result.Outliers, Outlier{
+						Directory: directories[i],
+						Level:     element.Level,
+						Element:   element.Element,
+						Attribute: attribute.Path,
+						Value:     value,
+						Consensus: consensus,
+					})
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// majorityValue returns the value held by more than half of the values, comparing them with
+// valuesEqual. nil values count as absent and can form the majority themselves.
+func majorityValue(values []interface{}) (interface{}, bool) {
+	for i, candidate := range values {
+		count := 0
+		for _, value := range values {
+			if matrixValuesEqual([]interface{}{candidate, value}) {
+				count++
+			}
+		}
+		if count*2 > len(values) {
+			return values[i], true
+		}
+	}
+	return nil, false
+}
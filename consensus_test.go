@@ -0,0 +1,135 @@
+package tfdiff
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindOutliers(t *testing.T) {
+	root := t.TempDir()
+	regions := map[string]string{
+		"us-east-1": `
+resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+}
+`,
+		"us-west-2": `
+resource "aws_instance" "web" {
+  instance_type = "t3.large"
+}
+
+resource "aws_s3_bucket" "debug" {
+  bucket = "debug"
+}
+`,
+		"eu-west-1": `
+resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "eu-logs"
+}
+`,
+		"ap-northeast-1": `
+resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "ap-logs"
+}
+`,
+		"sa-east-1": `
+resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "sa-logs"
+}
+`,
+	}
+
+	var modules []*ModuleDefinition
+	for _, region := range []string{"us-east-1", "us-west-2", "eu-west-1", "ap-northeast-1", "sa-east-1"} {
+		dir := filepath.Join(root, region)
+		writeModuleFiles(t, dir, map[string]string{"main.tf": regions[region]})
+		module, err := ParseModuleHCL(dir)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", region, err)
+		}
+		modules = append(modules, module)
+	}
+
+	config := ComparisonConfig{Levels: []ComparisonLevel{ComparisonLevelResources}}
+	result := FindOutliers(modules, config)
+
+	expected := []struct {
+		region    string
+		element   string
+		attribute string
+		value     interface{}
+		consensus interface{}
+	}{
+		{"us-west-2", "aws_instance.web", "instance_type", "t3.large", "t3.micro"},
+		{"us-west-2", "aws_s3_bucket.debug", "", elementPresent, nil},
+		{"us-east-1", "aws_s3_bucket.logs", "", nil, elementPresent},
+		{"us-west-2", "aws_s3_bucket.logs", "", nil, elementPresent},
+	}
+
+	if len(result.Outliers) != len(expected) {
+		for _, outlier := range result.Outliers {
+			t.Logf("Outlier: %+v", outlier)
+		}
+		t.Fatalf("expected %d outliers, got %d", len(expected), len(result.Outliers))
+	}
+	for i, want := range expected {
+		outlier := result.Outliers[i]
+		if outlier.Directory != filepath.Join(root, want.region) || outlier.Element != want.element ||
+			outlier.Attribute != want.attribute || outlier.Value != want.value || outlier.Consensus != want.consensus {
+			t.Errorf("outlier %d: expected %+v, got %+v", i, want, outlier)
+		}
+	}
+
+	// Every region with the bucket uses a different name, so there is no majority
+	if len(result.NoConsensus) != 1 || result.NoConsensus[0].Attribute != "bucket" {
+		t.Errorf("expected no consensus on bucket, got %+v", result.NoConsensus)
+	}
+
+	output := FormatConsensusOutput(result, true)
+	for _, want := range []string{
+		`~ resource aws_instance.web instance_type = "t3.large" (consensus: "t3.micro")`,
+		"+ resource aws_s3_bucket.debug (extra)",
+		"- resource aws_s3_bucket.logs (missing)",
+		"? resource aws_s3_bucket.logs bucket",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestMajorityValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []interface{}
+		expected interface{}
+		ok       bool
+	}{
+		{"strict majority", []interface{}{"a", "b", "a"}, "a", true},
+		{"tie", []interface{}{"a", "b"}, nil, false},
+		{"absent majority", []interface{}{nil, "a", nil}, nil, true},
+		{"semantic JSON equality", []interface{}{`{"a": 1}`, `{"a":1}`, "x"}, `{"a": 1}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, ok := majorityValue(tt.values)
+			if ok != tt.ok || value != tt.expected {
+				t.Errorf("expected (%v, %v), got (%v, %v)", tt.expected, tt.ok, value, ok)
+			}
+		})
+	}
+}
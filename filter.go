@@ -0,0 +1,113 @@
+package tfdiff
+
+import "strings"
+
+// filterModule returns a copy of a module that keeps only the elements selected by the
+// include and exclude address globs of the configuration. Elements are addressed like
+// Terraform addresses them: module.NAME, TYPE.NAME (or resource.TYPE.NAME), data.TYPE.NAME,
+// output.NAME, var.NAME, component.NAME, deployment.NAME and identity_token.NAME. Test files,
+// orchestrate rules, required providers and Terragrunt configuration have no address: include
+// globs never select them, and exclude globs never skip them.
+func filterModule(def *ModuleDefinition, config ComparisonConfig) *ModuleDefinition {
+	if len(config.Include) == 0 && len(config.Exclude) == 0 {
+		return def
+	}
+	selected := func(addresses ...string) bool {
+		return addressSelected(addresses, config.Include, config.Exclude)
+	}
+
+	copied := *def
+
+	copied.ModuleCalls = nil
+	for _, mc := range def.ModuleCalls {
+		if selected("module." + mc.Name) {
+			copied.ModuleCalls = append(copied.ModuleCalls, mc)
+		}
+	}
+
+	copied.Outputs = nil
+	for _, output := range def.Outputs {
+		if selected("output." + output.Name) {
+			copied.Outputs = append(copied.Outputs, output)
+		}
+	}
+
+	copied.Resources = nil
+	for _, r := range def.Resources {
+		if selected(resourceKey(r), "resource."+resourceKey(r)) {
+			copied.Resources = append(copied.Resources, r)
+		}
+	}
+
+	copied.DataSources = nil
+	for _, ds := range def.DataSources {
+		if selected("data." + dataSourceKey(ds)) {
+			copied.DataSources = append(copied.DataSources, ds)
+		}
+	}
+
+	copied.Variables = nil
+	for _, v := range def.Variables {
+		if selected("var." + v.Name) {
+			copied.Variables = append(copied.Variables, v)
+		}
+	}
+
+	copied.Components = nil
+	for _, c := range def.Components {
+		if selected("component." + c.Name) {
+			copied.Components = append(copied.Components, c)
+		}
+	}
+
+	copied.Deployments = nil
+	for _, d := range def.Deployments {
+		if selected("deployment." + d.Name) {
+			copied.Deployments = append(copied.Deployments, d)
+		}
+	}
+
+	copied.IdentityTokens = nil
+	for _, token := range def.IdentityTokens {
+		if selected("identity_token." + token.Name) {
+			copied.IdentityTokens = append(copied.IdentityTokens, token)
+		}
+	}
+
+	// Elements without an address are only compared without include globs
+	if len(config.Include) > 0 {
+		copied.TestFiles = nil
+		copied.OrchestrateRules = nil
+		copied.RequiredProviders = nil
+		copied.Terragrunt = nil
+	}
+
+	return &copied
+}
+
+// addressSelected reports whether any of the addresses of an element matches an include glob
+// (or no include globs are given) and none matches an exclude glob
+func addressSelected(addresses []string, include, exclude []string) bool {
+	included := len(include) == 0
+	for _, address := range addresses {
+		if matchesAnyAddress(exclude, address) {
+			return false
+		}
+		if !included && matchesAnyAddress(include, address) {
+			included = true
+		}
+	}
+	return included
+}
+
+// matchesAnyAddress reports whether an address matches any of the address globs. Globs are
+// matched segment by segment, so * never matches across a dot.
+func matchesAnyAddress(patterns []string, address string) bool {
+	segments := strings.Split(address, ".")
+	for _, pattern := range patterns {
+		if matchPathSegments(strings.Split(pattern, "."), segments) {
+			return true
+		}
+	}
+	return false
+}
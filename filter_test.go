@@ -0,0 +1,130 @@
+package tfdiff
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestCompareModules_IncludeExclude(t *testing.T) {
+	left := parseTestModule(t, map[string]string{
+		"main.tf": `
+module "vpc" {
+  source = "terraform-aws-modules/vpc/aws"
+}
+
+resource "aws_iam_role" "admin" {
+  name = "admin"
+}
+
+resource "aws_iam_policy" "read" {
+  name = "read"
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+
+data "aws_ami" "ubuntu" {
+  most_recent = true
+}
+
+output "db_endpoint" {
+  value = "db"
+}
+
+output "url" {
+  value = "url"
+}
+`,
+		"versions.tf": `
+terraform {
+  required_providers {
+    aws = {
+      source = "hashicorp/aws"
+    }
+  }
+}
+`,
+		"tests/main.tftest.hcl": `
+run "check" {
+  command = plan
+}
+`,
+	})
+	right := parseTestModule(t, map[string]string{"main.tf": ""})
+	unaddressed := []string{"aws", "tests/main.tftest.hcl"}
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    []string
+	}{
+		{
+			name: "no filters",
+			want: append([]string{"vpc", "db_endpoint", "url", "aws_iam_role.admin", "aws_iam_policy.read", "aws_s3_bucket.logs", "aws_ami.ubuntu"}, unaddressed...),
+		},
+		{
+			name:    "include resource glob",
+			include: []string{"aws_iam_*.*"},
+			want:    []string{"aws_iam_role.admin", "aws_iam_policy.read"},
+		},
+		{
+			name:    "include module, data source and output globs",
+			include: []string{"module.vpc", "data.aws_ami.*", "output.db_*"},
+			want:    []string{"vpc", "db_endpoint", "aws_ami.ubuntu"},
+		},
+		{
+			name:    "exclude",
+			exclude: []string{"aws_s3_bucket.*", "output.*", "data.*.*"},
+			want:    append([]string{"vpc", "aws_iam_role.admin", "aws_iam_policy.read"}, unaddressed...),
+		},
+		{
+			name:    "include resource address",
+			include: []string{"resource.aws_s3_bucket.*"},
+			want:    []string{"aws_s3_bucket.logs"},
+		},
+		{
+			name:    "exclude resource address",
+			include: []string{"aws_*.*"},
+			exclude: []string{"resource.aws_iam_*.*"},
+			want:    []string{"aws_s3_bucket.logs"},
+		},
+		{
+			name:    "exclude wins over include",
+			include: []string{"aws_iam_*.*"},
+			exclude: []string{"aws_iam_policy.*"},
+			want:    []string{"aws_iam_role.admin"},
+		},
+		{
+			name:    "wildcard does not match across segments",
+			include: []string{"*"},
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := ComparisonConfig{
+				Levels:  []ComparisonLevel{ComparisonLevelAll},
+				Include: tt.include,
+				Exclude: tt.exclude,
+			}
+			result := CompareModules(left, right, config)
+
+			var elements []string
+			for _, diff := range result.Diffs {
+				elements = append(elements, diff.Element)
+			}
+			sort.Strings(elements)
+			sort.Strings(tt.want)
+			if strings.Join(elements, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected diffs %v, got %v", tt.want, elements)
+			}
+			if result.Summary.Removed != len(tt.want) {
+				t.Errorf("expected %d removed elements in summary, got %d", len(tt.want), result.Summary.Removed)
+			}
+		})
+	}
+}
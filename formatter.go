@@ -4,8 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -73,9 +73,16 @@ func FormatDiffOutput(result *ComparisonResult, config ComparisonConfig, noColor
 			for _, line := range lines {
 				output.WriteString(colorize(fmt.Sprintf("+%s\n", line), ColorGreen, noColor))
 			}
-		case DiffTypeModified:
+		case DiffTypeModified, DiffTypeRenamed:
 			// For modified items, show attribute-level diffs
 			attributeDiffs := formatAttributeDiff(diff, config)
+			if isRenameableLevel(diff.Level) && len(attributeDiffs) > 0 {
+				// Renamed or matched elements show the block header with the old and the new name
+				before, after := firstLine(formatDiffLine(diff, diff.Before, config)), firstLine(formatDiffLine(diff, diff.After, config))
+				if before != after {
+					attributeDiffs = append([]string{"-" + before, "+" + after}, attributeDiffs[1:]...)
+				}
+			}
 			for _, line := range attributeDiffs {
 				coloredLine := line
 				if strings.HasPrefix(line, "-") {
@@ -93,6 +100,422 @@ func FormatDiffOutput(result *ComparisonResult, config ComparisonConfig, noColor
 	return output.String()
 }
 
+// FormatAPIOutput formats the result of comparing the interfaces of two module versions: the
+// diff, followed by the changes grouped by compatibility with their reasons
+func FormatAPIOutput(result *ComparisonResult, noColor bool) string {
+	var output strings.Builder
+	output.WriteString(FormatDiffOutput(result, ComparisonConfig{Levels: interfaceLevels}, noColor))
+
+	if len(result.Diffs) == 0 {
+		output.WriteString("\nNo interface changes\n")
+		return output.String()
+	}
+
+	sections := []struct {
+		compatibility Compatibility
+		title, color  string
+	}{
+		{CompatibilityBreaking, "Breaking changes:", ColorRed},
+		{CompatibilityCompatible, "Compatible changes:", ColorGreen},
+		{CompatibilityPatch, "Patch changes:", ColorCyan},
+	}
+	for _, section := range sections {
+		var lines []string
+		for _, diff := range result.Diffs {
+			if diff.Compatibility == section.compatibility {
+				lines = append(lines, fmt.Sprintf("  %s \"%s\": %s", diff.Level, diff.Element, diff.CompatibilityReason))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		output.WriteString("\n" + colorize(section.title, ColorBold+section.color, noColor) + "\n")
+		output.WriteString(strings.Join(lines, "\n") + "\n")
+	}
+
+	output.WriteString(fmt.Sprintf("\nCompatibility: %s\n", result.Compatibility))
+	return output.String()
+}
+
+// FormatVersionRecommendation formats the recommended next version of a module, followed by
+// the bump each diff calls for, the largest first
+func FormatVersionRecommendation(recommendation *VersionRecommendation, noColor bool) string {
+	var output strings.Builder
+	output.WriteString("\n" + colorize(fmt.Sprintf("Recommended version: %s (%s, previous %s)", recommendation.NextVersion, recommendation.Bump, recommendation.PreviousVersion), ColorBold, noColor) + "\n")
+
+	reasons := append([]VersionReason(nil), recommendation.Reasons...)
+	sort.SliceStable(reasons, func(i, j int) bool {
+		return reasons[i].Bump.rank() > reasons[j].Bump.rank()
+	})
+	for _, reason := range reasons {
+		output.WriteString(fmt.Sprintf("  %-5s  %s \"%s\": %s\n", reason.Bump, reason.Level, reason.Element, reason.Reason))
+	}
+	return output.String()
+}
+
+// FormatTreeOutput formats the result of comparing two directory trees.
+// Directories present on only one side are listed first, followed by the diff of each
+// directory pair that has differences.
+func FormatTreeOutput(result *TreeComparisonResult, config ComparisonConfig, noColor bool) string {
+	var output strings.Builder
+
+	output.WriteString(colorize(fmt.Sprintf("--- %s\n", result.LeftPath), ColorBold+ColorRed, noColor))
+	output.WriteString(colorize(fmt.Sprintf("+++ %s\n", result.RightPath), ColorBold+ColorGreen, noColor))
+
+	for _, diff := range result.Directories {
+		switch diff.Type {
+		case DiffTypeRemoved:
+			output.WriteString(colorize(fmt.Sprintf("-directory \"%s\"\n", diff.Element), ColorRed, noColor))
+		case DiffTypeAdded:
+			output.WriteString(colorize(fmt.Sprintf("+directory \"%s\"\n", diff.Element), ColorGreen, noColor))
+		}
+	}
+
+	for _, comparison := range result.Results {
+		if len(comparison.Diffs) == 0 {
+			continue
+		}
+		output.WriteString("\n")
+		output.WriteString(FormatDiffOutput(comparison, config, noColor))
+	}
+
+	return output.String()
+}
+
+// FormatReferenceOutput formats the result of comparing instances against a reference module.
+// A summary line per instance is followed by the diff of each diverging instance.
+func FormatReferenceOutput(result *ReferenceComparisonResult, config ComparisonConfig, noColor bool) string {
+	var output strings.Builder
+
+	output.WriteString(colorize(fmt.Sprintf("reference: %s\n", result.ReferencePath), ColorBold, noColor))
+
+	width := 0
+	for _, comparison := range result.Results {
+		if len(comparison.RightPath) > width {
+			width = len(comparison.RightPath)
+		}
+	}
+	for _, comparison := range result.Results {
+		if len(comparison.Diffs) == 0 {
+			output.WriteString(fmt.Sprintf("  %s  no differences\n", padRight(comparison.RightPath, width)))
+			continue
+		}
+		summary := comparison.Summary
+		line := fmt.Sprintf("  %s  %d added, %d removed, %d modified", padRight(comparison.RightPath, width), summary.Added, summary.Removed, summary.Modified)
+		if summary.Renamed > 0 {
+			line += fmt.Sprintf(", %d renamed", summary.Renamed)
+		}
+		output.WriteString(colorize(line, ColorYellow, noColor) + "\n")
+	}
+	output.WriteString(fmt.Sprintf("%d of %d instances diverge from the reference\n", result.Diverged, len(result.Results)))
+
+	for _, comparison := range result.Results {
+		if len(comparison.Diffs) == 0 {
+			continue
+		}
+		output.WriteString("\n")
+		output.WriteString(FormatDiffOutput(comparison, config, noColor))
+	}
+
+	return output.String()
+}
+
+// matrixCellWidth is the maximum width of a value shown in a drift matrix cell
+const matrixCellWidth = 40
+
+// FormatMatrixOutput formats a drift matrix as a table with one column per environment.
+// Rows that differ are marked with "~" and their cells that differ from the first
+// environment are highlighted.
+func FormatMatrixOutput(matrix *DriftMatrix, noColor bool) string {
+	var output strings.Builder
+
+	// Build the rows first to compute column widths
+	type matrixRow struct {
+		label       string
+		cells       []string
+		highlighted []bool
+		differs     bool
+		header      bool
+	}
+	var rows []matrixRow
+
+	for _, element := range matrix.Elements {
+		row := matrixRow{
+			label:       fmt.Sprintf("%s %s", element.Level, element.Element),
+			cells:       make([]string, len(matrix.Environments)),
+			highlighted: make([]bool, len(matrix.Environments)),
+			differs:     element.Differs,
+			header:      true,
+		}
+		for i, present := range element.Present {
+			if !present {
+				row.cells[i] = "(absent)"
+			}
+			row.highlighted[i] = present != element.Present[0]
+		}
+		rows = append(rows, row)
+
+		for _, attribute := range element.Attributes {
+			row := matrixRow{
+				label:       "  " + attribute.Path,
+				cells:       make([]string, len(matrix.Environments)),
+				highlighted: make([]bool, len(matrix.Environments)),
+				differs:     attribute.Differs,
+			}
+			for i, value := range attribute.Values {
+				if value == nil {
+					row.cells[i] = "-"
+				} else {
+					row.cells[i] = truncateCell(interfaceToDisplayString(value))
+				}
+				row.highlighted[i] = !matrixValuesEqual([]interface{}{attribute.Values[0], value})
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	labelWidth := 0
+	widths := make([]int, len(matrix.Environments))
+	for i, env := range matrix.Environments {
+		widths[i] = len([]rune(env))
+	}
+	for _, row := range rows {
+		if width := len([]rune(row.label)); width > labelWidth {
+			labelWidth = width
+		}
+		for i, cell := range row.cells {
+			if width := len([]rune(cell)); width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+
+	header := fmt.Sprintf("  %s", padRight("", labelWidth))
+	for i, env := range matrix.Environments {
+		header += "  " + padRight(env, widths[i])
+	}
+	output.WriteString(colorize(strings.TrimRight(header, " "), ColorBold, noColor) + "\n")
+
+	for _, row := range rows {
+		marker := "  "
+		if row.differs {
+			marker = "~ "
+		}
+		line := marker + padRight(row.label, labelWidth)
+		if row.header {
+			line = colorize(marker+padRight(row.label, labelWidth), ColorCyan, noColor)
+		}
+		for i, cell := range row.cells {
+			text := padRight(cell, widths[i])
+			if row.highlighted[i] {
+				text = colorize(text, ColorYellow, noColor)
+			}
+			line += "  " + text
+		}
+		output.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+
+	return output.String()
+}
+
+// truncateCell shortens a matrix cell value to matrixCellWidth runes
+func truncateCell(value string) string {
+	value = strings.ReplaceAll(value, "\n", " ")
+	runes := []rune(value)
+	if len(runes) <= matrixCellWidth {
+		return value
+	}
+	return string(runes[:matrixCellWidth-3]) + "..."
+}
+
+// padRight pads a string with spaces to the given width in runes
+func padRight(value string, width int) string {
+	if padding := width - len([]rune(value)); padding > 0 {
+		return value + strings.Repeat(" ", padding)
+	}
+	return value
+}
+
+// FormatConsensusOutput formats the outliers of a consensus comparison grouped by directory.
+// Missing elements are prefixed with "-", extra elements with "+" and deviating attribute
+// values with "~", followed by the consensus value.
+func FormatConsensusOutput(result *ConsensusResult, noColor bool) string {
+	var output strings.Builder
+
+	output.WriteString(colorize(fmt.Sprintf("consensus of %d directories: %s\n", len(result.Directories), strings.Join(result.Directories, ", ")), ColorBold, noColor))
+
+	if len(result.Outliers) == 0 && len(result.NoConsensus) == 0 {
+		output.WriteString("no outliers\n")
+		return output.String()
+	}
+
+	for _, directory := range result.Directories {
+		var lines []string
+		for _, outlier := range result.Outliers {
+			if outlier.Directory != directory {
+				continue
+			}
+			lines = append(lines, formatOutlier(outlier, noColor))
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		output.WriteString("\n")
+		output.WriteString(colorize(directory, ColorCyan, noColor) + "\n")
+		for _, line := range lines {
+			output.WriteString(line + "\n")
+		}
+	}
+
+	if len(result.NoConsensus) > 0 {
+		output.WriteString("\n")
+		output.WriteString(colorize("no consensus", ColorCyan, noColor) + "\n")
+		for _, conflict := range result.NoConsensus {
+			line := fmt.Sprintf("  ? %s %s", conflict.Level, conflict.Element)
+			if conflict.Attribute != "" {
+				line += " " + conflict.Attribute
+			}
+			output.WriteString(colorize(line, ColorYellow, noColor) + "\n")
+		}
+	}
+
+	return output.String()
+}
+
+// formatOutlier formats a single outlier line
+func formatOutlier(outlier Outlier, noColor bool) string {
+	if outlier.Attribute == "" {
+		if outlier.Value == nil {
+			return colorize(fmt.Sprintf("  - %s %s (missing)", outlier.Level, outlier.Element), ColorRed, noColor)
+		}
+		return colorize(fmt.Sprintf("  + %s %s (extra)", outlier.Level, outlier.Element), ColorGreen, noColor)
+	}
+	return colorize(fmt.Sprintf("  ~ %s %s %s = %s (consensus: %s)",
+		outlier.Level, outlier.Element, outlier.Attribute,
+		formatConsensusValue(outlier.Value), formatConsensusValue(outlier.Consensus)), ColorYellow, noColor)
+}
+
+// formatConsensusValue formats an attribute value of a consensus comparison
+func formatConsensusValue(value interface{}) string {
+	if value == nil {
+		return "(absent)"
+	}
+	return formatDisplayValue(value)
+}
+
+// FormatThreeWayOutput formats a three-way comparison grouped by change class
+func FormatThreeWayOutput(result *ThreeWayResult, noColor bool) string {
+	var output strings.Builder
+
+	output.WriteString(colorize(fmt.Sprintf("base:  %s\n", result.BasePath), ColorBold, noColor))
+	output.WriteString(colorize(fmt.Sprintf("left:  %s\n", result.LeftPath), ColorBold+ColorRed, noColor))
+	output.WriteString(colorize(fmt.Sprintf("right: %s\n", result.RightPath), ColorBold+ColorGreen, noColor))
+
+	groups := []struct {
+		class  ThreeWayClass
+		title  string
+		marker string
+		color  string
+	}{
+		{ThreeWayLeftOnly, "changed in left only", "<", ColorRed},
+		{ThreeWayRightOnly, "changed in right only", ">", ColorGreen},
+		{ThreeWayBothIdentical, "changed in both identically", "=", ColorCyan},
+		{ThreeWayConflict, "conflicts", "!", ColorYellow},
+	}
+
+	for _, group := range groups {
+		var lines []string
+		for _, change := range result.Changes {
+			if change.Class == group.class {
+				lines = append(lines, fmt.Sprintf("  %s %s", group.marker, formatThreeWayChange(change)))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		output.WriteString("\n")
+		output.WriteString(colorize(group.title, ColorCyan, noColor) + "\n")
+		for _, line := range lines {
+			output.WriteString(colorize(line, group.color, noColor) + "\n")
+		}
+	}
+
+	return output.String()
+}
+
+// formatThreeWayChange formats the element, attribute and values of a three-way change
+func formatThreeWayChange(change ThreeWayChange) string {
+	name := fmt.Sprintf("%s %s", change.Level, change.Element)
+	if change.Attribute == "" {
+		return fmt.Sprintf("%s: base %s, left %s, right %s", name,
+			formatPresence(change.Base), formatPresence(change.Left), formatPresence(change.Right))
+	}
+	return fmt.Sprintf("%s %s: base %s, left %s, right %s", name, change.Attribute,
+		formatConsensusValue(change.Base), formatConsensusValue(change.Left), formatConsensusValue(change.Right))
+}
+
+// formatPresence formats whether an element exists
+func formatPresence(value interface{}) string {
+	if value == nil {
+		return "(absent)"
+	}
+	return elementPresent
+}
+
+// FormatBaselineOutput formats the differences that are new, changed or resolved compared to a baseline
+func FormatBaselineOutput(result *BaselineResult, noColor bool) string {
+	var output strings.Builder
+
+	output.WriteString(colorize(fmt.Sprintf("--- %s\n", result.LeftPath), ColorBold+ColorRed, noColor))
+	output.WriteString(colorize(fmt.Sprintf("+++ %s\n", result.RightPath), ColorBold+ColorGreen, noColor))
+
+	if len(result.New) > 0 {
+		output.WriteString("\n" + colorize("new differences", ColorCyan, noColor) + "\n")
+		for _, entry := range result.New {
+			output.WriteString(colorize("  + "+formatBaselineEntry(entry), ColorRed, noColor) + "\n")
+		}
+	}
+	if len(result.Changed) > 0 {
+		output.WriteString("\n" + colorize("changed differences", ColorCyan, noColor) + "\n")
+		for _, change := range result.Changed {
+			line := fmt.Sprintf("  ~ %s (baseline: left %s, right %s)", formatBaselineEntry(change.Current),
+				formatBaselineValue(change.Baseline, change.Baseline.Left), formatBaselineValue(change.Baseline, change.Baseline.Right))
+			output.WriteString(colorize(line, ColorYellow, noColor) + "\n")
+		}
+	}
+	if len(result.Resolved) > 0 {
+		output.WriteString("\n" + colorize("resolved differences", ColorCyan, noColor) + "\n")
+		for _, entry := range result.Resolved {
+			output.WriteString(colorize("  - "+formatBaselineEntry(entry), ColorGreen, noColor) + "\n")
+		}
+	}
+
+	output.WriteString(fmt.Sprintf("\nSummary: %d new, %d changed, %d resolved, %d accepted\n",
+		len(result.New), len(result.Changed), len(result.Resolved), result.Accepted))
+
+	return output.String()
+}
+
+// formatBaselineEntry formats the element, attribute and values of a baseline difference
+func formatBaselineEntry(entry BaselineEntry) string {
+	name := fmt.Sprintf("%s %s", entry.Level, entry.Element)
+	if entry.RenamedFrom != "" {
+		name += fmt.Sprintf(" (renamed from %s)", entry.RenamedFrom)
+	}
+	if entry.Attribute != "" {
+		name += " " + entry.Attribute
+	}
+	return fmt.Sprintf("%s: left %s, right %s", name, formatBaselineValue(entry, entry.Left), formatBaselineValue(entry, entry.Right))
+}
+
+// formatBaselineValue formats one side of a baseline difference
+func formatBaselineValue(entry BaselineEntry, value interface{}) string {
+	if entry.Attribute == "" {
+		return formatPresence(value)
+	}
+	return formatConsensusValue(value)
+}
+
 // sortDiffsForDiffOutput sorts diffs by level and name for consistent output
 func sortDiffsForDiffOutput(diffs []Diff) []Diff {
 	sorted := make([]Diff, len(diffs))
@@ -103,6 +526,14 @@ func sortDiffsForDiffOutput(diffs []Diff) []Diff {
 	return sorted
 }
 
+// firstLine returns the first line of a text
+func firstLine(text string) string {
+	if i := strings.Index(text, "\n"); i >= 0 {
+		return text[:i]
+	}
+	return text
+}
+
 // formatDiffLine formats a single line for diff output
 func formatDiffLine(diff Diff, item interface{}, config ComparisonConfig) string {
 	switch diff.Level {
@@ -115,10 +546,12 @@ func formatDiffLine(diff Diff, item interface{}, config ComparisonConfig) string
 			}
 			
 			if !config.IgnoreArguments && len(mc.Args) > 0 {
-				for key, value := range mc.Args {
-					if value != "" && value != "<complex_expression>" {
-						lines = append(lines, fmt.Sprintf("  %s = \"%s\"", key, value))
-					}
+				keys := make(map[string]bool)
+				for key := range mc.Args {
+					keys[key] = true
+				}
+				for _, key := range sortedKeys(keys) {
+					lines = append(lines, formatNestedValue(key+" = ", mc.Args[key], "  ")...)
 				}
 			}
 			lines = append(lines, "}")
@@ -140,12 +573,8 @@ func formatDiffLine(diff Diff, item interface{}, config ComparisonConfig) string
 	case "resource":
 		if res, ok := item.(Resource); ok {
 			lines := []string{fmt.Sprintf("resource \"%s\" \"%s\" {", res.Type, res.Name)}
-			if !config.IgnoreArguments && len(res.Config) > 0 {
-				for key, value := range res.Config {
-					if strValue, ok := value.(string); ok && isDisplayableValue(strValue) {
-						lines = append(lines, fmt.Sprintf("  %s = \"%s\"", key, strValue))
-					}
-				}
+			if !config.IgnoreArguments {
+				lines = append(lines, formatConfigValues(res.Config)...)
 			}
 			lines = append(lines, "}")
 			return strings.Join(lines, "\n")
@@ -153,12 +582,8 @@ func formatDiffLine(diff Diff, item interface{}, config ComparisonConfig) string
 	case "data_source":
 		if ds, ok := item.(DataSource); ok {
 			lines := []string{fmt.Sprintf("data \"%s\" \"%s\" {", ds.Type, ds.Name)}
-			if !config.IgnoreArguments && len(ds.Config) > 0 {
-				for key, value := range ds.Config {
-					if strValue, ok := value.(string); ok && isDisplayableValue(strValue) {
-						lines = append(lines, fmt.Sprintf("  %s = \"%s\"", key, strValue))
-					}
-				}
+			if !config.IgnoreArguments {
+				lines = append(lines, formatConfigValues(ds.Config)...)
 			}
 			lines = append(lines, "}")
 			return strings.Join(lines, "\n")
@@ -172,16 +597,229 @@ func formatDiffLine(diff Diff, item interface{}, config ComparisonConfig) string
 			if v.Description != "" {
 				lines = append(lines, fmt.Sprintf("  description = \"%s\"", v.Description))
 			}
-			if v.DefaultValue != "" {
-				lines = append(lines, fmt.Sprintf("  default = %s", v.DefaultValue))
+			if v.DefaultValue != nil {
+				lines = append(lines, formatNestedValue("default = ", *v.DefaultValue, "  ")...)
+			}
+			if !v.IsNullable() {
+				lines = append(lines, "  nullable = false")
+			}
+			if v.Sensitive {
+				lines = append(lines, "  sensitive = true")
+			}
+			for _, validation := range v.Validations {
+				lines = append(lines, fmt.Sprintf("  validation { condition = %s }", validation.Condition))
+			}
+			lines = append(lines, "}")
+			return strings.Join(lines, "\n")
+		}
+	case "required_provider":
+		if p, ok := item.(RequiredProvider); ok {
+			lines := []string{fmt.Sprintf("required_provider \"%s\" {", p.Name)}
+			if p.Source != "" {
+				lines = append(lines, fmt.Sprintf("  source  = \"%s\"", p.Source))
+			}
+			if p.Version != "" {
+				lines = append(lines, fmt.Sprintf("  version = \"%s\"", p.Version))
+			}
+			if len(p.ConfigurationAliases) > 0 {
+				lines = append(lines, fmt.Sprintf("  configuration_aliases = [%s]", strings.Join(p.ConfigurationAliases, ", ")))
 			}
 			lines = append(lines, "}")
 			return strings.Join(lines, "\n")
 		}
+	case "test_file":
+		if tf, ok := item.(TestFile); ok {
+			lines := []string{fmt.Sprintf("# %s", tf.Path)}
+			lines = append(lines, formatTestVariables("", tf.Variables)...)
+			for _, provider := range tf.Providers {
+				lines = append(lines, formatTestProvider(provider))
+			}
+			for _, run := range tf.Runs {
+				lines = append(lines, formatTestRun(run)...)
+			}
+			return strings.Join(lines, "\n")
+		}
+	case "component":
+		if c, ok := item.(Component); ok {
+			lines := []string{fmt.Sprintf("component \"%s\" {", c.Name)}
+			lines = append(lines, fmt.Sprintf("  source  = \"%s\"", c.Source))
+			if c.Version != "" {
+				lines = append(lines, fmt.Sprintf("  version = \"%s\"", c.Version))
+			}
+			if c.ForEach != "" {
+				lines = append(lines, fmt.Sprintf("  for_each = %s", c.ForEach))
+			}
+			if !config.IgnoreArguments {
+				lines = append(lines, formatValueMapAttribute("inputs", c.Inputs)...)
+			}
+			lines = append(lines, formatValueMapAttribute("providers", c.Providers)...)
+			lines = append(lines, "}")
+			return strings.Join(lines, "\n")
+		}
+	case "deployment":
+		if d, ok := item.(Deployment); ok {
+			lines := []string{fmt.Sprintf("deployment \"%s\" {", d.Name)}
+			lines = append(lines, formatValueMapAttribute("inputs", d.Inputs)...)
+			lines = append(lines, "}")
+			return strings.Join(lines, "\n")
+		}
+	case "orchestrate_rule":
+		if r, ok := item.(OrchestrateRule); ok {
+			lines := []string{fmt.Sprintf("orchestrate \"%s\" \"%s\" {", r.Type, r.Name)}
+			for _, check := range orchestrateCheckLines(r.Checks) {
+				lines = append(lines, "  "+check)
+			}
+			lines = append(lines, "}")
+			return strings.Join(lines, "\n")
+		}
+	case "identity_token":
+		if t, ok := item.(IdentityToken); ok {
+			lines := []string{fmt.Sprintf("identity_token \"%s\" {", t.Name)}
+			if t.Audience != "" {
+				lines = append(lines, "  "+formatField("audience", t.Audience))
+			}
+			lines = append(lines, "}")
+			return strings.Join(lines, "\n")
+		}
+	case "terragrunt":
+		return strings.Join(formatTerragruntItem(diff.Element, item), "\n")
+	case "test_run":
+		if run, ok := item.(TestRun); ok {
+			lines := formatTestRun(run)
+			lines[0] = fmt.Sprintf("%s  # %s", lines[0], diff.Element)
+			return strings.Join(lines, "\n")
+		}
 	}
 	return diff.Message
 }
 
+// formatTerragruntItem formats a single element of a Terragrunt configuration
+func formatTerragruntItem(element string, item interface{}) []string {
+	switch value := item.(type) {
+	case string, Value:
+		return formatNestedValue(element+" = ", value, "")
+	case TerragruntDependency:
+		return []string{
+			terragruntBlockHeader(element, value),
+			fmt.Sprintf("  config_path = \"%s\"", value.ConfigPath),
+			"}",
+		}
+	case TerragruntInclude:
+		return []string{terragruntBlockHeader(element, value), fmt.Sprintf("  path = \"%s\"", value.Path), "}"}
+	case TerragruntRemoteState:
+		lines := []string{terragruntBlockHeader(element, value), fmt.Sprintf("  backend = \"%s\"", value.Backend)}
+		lines = append(lines, formatValueMapAttribute("config", value.Config)...)
+		return append(lines, "}")
+	}
+	return []string{element}
+}
+
+// terragruntBlockHeader formats the opening line of the block holding a Terragrunt element.
+// Sources and inputs, named terraform.source and inputs.NAME, are held by the terraform and
+// inputs blocks.
+func terragruntBlockHeader(element string, item interface{}) string {
+	switch value := item.(type) {
+	case TerragruntDependency:
+		return fmt.Sprintf("dependency \"%s\" {", value.Name)
+	case TerragruntInclude:
+		if value.Name != "" {
+			return fmt.Sprintf("include \"%s\" {", value.Name)
+		}
+		return "include {"
+	case TerragruntRemoteState:
+		return "remote_state {"
+	}
+	block, _, _ := strings.Cut(element, ".")
+	return block + " {"
+}
+
+// formatField formats an attribute with its value
+func formatField(name string, value interface{}) string {
+	return fmt.Sprintf("%s = %s", name, formatDisplayValue(value))
+}
+
+// formatValueMapAttribute formats a map attribute such as component inputs with sorted keys
+func formatValueMapAttribute(name string, values map[string]Value) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := []string{fmt.Sprintf("  %s = {", name)}
+	for _, key := range keys {
+		lines = append(lines, formatNestedValue(key+" = ", values[key], "    ")...)
+	}
+	lines = append(lines, "  }")
+	return lines
+}
+
+// orchestrateCheckLines formats check blocks of an orchestrate rule as single lines
+func orchestrateCheckLines(checks []OrchestrateCheck) []string {
+	var lines []string
+	for _, check := range checks {
+		line := fmt.Sprintf("check { condition = %s }", check.Condition)
+		if check.Reason != "" {
+			line = fmt.Sprintf("check { condition = %s, reason = \"%s\" }", check.Condition, check.Reason)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// formatTestRun formats a run block of a test file
+func formatTestRun(run TestRun) []string {
+	lines := []string{fmt.Sprintf("run \"%s\" {", run.Name)}
+	lines = append(lines, fmt.Sprintf("  command = %s", run.Command))
+	if run.Module != "" {
+		lines = append(lines, fmt.Sprintf("  module { source = \"%s\" }", run.Module))
+	}
+	lines = append(lines, formatTestVariables("  ", run.Variables)...)
+	if len(run.ExpectFailures) > 0 {
+		lines = append(lines, fmt.Sprintf("  expect_failures = [%s]", strings.Join(run.ExpectFailures, ", ")))
+	}
+	for _, assertion := range run.Assertions {
+		lines = append(lines, fmt.Sprintf("  assert { condition = %s }", assertion.Condition))
+	}
+	lines = append(lines, "}")
+	return lines
+}
+
+// formatTestVariables formats a variables block of a test file or run block
+func formatTestVariables(indent string, variables map[string]Value) []string {
+	if len(variables) == 0 {
+		return nil
+	}
+	var keys []string
+	for key := range variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := []string{indent + "variables {"}
+	for _, key := range keys {
+		lines = append(lines, formatNestedValue(key+" = ", variables[key], indent+"  ")...)
+	}
+	lines = append(lines, indent+"}")
+	return lines
+}
+
+// formatTestProvider formats a provider or mock_provider block of a test file
+func formatTestProvider(provider TestProvider) string {
+	blockType := "provider"
+	if provider.Mock {
+		blockType = "mock_provider"
+	}
+	line := fmt.Sprintf("%s \"%s\"", blockType, provider.Name)
+	if provider.Alias != "" {
+		line += fmt.Sprintf(" { alias = \"%s\" }", provider.Alias)
+	}
+	return line
+}
+
 // formatAttributeDiff formats attribute-level differences for modified items
 func formatAttributeDiff(diff Diff, config ComparisonConfig) []string {
 	var lines []string
@@ -189,24 +827,18 @@ func formatAttributeDiff(diff Diff, config ComparisonConfig) []string {
 	switch diff.Level {
 	case "module_call":
 		if before, okBefore := diff.Before.(ModuleCall); okBefore {
-			if after, okAfter := diff.After.(ModuleCall); okAfter {
+			if _, okAfter := diff.After.(ModuleCall); okAfter {
 				lines = append(lines, fmt.Sprintf(" module \"%s\" {", before.Name))
-				
-				// Compare basic attributes
-				if before.Source != after.Source {
-					lines = append(lines, fmt.Sprintf("-  source  = \"%s\"", before.Source))
-					lines = append(lines, fmt.Sprintf("+  source  = \"%s\"", after.Source))
-				}
-				if before.Version != after.Version {
-					lines = append(lines, fmt.Sprintf("-  version = \"%s\"", before.Version))
-					lines = append(lines, fmt.Sprintf("+  version = \"%s\"", after.Version))
-				}
-				
-				// Compare arguments if not ignoring them
-				if !config.IgnoreArguments {
-					lines = append(lines, compareMapAttributes(before.Args, after.Args)...)
-				}
-				
+				lines = append(lines, formatFieldChanges(diff.Changes, func(name string, value interface{}) string {
+					switch {
+					case name == "source" || name == "version":
+						return fmt.Sprintf("%-7s = %s", name, formatDisplayValue(value))
+					case isDisplayableValue(interfaceToDisplayString(value)):
+						return formatField(name, value)
+					default:
+						return ""
+					}
+				})...)
 				lines = append(lines, " }")
 			}
 		}
@@ -214,12 +846,7 @@ func formatAttributeDiff(diff Diff, config ComparisonConfig) []string {
 		if before, okBefore := diff.Before.(Resource); okBefore {
 			if after, okAfter := diff.After.(Resource); okAfter {
 				lines = append(lines, fmt.Sprintf(" resource \"%s\" \"%s\" {", before.Type, before.Name))
-				
-				// Compare config if not ignoring arguments
-				if !config.IgnoreArguments {
-					lines = append(lines, compareInterfaceMapAttributes(before.Config, after.Config)...)
-				}
-				
+				lines = append(lines, formatConfigChanges(diff.Changes, before.Config, after.Config, newAttributePath(config, diff.Level, diff.Element), blockKeys(config))...)
 				lines = append(lines, " }")
 			}
 		}
@@ -227,74 +854,111 @@ func formatAttributeDiff(diff Diff, config ComparisonConfig) []string {
 		if before, okBefore := diff.Before.(DataSource); okBefore {
 			if after, okAfter := diff.After.(DataSource); okAfter {
 				lines = append(lines, fmt.Sprintf(" data \"%s\" \"%s\" {", before.Type, before.Name))
-				
-				// Compare config if not ignoring arguments
-				if !config.IgnoreArguments {
-					lines = append(lines, compareInterfaceMapAttributes(before.Config, after.Config)...)
-				}
-				
+				lines = append(lines, formatConfigChanges(diff.Changes, before.Config, after.Config, newAttributePath(config, diff.Level, diff.Element), blockKeys(config))...)
 				lines = append(lines, " }")
 			}
 		}
 	case "output":
 		if before, okBefore := diff.Before.(Output); okBefore {
-			if after, okAfter := diff.After.(Output); okAfter {
+			if _, okAfter := diff.After.(Output); okAfter {
 				lines = append(lines, fmt.Sprintf(" output \"%s\" {", before.Name))
-				
-				if before.Description != after.Description {
-					if before.Description != "" {
-						lines = append(lines, fmt.Sprintf("-  description = \"%s\"", before.Description))
-					}
-					if after.Description != "" {
-						lines = append(lines, fmt.Sprintf("+  description = \"%s\"", after.Description))
-					}
-				}
-				if before.Sensitive != after.Sensitive {
-					lines = append(lines, fmt.Sprintf("-  sensitive = %t", before.Sensitive))
-					lines = append(lines, fmt.Sprintf("+  sensitive = %t", after.Sensitive))
-				}
-				if before.Value != after.Value {
-					if before.Value != "" {
-						lines = append(lines, fmt.Sprintf("-  value = \"%s\"", before.Value))
-					}
-					if after.Value != "" {
-						lines = append(lines, fmt.Sprintf("+  value = \"%s\"", after.Value))
-					}
-				}
-				
+				lines = append(lines, formatFieldChanges(diff.Changes, formatField)...)
 				lines = append(lines, " }")
 			}
 		}
 	case "variable":
 		if before, okBefore := diff.Before.(Variable); okBefore {
-			if after, okAfter := diff.After.(Variable); okAfter {
+			if _, okAfter := diff.After.(Variable); okAfter {
 				lines = append(lines, fmt.Sprintf(" variable \"%s\" {", before.Name))
-				
-				if before.Type != after.Type {
-					if before.Type != "" {
-						lines = append(lines, fmt.Sprintf("-  type = \"%s\"", before.Type))
-					}
-					if after.Type != "" {
-						lines = append(lines, fmt.Sprintf("+  type = \"%s\"", after.Type))
-					}
-				}
-				if before.Description != after.Description {
-					if before.Description != "" {
-						lines = append(lines, fmt.Sprintf("-  description = \"%s\"", before.Description))
+				lines = append(lines, formatFieldChanges(diff.Changes, formatField)...)
+				lines = append(lines, " }")
+			}
+		}
+	case "required_provider":
+		if before, okBefore := diff.Before.(RequiredProvider); okBefore {
+			if _, okAfter := diff.After.(RequiredProvider); okAfter {
+				lines = append(lines, fmt.Sprintf(" required_provider \"%s\" {", before.Name))
+				lines = append(lines, formatFieldChanges(diff.Changes, func(name string, value interface{}) string {
+					if aliases, ok := value.([]interface{}); ok {
+						names := make([]string, len(aliases))
+						for i, alias := range aliases {
+							names[i] = fmt.Sprint(alias)
+						}
+						return fmt.Sprintf("%s = [%s]", name, strings.Join(names, ", "))
 					}
-					if after.Description != "" {
-						lines = append(lines, fmt.Sprintf("+  description = \"%s\"", after.Description))
+					return fmt.Sprintf("%-7s = \"%s\"", name, value)
+				})...)
+				lines = append(lines, " }")
+			}
+		}
+	case "component":
+		if before, okBefore := diff.Before.(Component); okBefore {
+			if _, okAfter := diff.After.(Component); okAfter {
+				lines = append(lines, fmt.Sprintf(" component \"%s\" {", before.Name))
+				lines = append(lines, formatFieldChanges(diff.Changes, func(name string, value interface{}) string {
+					if name == "for_each" {
+						return fmt.Sprintf("%s = %s", name, value)
 					}
-				}
-				if before.DefaultValue != after.DefaultValue {
-					if before.DefaultValue != "" {
-						lines = append(lines, fmt.Sprintf("-  default = %s", before.DefaultValue))
+					return fmt.Sprintf("%-7s = \"%s\"", name, value)
+				})...)
+				lines = append(lines, " }")
+			}
+		}
+	case "deployment":
+		if before, okBefore := diff.Before.(Deployment); okBefore {
+			if _, okAfter := diff.After.(Deployment); okAfter {
+				lines = append(lines, fmt.Sprintf(" deployment \"%s\" {", before.Name))
+				lines = append(lines, formatFieldChanges(diff.Changes, formatField)...)
+				lines = append(lines, " }")
+			}
+		}
+	case "orchestrate_rule":
+		if before, okBefore := diff.Before.(OrchestrateRule); okBefore {
+			if _, okAfter := diff.After.(OrchestrateRule); okAfter {
+				lines = append(lines, fmt.Sprintf(" orchestrate \"%s\" \"%s\" {", before.Type, before.Name))
+				lines = append(lines, formatFieldChanges(diff.Changes, formatField)...)
+				lines = append(lines, " }")
+			}
+		}
+	case "identity_token":
+		if before, okBefore := diff.Before.(IdentityToken); okBefore {
+			if _, okAfter := diff.After.(IdentityToken); okAfter {
+				lines = append(lines, fmt.Sprintf(" identity_token \"%s\" {", before.Name))
+				lines = append(lines, formatFieldChanges(diff.Changes, formatField)...)
+				lines = append(lines, " }")
+			}
+		}
+	case "terragrunt":
+		lines = append(lines, " "+terragruntBlockHeader(diff.Element, diff.Before))
+		lines = append(lines, formatFieldChanges(diff.Changes, formatField)...)
+		lines = append(lines, " }")
+	case "test_file":
+		if before, okBefore := diff.Before.(TestFile); okBefore {
+			if _, okAfter := diff.After.(TestFile); okAfter {
+				lines = append(lines, fmt.Sprintf(" # %s", before.Path))
+				lines = append(lines, formatFieldChanges(diff.Changes, formatField)...)
+			}
+		}
+	case "test_run":
+		if before, okBefore := diff.Before.(TestRun); okBefore {
+			if _, okAfter := diff.After.(TestRun); okAfter {
+				lines = append(lines, fmt.Sprintf(" run \"%s\" {  # %s", before.Name, diff.Element))
+				lines = append(lines, formatFieldChanges(diff.Changes, func(name string, value interface{}) string {
+					switch name {
+					case "command":
+						return fmt.Sprintf("%s = %s", name, value)
+					case "module":
+						return fmt.Sprintf("module { source = \"%s\" }", value)
 					}
-					if after.DefaultValue != "" {
-						lines = append(lines, fmt.Sprintf("+  default = %s", after.DefaultValue))
+					if items, ok := value.([]interface{}); ok {
+						names := make([]string, len(items))
+						for i, item := range items {
+							names[i] = fmt.Sprint(item)
+						}
+						return fmt.Sprintf("%s = [%s]", name, strings.Join(names, ", "))
 					}
-				}
-				
+					return formatField(name, value)
+				})...)
 				lines = append(lines, " }")
 			}
 		}
@@ -303,173 +967,172 @@ func formatAttributeDiff(diff Diff, config ComparisonConfig) []string {
 	return lines
 }
 
-// compareInterfaceMapAttributes compares two interface maps and returns diff lines
-func compareInterfaceMapAttributes(before, after map[string]interface{}) []string {
+// formatConfigChanges formats the attribute changes of a resource, data source or nested
+// block config. Change paths are relative to the config.
+func formatConfigChanges(changes []AttributeChange, before, after map[string]interface{}, at attributePath, keys map[string][]string) []string {
 	var lines []string
 	
-	// Get all keys from both maps
-	allKeys := make(map[string]bool)
-	for key := range before {
-		allKeys[key] = true
-	}
-	for key := range after {
-		allKeys[key] = true
+	blockTypes := make(map[string]bool)
+	for blockType := range configBlocks(before) {
+		blockTypes[blockType] = true
 	}
-	
-	// Sort keys for consistent output
-	var sortedKeys []string
-	for key := range allKeys {
-		sortedKeys = append(sortedKeys, key)
+	for blockType := range configBlocks(after) {
+		blockTypes[blockType] = true
 	}
-	sort.Strings(sortedKeys)
 	
-	// Compare each key
-	for _, key := range sortedKeys {
-		beforeVal, beforeExists := before[key]
-		afterVal, afterExists := after[key]
-		
-		// Handle _blocks specially for nested blocks comparison
-		if key == "_blocks" {
-			blockLines := compareNestedBlocks(beforeVal, afterVal)
-			lines = append(lines, blockLines...)
-			continue
-		}
-		
-		// Convert to string for display, skipping complex expressions
-		var beforeStr, afterStr string
-		beforeStr = interfaceToDisplayString(beforeVal)
-		afterStr = interfaceToDisplayString(afterVal)
-		
-		// Skip complex expressions and empty values
-		if (beforeExists && (beforeStr == "" || beforeStr == "<complex_expression>" || strings.HasPrefix(beforeStr, "<"))) &&
-		   (afterExists && (afterStr == "" || afterStr == "<complex_expression>" || strings.HasPrefix(afterStr, "<"))) {
-			continue
+	for i := 0; i < len(changes); {
+		name, segment := changeSegment(changes[i].Path, blockTypes)
+		j := i + 1
+		for j < len(changes) {
+			if _, next := changeSegment(changes[j].Path, blockTypes); next != segment {
+				break
+			}
+			j++
 		}
+		group := changes[i:j]
+		i = j
 		
-		if !beforeExists && afterExists {
-			if isDisplayableValue(afterStr) {
-				lines = append(lines, fmt.Sprintf("  + %s = \"%s\"", key, afterStr))
-			}
-		} else if beforeExists && !afterExists {
-			if isDisplayableValue(beforeStr) {
-				lines = append(lines, fmt.Sprintf("  - %s = \"%s\"", key, beforeStr))
-			}
-		} else if beforeExists && afterExists && beforeStr != afterStr {
-			if isDisplayableValue(beforeStr) || isDisplayableValue(afterStr) {
-				lines = append(lines, fmt.Sprintf("  - %s = \"%s\"", key, beforeStr))
-				lines = append(lines, fmt.Sprintf("  + %s = \"%s\"", key, afterStr))
+		switch {
+		case segment != name:
+			// Nested block changes
+			lines = append(lines, formatBlockChanges(name, segment, group, before, after, at.child(name), keys)...)
+		case len(group) == 1 && group[0].Path == name:
+			change := group[0]
+			beforeStr, afterStr := interfaceToDisplayString(change.Before), interfaceToDisplayString(change.After)
+			switch change.Action {
+			case DiffTypeAdded:
+				if isDisplayableValue(afterStr) {
+					lines = append(lines, "  + "+formatField(name, change.After))
+				}
+			case DiffTypeRemoved:
+				if isDisplayableValue(beforeStr) {
+					lines = append(lines, "  - "+formatField(name, change.Before))
+				}
+			default:
+				if isDisplayableValue(beforeStr) || isDisplayableValue(afterStr) {
+					beforeLine, afterLine := formatField(name, change.Before), formatField(name, change.After)
+					beforeType, afterType := typeAnnotations(beforeLine == afterLine, change.Before, change.After)
+					lines = append(lines, "  - "+beforeLine+beforeType)
+					lines = append(lines, "  + "+afterLine+afterType)
+				}
 			}
+		default:
+			// Show the keys and elements that changed instead of the whole collection
+			lines = append(lines, formatCollectionChanges(name, group)...)
 		}
 	}
 	
 	return lines
 }
 
-// compareNestedBlocks compares nested blocks (like ingress/egress) and returns diff lines
-func compareNestedBlocks(beforeVal, afterVal interface{}) []string {
-	var lines []string
-	
-	// Convert to map[string][]map[string]interface{} if possible
-	beforeBlocks, beforeOk := beforeVal.(map[string][]map[string]interface{})
-	afterBlocks, afterOk := afterVal.(map[string][]map[string]interface{})
-	
-	// If either is nil or not the expected type, handle accordingly
-	if !beforeOk && !afterOk {
+// formatBlockChanges formats the changes of one nested block. A removed or added block is
+// shown whole; a block paired by key attributes shows its changes with the key attributes
+// for context.
+func formatBlockChanges(blockType, segment string, changes []AttributeChange, before, after map[string]interface{}, at attributePath, keys map[string][]string) []string {
+	// A removed and an added block may share the index of the segment
+	if changes[0].Path == segment {
+		var lines []string
+		for _, change := range changes {
+			if block, ok := change.Before.(map[string]interface{}); ok && change.Action == DiffTypeRemoved {
+				lines = append(lines, formatNestedBlockDiff(blockType, block, "-")...)
+			}
+			if block, ok := change.After.(map[string]interface{}); ok && change.Action == DiffTypeAdded {
+				lines = append(lines, formatNestedBlockDiff(blockType, block, "+")...)
+			}
+		}
 		return lines
 	}
 	
-	if !beforeOk {
-		beforeBlocks = make(map[string][]map[string]interface{})
-	}
-	if !afterOk {
-		afterBlocks = make(map[string][]map[string]interface{})
+	var index int
+	fmt.Sscanf(strings.TrimPrefix(segment, blockType), "[%d]", &index)
+	beforeList := withoutIgnoredBlocks(configBlocks(before)[blockType], at)
+	afterList := withoutIgnoredBlocks(configBlocks(after)[blockType], at)
+	if index >= len(beforeList) {
+		return nil
 	}
+	beforeBlock := beforeList[index]
+	afterBlock := pairedBlock(beforeList, afterList, index, at, keys[blockType])
 	
-	// Get all block types
-	blockTypes := make(map[string]bool)
-	for blockType := range beforeBlocks {
-		blockTypes[blockType] = true
+	lines := []string{fmt.Sprintf("  %s {", blockType)}
+	for _, attribute := range keys[blockType] {
+		lines = append(lines, "      "+formatField(attribute, beforeBlock[attribute]))
 	}
-	for blockType := range afterBlocks {
-		blockTypes[blockType] = true
-	}
-	
-	// Sort block types for consistent output
-	var sortedBlockTypes []string
-	for blockType := range blockTypes {
-		sortedBlockTypes = append(sortedBlockTypes, blockType)
+	for _, line := range formatConfigChanges(relativeChanges(segment, changes), beforeBlock, afterBlock, at, keys) {
+		lines = append(lines, "  "+line)
 	}
-	sort.Strings(sortedBlockTypes)
-	
-	// Compare each block type
-	for _, blockType := range sortedBlockTypes {
-		beforeList := beforeBlocks[blockType]
-		afterList := afterBlocks[blockType]
-		
-		// Find which blocks were added, removed, or are common
-		matched := make([]bool, len(afterList))
-		
-		// First pass: find exact matches and removals
-		for _, beforeBlock := range beforeList {
-			found := false
-			for j, afterBlock := range afterList {
-				if !matched[j] && blocksEqual(beforeBlock, afterBlock) {
-					matched[j] = true
-					found = true
-					break
-				}
-			}
-			
-			if !found {
-				// Block was removed
-				lines = append(lines, formatNestedBlockDiff(blockType, beforeBlock, "-")...)
+	lines = append(lines, "  }")
+	return lines
+}
+
+// formatFieldChanges formats the attribute changes of a module call, output or variable.
+// Whole values are formatted by the format function, which returns an empty string for
+// values not worth showing; collection values show the keys and elements that changed.
+func formatFieldChanges(changes []AttributeChange, format func(name string, value interface{}) string) []string {
+	var lines []string
+	for i := 0; i < len(changes); {
+		name, _ := changeSegment(changes[i].Path, nil)
+		j := i + 1
+		for j < len(changes) {
+			if next, _ := changeSegment(changes[j].Path, nil); next != name {
+				break
 			}
+			j++
 		}
+		group := changes[i:j]
+		i = j
 		
-		// Second pass: find additions
-		for j, afterBlock := range afterList {
-			if !matched[j] {
-				// Block was added
-				lines = append(lines, formatNestedBlockDiff(blockType, afterBlock, "+")...)
-			}
+		if len(group) > 1 || group[0].Path != name {
+			lines = append(lines, formatCollectionChanges(name, group)...)
+			continue
+		}
+		beforeLine, afterLine := format(name, group[0].Before), format(name, group[0].After)
+		beforeType, afterType := typeAnnotations(group[0].Action == DiffTypeModified && beforeLine == afterLine, group[0].Before, group[0].After)
+		if group[0].Action != DiffTypeAdded && beforeLine != "" {
+			lines = append(lines, "-  "+beforeLine+beforeType)
+		}
+		if group[0].Action != DiffTypeRemoved && afterLine != "" {
+			lines = append(lines, "+  "+afterLine+afterType)
 		}
 	}
-	
 	return lines
 }
 
-// blocksEqual checks if two blocks are equal
-func blocksEqual(a, b map[string]interface{}) bool {
-	if len(a) != len(b) {
-		return false
+// typeAnnotations names the types of two values that display alike, such as a list and a set
+// of the same strings
+func typeAnnotations(alike bool, before, after interface{}) (string, string) {
+	beforeVal, beforeOk := before.(Value)
+	afterVal, afterOk := after.(Value)
+	if !alike || !beforeOk || !afterOk {
+		return "", ""
 	}
-	
-	for key, aVal := range a {
-		bVal, exists := b[key]
-		if !exists {
-			return false
-		}
-		
-		// Handle different types of values
-		switch aTyped := aVal.(type) {
-		case string:
-			bTyped, ok := bVal.(string)
-			if !ok || aTyped != bTyped {
-				return false
-			}
-		case []interface{}:
-			bTyped, ok := bVal.([]interface{})
-			if !ok || !reflect.DeepEqual(aTyped, bTyped) {
-				return false
-			}
-		default:
-			if !reflect.DeepEqual(aVal, bVal) {
-				return false
-			}
-		}
+	return fmt.Sprintf(" (%s)", beforeVal.TypeName()), fmt.Sprintf(" (%s)", afterVal.TypeName())
+}
+
+// changeSegment splits the first segment off a change path: the attribute name, and the
+// segment, which for nested blocks includes the block index (ingress[0])
+func changeSegment(path string, blockTypes map[string]bool) (name, segment string) {
+	name = path
+	if i := strings.IndexAny(path, ".["); i >= 0 {
+		name = path[:i]
 	}
-	
-	return true
+	if !blockTypes[name] || !strings.HasPrefix(path[len(name):], "[") {
+		return name, name
+	}
+	segment = path
+	if i := strings.Index(path, "."); i >= 0 {
+		segment = path[:i]
+	}
+	return name, segment
+}
+
+// relativeChanges returns the changes with the paths made relative to a prefix
+func relativeChanges(prefix string, changes []AttributeChange) []AttributeChange {
+	result := make([]AttributeChange, len(changes))
+	for i, change := range changes {
+		change.Path = strings.TrimPrefix(strings.TrimPrefix(change.Path, prefix), ".")
+		result[i] = change
+	}
+	return result
 }
 
 // formatNestedBlockDiff formats a nested block for diff output
@@ -493,9 +1156,8 @@ func formatNestedBlockDiff(blockType string, block map[string]interface{}, prefi
 	// Format each attribute
 	for _, key := range keys {
 		value := block[key]
-		valueStr := interfaceToDisplayString(value)
-		if isDisplayableValue(valueStr) {
-			lines = append(lines, fmt.Sprintf("%s   %s = \"%s\"", prefix, key, valueStr))
+		if isDisplayableValue(interfaceToDisplayString(value)) {
+			lines = append(lines, fmt.Sprintf("%s   %s", prefix, formatField(key, value)))
 		}
 	}
 	
@@ -505,6 +1167,84 @@ func formatNestedBlockDiff(blockType string, block map[string]interface{}, prefi
 	return lines
 }
 
+// formatNestedValue formats a value as HCL-like lines, with maps and lists spread over nested
+// lines. The label prefixes the first line, such as "tags = " or "" for a list element.
+func formatNestedValue(label string, value interface{}, indent string) []string {
+	switch data := displayData(decodeCollection(value)).(type) {
+	case map[string]interface{}:
+		if len(data) == 0 {
+			return []string{indent + label + "{}"}
+		}
+		keys := make(map[string]bool)
+		for key := range data {
+			keys[key] = true
+		}
+		lines := []string{indent + label + "{"}
+		for _, key := range sortedKeys(keys) {
+			lines = append(lines, formatNestedValue(key+" = ", data[key], indent+"  ")...)
+		}
+		return append(lines, indent+"}")
+	case []interface{}:
+		if len(data) == 0 {
+			return []string{indent + label + "[]"}
+		}
+		lines := []string{indent + label + "["}
+		for _, item := range data {
+			itemLines := formatNestedValue("", item, indent+"  ")
+			itemLines[len(itemLines)-1] += ","
+			lines = append(lines, itemLines...)
+		}
+		return append(lines, indent+"]")
+	case nil:
+		return []string{indent + label + "null"}
+	default:
+		return []string{indent + label + formatDisplayValue(data)}
+	}
+}
+
+// formatDisplayValue formats a value for text output with quotes that follow its type:
+// strings, and expressions in interpolation syntax, are quoted, while numbers, bools and null
+// are not. Collections are formatted as JSON.
+func formatDisplayValue(value interface{}) string {
+	if v, ok := value.(*Value); ok {
+		if v == nil {
+			return "null"
+		}
+		value = *v
+	}
+	switch data := displayData(decodeCollection(value)).(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("\"%s\"", data)
+	case json.Number:
+		return data.String()
+	case float64:
+		return strconv.FormatFloat(data, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(data)
+	default:
+		return formatChangeValue(data)
+	}
+}
+
+// formatConfigValues formats the attributes of a resource or data source config in sorted
+// order, with maps and lists spread over nested lines. Nested blocks and labels are not
+// displayed.
+func formatConfigValues(config map[string]interface{}) []string {
+	keys := make(map[string]bool)
+	for key, value := range config {
+		if _, ok := displayConfigValue(value); ok {
+			keys[key] = true
+		}
+	}
+	var lines []string
+	for _, key := range sortedKeys(keys) {
+		lines = append(lines, formatNestedValue(key+" = ", config[key], "  ")...)
+	}
+	return lines
+}
+
 // interfaceToDisplayString converts interface{} to string for display purposes
 func interfaceToDisplayString(value interface{}) string {
 	if value == nil {
@@ -513,6 +1253,15 @@ func interfaceToDisplayString(value interface{}) string {
 	if str, ok := value.(string); ok {
 		return str
 	}
+	switch v := value.(type) {
+	case Value:
+		return v.String()
+	case *Value:
+		if v == nil {
+			return ""
+		}
+		return v.String()
+	}
 	// For non-string values, convert to JSON or mark as complex
 	if jsonBytes, err := json.Marshal(value); err == nil {
 		return string(jsonBytes)
@@ -520,55 +1269,19 @@ func interfaceToDisplayString(value interface{}) string {
 	return "<complex_expression>"
 }
 
-// compareMapAttributes compares two string maps and returns diff lines
-func compareMapAttributes(before, after map[string]string) []string {
-	var lines []string
-	
-	// Get all keys from both maps
-	allKeys := make(map[string]bool)
-	for key := range before {
-		allKeys[key] = true
-	}
-	for key := range after {
-		allKeys[key] = true
-	}
-	
-	// Sort keys for consistent output
-	var sortedKeys []string
-	for key := range allKeys {
-		sortedKeys = append(sortedKeys, key)
-	}
-	sort.Strings(sortedKeys)
-	
-	// Compare each key
-	for _, key := range sortedKeys {
-		beforeVal, beforeExists := before[key]
-		afterVal, afterExists := after[key]
-		
-		// Skip complex expressions and empty values
-		if (beforeExists && (beforeVal == "" || beforeVal == "<complex_expression>" || strings.HasPrefix(beforeVal, "<"))) &&
-		   (afterExists && (afterVal == "" || afterVal == "<complex_expression>" || strings.HasPrefix(afterVal, "<"))) {
-			continue
-		}
-		
-		if !beforeExists && afterExists && afterVal != "" && afterVal != "<complex_expression>" && !strings.HasPrefix(afterVal, "<") {
-			// Added attribute
-			lines = append(lines, fmt.Sprintf("+  %s = \"%s\"", key, afterVal))
-		} else if beforeExists && !afterExists && beforeVal != "" && beforeVal != "<complex_expression>" && !strings.HasPrefix(beforeVal, "<") {
-			// Removed attribute
-			lines = append(lines, fmt.Sprintf("-  %s = \"%s\"", key, beforeVal))
-		} else if beforeExists && afterExists && beforeVal != afterVal {
-			// Modified attribute
-			if beforeVal != "" && beforeVal != "<complex_expression>" && !strings.HasPrefix(beforeVal, "<") {
-				lines = append(lines, fmt.Sprintf("-  %s = \"%s\"", key, beforeVal))
-			}
-			if afterVal != "" && afterVal != "<complex_expression>" && !strings.HasPrefix(afterVal, "<") {
-				lines = append(lines, fmt.Sprintf("+  %s = \"%s\"", key, afterVal))
-			}
-		}
+// displayConfigValue formats an attribute value of a config for display. Nested blocks and
+// labels are not displayed.
+func displayConfigValue(value interface{}) (string, bool) {
+	var valueStr string
+	switch v := value.(type) {
+	case Value:
+		valueStr = v.String()
+	case string:
+		valueStr = v
+	default:
+		return "", false
 	}
-	
-	return lines
+	return valueStr, isDisplayableValue(valueStr)
 }
 
 // groupDiffsByLevel groups diffs by their level
@@ -595,6 +1308,22 @@ func formatLevelName(level string) string {
 		return "📊 Data Sources"
 	case "variable":
 		return "🔧 Variables"
+	case "required_provider":
+		return "🔌 Required Providers"
+	case "component":
+		return "🧩 Components"
+	case "deployment":
+		return "🚀 Deployments"
+	case "orchestrate_rule":
+		return "🎛️  Orchestrate Rules"
+	case "identity_token":
+		return "🔑 Identity Tokens"
+	case "terragrunt":
+		return "🌍 Terragrunt"
+	case "test_file":
+		return "🧪 Test Files"
+	case "test_run":
+		return "🧪 Test Runs"
 	default:
 		return strings.Title(strings.ReplaceAll(level, "_", " "))
 	}
@@ -609,6 +1338,8 @@ func getDiffIcon(diffType DiffType) string {
 		return "➖"
 	case DiffTypeModified:
 		return "📝"
+	case DiffTypeRenamed:
+		return "🔀"
 	default:
 		return "❓"
 	}
@@ -709,8 +1440,8 @@ func formatVariableDetails(before, after Variable) string {
 	if before.Description != after.Description {
 		details.WriteString(fmt.Sprintf("      Description: %s → %s\n", before.Description, after.Description))
 	}
-	if before.DefaultValue != after.DefaultValue {
-		details.WriteString(fmt.Sprintf("      Default: %s → %s\n", before.DefaultValue, after.DefaultValue))
+	if !optionalValuesEqual(before.DefaultValue, after.DefaultValue) {
+		details.WriteString(fmt.Sprintf("      Default: %s → %s\n", interfaceToDisplayString(before.DefaultValue), interfaceToDisplayString(after.DefaultValue)))
 	}
 	
 	return details.String()
@@ -0,0 +1,272 @@
+package tfdiff
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ParseRevisionRange splits a REV1..REV2 revision range. An empty side defaults to HEAD.
+func ParseRevisionRange(spec string) (string, string, error) {
+	left, right, found := strings.Cut(spec, "..")
+	if !found || strings.HasPrefix(right, ".") {
+		return "", "", fmt.Errorf("invalid revision range %q: expected REV1..REV2", spec)
+	}
+	if left == "" {
+		left = "HEAD"
+	}
+	if right == "" {
+		right = "HEAD"
+	}
+	return left, right, nil
+}
+
+// ParseModuleGit parses a module directory as it exists at a revision of its git repository.
+// The files of the module and of its parent directories are read with git plumbing commands
+// into memory, so the working tree is neither read nor modified. The module path of the
+// result is REVISION:PATH.
+func ParseModuleGit(modulePath, revision string, options ParseOptions) (*ModuleDefinition, error) {
+	repoRoot, modulePrefix, err := gitModulePrefix(modulePath)
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := runGit(repoRoot, nil, "rev-parse", "--verify", "--quiet", revision+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %s", revision)
+	}
+
+	files, err := readGitModuleFiles(repoRoot, strings.TrimSpace(string(commit)), modulePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at %s: %w", modulePath, revision, err)
+	}
+
+	module := moduleFS{
+		fsys: files,
+		dir:  modulePrefix,
+		path: modulePath,
+	}
+	found, err := containsModuleFiles(module)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%s does not contain Terraform files at revision %s", modulePath, revision)
+	}
+
+	def, err := parseModuleFS(module, options)
+	if err != nil {
+		return nil, err
+	}
+	def.Path = fmt.Sprintf("%s:%s", revision, modulePath)
+	return def, nil
+}
+
+// gitModulePrefix returns the root of the repository containing modulePath and the
+// slash-separated path of the module within it. The module directory does not need to
+// exist in the working tree.
+func gitModulePrefix(modulePath string) (string, string, error) {
+	abs, err := filepath.Abs(modulePath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve %s: %w", modulePath, err)
+	}
+
+	// Run git from the nearest existing directory
+	dir := abs
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	out, err := runGit(dir, nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", "", fmt.Errorf("%s is not inside a git repository: %w", modulePath, err)
+	}
+	repoRoot := strings.TrimSpace(string(out))
+
+	// Compare symlink-resolved paths, since git reports the resolved repository root
+	if eval, err := filepath.EvalSymlinks(dir); err == nil {
+		if rel, err := filepath.Rel(dir, abs); err == nil {
+			abs = filepath.Join(eval, rel)
+		}
+	}
+	rel, err := filepath.Rel(repoRoot, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", "", fmt.Errorf("%s is outside of the git repository %s", modulePath, repoRoot)
+	}
+	return repoRoot, filepath.ToSlash(rel), nil
+}
+
+// gitSymlinkMode is the mode of a symbolic link in a git tree, whose blob holds the target
+const gitSymlinkMode = "120000"
+
+// gitTreeEntry is a blob in a git tree
+type gitTreeEntry struct {
+	mode, object string
+}
+
+// readGitModuleFiles reads the files below the module directory and the files directly in
+// each of its parent directories (for Terragrunt includes) from a commit into memory.
+// Symbolic links are followed within the commit, like a working tree follows them.
+func readGitModuleFiles(repoRoot, commit, modulePrefix string) (memFS, error) {
+	out, err := runGit(repoRoot, nil, "ls-tree", "-r", "-z", "--full-tree", commit)
+	if err != nil {
+		return nil, err
+	}
+
+	ancestors := map[string]bool{".": true}
+	for dir := modulePrefix; dir != "."; dir = path.Dir(dir) {
+		ancestors[dir] = true
+	}
+
+	entries := make(map[string]gitTreeEntry)
+	var names, links []string
+	for _, entry := range strings.Split(string(out), "\x00") {
+		// Each entry is "<mode> <type> <object>\t<path>"
+		meta, name, found := strings.Cut(entry, "\t")
+		if !found {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 3 || fields[1] != "blob" {
+			continue
+		}
+		entries[name] = gitTreeEntry{mode: fields[0], object: fields[2]}
+		if fields[0] == gitSymlinkMode {
+			links = append(links, name)
+		}
+		if isModuleFile(name, modulePrefix) || ancestors[path.Dir(name)] {
+			names = append(names, name)
+		}
+	}
+
+	linkObjects := make([]string, 0, len(links))
+	for _, name := range links {
+		linkObjects = append(linkObjects, entries[name].object)
+	}
+	linkTargets, err := readGitObjects(repoRoot, links, linkObjects)
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(links))
+	for i, name := range links {
+		targets[name] = string(linkTargets[i])
+	}
+
+	var fileNames, objects []string
+	for _, name := range names {
+		// Links to directories or outside of the repository are skipped
+		if object, ok := resolveGitSymlink(name, entries, targets); ok {
+			fileNames = append(fileNames, name)
+			objects = append(objects, object)
+		}
+	}
+
+	contents, err := readGitObjects(repoRoot, fileNames, objects)
+	if err != nil {
+		return nil, err
+	}
+	files := make(memFS)
+	for i, name := range fileNames {
+		files[name] = contents[i]
+	}
+	return files, nil
+}
+
+// resolveGitSymlink follows a path through symbolic links within a tree to the object of
+// the regular file it points to
+func resolveGitSymlink(name string, entries map[string]gitTreeEntry, targets map[string]string) (string, bool) {
+	// Give up on link loops after as many links as Linux follows
+	for hops := 0; hops <= 40; hops++ {
+		entry, ok := entries[name]
+		if !ok {
+			return "", false
+		}
+		if entry.mode != gitSymlinkMode {
+			return entry.object, true
+		}
+		target := targets[name]
+		if path.IsAbs(target) {
+			return "", false
+		}
+		name = path.Join(path.Dir(name), target)
+		if name == ".." || strings.HasPrefix(name, "../") {
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// readGitObjects reads the content of blobs. Names label the objects in errors.
+func readGitObjects(repoRoot string, names, objects []string) ([][]byte, error) {
+	if len(objects) == 0 {
+		return nil, nil
+	}
+
+	out, err := runGit(repoRoot, []byte(strings.Join(objects, "\n")+"\n"), "cat-file", "--batch")
+	if err != nil {
+		return nil, err
+	}
+
+	// Each object is "<object> <type> <size>\n<content>\n"
+	contents := make([][]byte, 0, len(objects))
+	reader := bufio.NewReader(bytes.NewReader(out))
+	for _, name := range names {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("failed to read object of %s: %w", name, err)
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected object header for %s: %q", name, header)
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected object size for %s: %q", name, header)
+		}
+		content := make([]byte, size+1)
+		if _, err := io.ReadFull(reader, content); err != nil {
+			return nil, fmt.Errorf("failed to read object of %s: %w", name, err)
+		}
+		contents = append(contents, content[:size])
+	}
+	return contents, nil
+}
+
+// isModuleFile reports whether a repository path is below the module directory
+func isModuleFile(name, modulePrefix string) bool {
+	return modulePrefix == "." || strings.HasPrefix(name, modulePrefix+"/")
+}
+
+// runGit runs a git command in dir and returns its standard output
+func runGit(dir string, stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], message)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
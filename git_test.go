@@ -0,0 +1,200 @@
+package tfdiff
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseRevisionRange(t *testing.T) {
+	tests := []struct {
+		spec    string
+		left    string
+		right   string
+		wantErr bool
+	}{
+		{spec: "main..feature", left: "main", right: "feature"},
+		{spec: "v1.0.0..", left: "v1.0.0", right: "HEAD"},
+		{spec: "..HEAD~1", left: "HEAD", right: "HEAD~1"},
+		{spec: "main", wantErr: true},
+		{spec: "main...feature", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			left, right, err := ParseRevisionRange(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if left != tt.left || right != tt.right {
+				t.Errorf("expected %s..%s, got %s..%s", tt.left, tt.right, left, right)
+			}
+		})
+	}
+}
+
+func TestParseModuleGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		if _, err := runGit(repo, nil, args...); err != nil {
+			t.Fatalf("git %v failed: %v", args, err)
+		}
+	}
+
+	git("init", "-q")
+	git("config", "user.email", "tfdiff@example.com")
+	git("config", "user.name", "tfdiff")
+
+	writeModuleFiles(t, repo, map[string]string{
+		"terragrunt.hcl": terragruntRootContent,
+		"env/production/main.tf": `
+resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+}
+`,
+		"env/production/terragrunt.hcl": `
+include "root" {
+  path = find_in_parent_folders()
+}
+`,
+	})
+	git("add", "-A")
+	git("commit", "-q", "-m", "first")
+	git("tag", "v1")
+
+	writeModuleFiles(t, repo, map[string]string{
+		"env/production/main.tf": `
+resource "aws_instance" "web" {
+  instance_type = "t3.large"
+}
+`,
+	})
+	git("commit", "-q", "-a", "-m", "second")
+
+	// Uncommitted changes must not be read
+	writeModuleFiles(t, repo, map[string]string{
+		"env/production/extra.tf": `
+resource "aws_s3_bucket" "uncommitted" {
+}
+`,
+	})
+
+	modulePath := filepath.Join(repo, "env", "production")
+	left, err := ParseModuleGit(modulePath, "v1", ParseOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse v1: %v", err)
+	}
+	right, err := ParseModuleGit(modulePath, "HEAD", ParseOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse HEAD: %v", err)
+	}
+
+	if left.Path != "v1:"+modulePath || right.Path != "HEAD:"+modulePath {
+		t.Errorf("unexpected module paths: %s, %s", left.Path, right.Path)
+	}
+	if len(right.Resources) != 1 {
+		t.Fatalf("expected only committed resources, got %+v", right.Resources)
+	}
+	if left.Terragrunt == nil || left.Terragrunt.RemoteState == nil || left.Terragrunt.Includes[0].Path != "../../terragrunt.hcl" {
+		t.Errorf("expected terragrunt include to be resolved from the revision, got %+v", left.Terragrunt)
+	}
+
+	config := ComparisonConfig{Levels: []ComparisonLevel{ComparisonLevelResources}}
+	result := CompareModules(left, right, config)
+	if len(result.Diffs) != 1 || result.Diffs[0].Type != DiffTypeModified {
+		t.Errorf("expected one modified resource, got %+v", result.Diffs)
+	}
+
+	output := FormatTextOutput(result, config, true)
+	if !strings.HasPrefix(output, "--- v1:"+modulePath) {
+		t.Errorf("expected revision in header, got:\n%s", output)
+	}
+
+	if _, err := ParseModuleGit(modulePath, "missing", ParseOptions{}); err == nil {
+		t.Errorf("expected error for unknown revision")
+	}
+	if _, err := ParseModuleGit(filepath.Join(repo, "env", "staging"), "HEAD", ParseOptions{}); err == nil {
+		t.Errorf("expected error for directory missing at revision")
+	}
+	if err := os.RemoveAll(modulePath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseModuleGit(modulePath, "v1", ParseOptions{}); err != nil {
+		t.Errorf("expected directory deleted from the working tree to be read from the revision: %v", err)
+	}
+}
+
+func TestParseModuleGit_Symlinks(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		if _, err := runGit(repo, nil, args...); err != nil {
+			t.Fatalf("git %v failed: %v", args, err)
+		}
+	}
+
+	git("init", "-q")
+	git("config", "user.email", "tfdiff@example.com")
+	git("config", "user.name", "tfdiff")
+	git("config", "core.symlinks", "true")
+
+	writeModuleFiles(t, repo, map[string]string{
+		"shared/v2.tf": `
+resource "aws_s3_bucket" "shared" {
+  bucket = "shared"
+}
+`,
+		"module/main.tf": `
+resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+}
+`,
+	})
+	// A link to a link to a file, and a link to a directory
+	for link, target := range map[string]string{
+		"shared/current.tf":   "v2.tf",
+		"module/providers.tf": "../shared/current.tf",
+		"module/shared":       "../shared",
+	} {
+		if err := os.Symlink(target, filepath.Join(repo, link)); err != nil {
+			t.Skipf("symbolic links are not supported: %v", err)
+		}
+	}
+	git("add", "-A")
+	git("commit", "-q", "-m", "first")
+
+	moduleDir := filepath.Join(repo, "module")
+	def, err := ParseModuleGit(moduleDir, "HEAD", ParseOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse HEAD: %v", err)
+	}
+	working, err := ParseModuleHCL(moduleDir)
+	if err != nil {
+		t.Fatalf("failed to parse the working tree: %v", err)
+	}
+
+	config := ComparisonConfig{Levels: []ComparisonLevel{ComparisonLevelResources}}
+	if result := CompareModules(working, def, config); len(result.Diffs) != 0 {
+		t.Errorf("expected the revision to parse like the working tree, got %+v", result.Diffs)
+	}
+	if len(def.Resources) != 2 {
+		t.Errorf("expected the linked resources to be parsed, got %+v", def.Resources)
+	}
+}
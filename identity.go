@@ -0,0 +1,145 @@
+package tfdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IdentityRule matches resources of a type across modules by the value of an attribute
+// instead of by name. Right-side values are matched after substitutions.
+type IdentityRule struct {
+	Type      string `hcl:"type,label" json:"type"`
+	Attribute string `hcl:"attribute" json:"attribute"`
+}
+
+// ResourceMatch explicitly matches a left resource with a right resource of another name
+type ResourceMatch struct {
+	Left  string `hcl:"left" json:"left"`
+	Right string `hcl:"right" json:"right"`
+}
+
+// resourcePair is a left and a right resource compared with each other; one of them is nil
+// when the resource exists on one side only
+type resourcePair struct {
+	left, right *Resource
+}
+
+// matchResources pairs the resources of two modules. Explicit matches take precedence over
+// identity rules, which take precedence over matching by type and name. Each resource is
+// paired at most once.
+func matchResources(left, right []Resource, config ComparisonConfig) []resourcePair {
+	leftByKey := make(map[string]*Resource, len(left))
+	for i := range left {
+		leftByKey[resourceKey(left[i])] = &left[i]
+	}
+	rightByKey := make(map[string]*Resource, len(right))
+	for i := range right {
+		rightByKey[resourceKey(right[i])] = &right[i]
+	}
+
+	var pairs []resourcePair
+	pairedLeft := make(map[string]bool)
+	pairedRight := make(map[string]bool)
+	pair := func(leftKey, rightKey string) {
+		l, r := leftByKey[leftKey], rightByKey[rightKey]
+		if l == nil || r == nil || pairedLeft[leftKey] || pairedRight[rightKey] {
+			return
+		}
+		pairedLeft[leftKey] = true
+		pairedRight[rightKey] = true
+		pairs = append(pairs, resourcePair{l, r})
+	}
+
+	matches := append(append([]ResourceMatch{}, config.ResourceMatches...), identityMatches(left, right, config.Identities, config.Substitutions)...)
+	for _, match := range matches {
+		pair(match.Left, match.Right)
+	}
+	for key := range leftByKey {
+		pair(key, key)
+	}
+
+	for key, l := range leftByKey {
+		if !pairedLeft[key] {
+			pairs = append(pairs, resourcePair{left: l})
+		}
+	}
+	for key, r := range rightByKey {
+		if !pairedRight[key] {
+			pairs = append(pairs, resourcePair{right: r})
+		}
+	}
+	return pairs
+}
+
+// identityMatches lists the resources matched by identity rules. Right-side attribute values
+// are compared after applying the substitutions. Values shared by several resources on one
+// side are ambiguous and match nothing.
+func identityMatches(left, right []Resource, rules []IdentityRule, substitutions []Substitution) []ResourceMatch {
+	if len(rules) == 0 {
+		return nil
+	}
+	if len(substitutions) > 0 {
+		right = substituteModule(&ModuleDefinition{Resources: right}, substitutions).Resources
+	}
+
+	var matches []ResourceMatch
+	for _, rule := range rules {
+		leftKeys := identityIndex(left, rule)
+		rightKeys := identityIndex(right, rule)
+		values := make([]string, 0, len(leftKeys))
+		for value := range leftKeys {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+		for _, value := range values {
+			l, r := leftKeys[value], rightKeys[value]
+			if len(l) == 1 && len(r) == 1 {
+				matches = append(matches, ResourceMatch{Left: l[0], Right: r[0]})
+			}
+		}
+	}
+	return matches
+}
+
+// identityIndex indexes the keys of the resources of a rule's type by the rule's attribute value
+func identityIndex(resources []Resource, rule IdentityRule) map[string][]string {
+	index := make(map[string][]string)
+	for _, r := range resources {
+		if r.Type != rule.Type {
+			continue
+		}
+		value, ok := r.Config[rule.Attribute]
+		if !ok || value == nil {
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+		index[string(data)] = append(index[string(data)], resourceKey(r))
+	}
+	return index
+}
+
+// resolveIdentities turns the identity rules of a configuration into explicit matches, so
+// that comparisons of the original and the substituted right module pair resources alike
+func resolveIdentities(left, right *ModuleDefinition, config ComparisonConfig) ComparisonConfig {
+	if len(config.Identities) == 0 {
+		return config
+	}
+	config.ResourceMatches = append(append([]ResourceMatch{}, config.ResourceMatches...), identityMatches(left.Resources, right.Resources, config.Identities, config.Substitutions)...)
+	config.Identities = nil
+	return config
+}
+
+// validate checks that a match names resources as TYPE.NAME
+func (m ResourceMatch) validate() error {
+	for _, key := range []string{m.Left, m.Right} {
+		if len(strings.Split(key, ".")) != 2 {
+			return fmt.Errorf("invalid resource address %q: expected TYPE.NAME", key)
+		}
+	}
+	return nil
+}
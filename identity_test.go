@@ -0,0 +1,119 @@
+package tfdiff
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestCompareModules_Identities(t *testing.T) {
+	staging := parseTestModule(t, map[string]string{
+		"main.tf": `
+resource "aws_s3_bucket" "stg_logs" {
+  bucket = "logs-stg"
+  tags = {
+    Team = "platform"
+  }
+}
+
+resource "aws_s3_bucket" "stg_assets" {
+  bucket = "assets-stg"
+}
+
+resource "aws_iam_role" "stg_deployer" {
+  name = "deployer-stg"
+}
+`,
+	})
+	production := parseTestModule(t, map[string]string{
+		"main.tf": `
+resource "aws_s3_bucket" "prd_logs" {
+  bucket = "logs-prd"
+  tags = {
+    Team = "security"
+  }
+}
+
+resource "aws_s3_bucket" "prd_assets" {
+  bucket = "assets-prd"
+}
+
+resource "aws_iam_role" "prd_deployer" {
+  name = "deployer-stg"
+}
+`,
+	})
+
+	substitutions := []Substitution{{From: "-prd", To: "-stg"}}
+	identities := []IdentityRule{{Type: "aws_s3_bucket", Attribute: "bucket"}}
+	matches := []ResourceMatch{{Left: "aws_iam_role.stg_deployer", Right: "aws_iam_role.prd_deployer"}}
+
+	tests := []struct {
+		name   string
+		config ComparisonConfig
+		want   []string
+	}{
+		{
+			name:   "by name",
+			config: ComparisonConfig{},
+			want: []string{
+				"added aws_iam_role.prd_deployer", "added aws_s3_bucket.prd_assets", "added aws_s3_bucket.prd_logs",
+				"removed aws_iam_role.stg_deployer", "removed aws_s3_bucket.stg_assets", "removed aws_s3_bucket.stg_logs",
+			},
+		},
+		{
+			name:   "identity after substitution and explicit match",
+			config: ComparisonConfig{Substitutions: substitutions, Identities: identities, ResourceMatches: matches},
+			want:   []string{"modified aws_s3_bucket.stg_logs"},
+		},
+		{
+			name:   "identity without substitution matches nothing",
+			config: ComparisonConfig{Identities: identities, ResourceMatches: matches},
+			want: []string{
+				"added aws_s3_bucket.prd_assets", "added aws_s3_bucket.prd_logs",
+				"removed aws_s3_bucket.stg_assets", "removed aws_s3_bucket.stg_logs",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.Levels = []ComparisonLevel{ComparisonLevelResources}
+			result := CompareModules(staging, production, config)
+
+			var got []string
+			for _, diff := range result.Diffs {
+				got = append(got, string(diff.Type)+" "+diff.Element)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected diffs %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	// A matched resource shows the names of both sides
+	config := ComparisonConfig{Levels: []ComparisonLevel{ComparisonLevelResources}, Identities: identities, Substitutions: substitutions}
+	output := FormatTextOutput(CompareModules(staging, production, config), config, true)
+	for _, want := range []string{`-resource "aws_s3_bucket" "stg_logs" {`, `+resource "aws_s3_bucket" "prd_logs" {`} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestIdentityMatches_Ambiguous(t *testing.T) {
+	left := []Resource{
+		{Type: "aws_s3_bucket", Name: "a", Config: map[string]interface{}{"bucket": "shared"}},
+		{Type: "aws_s3_bucket", Name: "b", Config: map[string]interface{}{"bucket": "shared"}},
+	}
+	right := []Resource{
+		{Type: "aws_s3_bucket", Name: "c", Config: map[string]interface{}{"bucket": "shared"}},
+	}
+
+	matches := identityMatches(left, right, []IdentityRule{{Type: "aws_s3_bucket", Attribute: "bucket"}}, nil)
+	if len(matches) != 0 {
+		t.Errorf("expected ambiguous values to match nothing, got %v", matches)
+	}
+}
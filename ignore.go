@@ -8,6 +8,8 @@ import (
 
 type ParseOptions struct {
 	IgnoreFiles []string
+	// SkipTests skips the Terraform test files (*.tftest.hcl), which only the tests level compares
+	SkipTests bool
 }
 
 func loadIgnorePatterns(extra []string) []string {
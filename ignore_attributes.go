@@ -0,0 +1,173 @@
+package tfdiff
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// attributePath is the path of an attribute being compared together with the attribute
+// ignore patterns and the patterns of list attributes compared as sets. Paths start with the
+// address of the element, such as resource.aws_instance.web, module.vpc or
+// data.aws_ami.ubuntu, followed by the attribute names; map keys and nested block types
+// extend the path.
+type attributePath struct {
+	patterns [][]string
+	sets     [][]string
+	segments []string
+}
+
+// newAttributePath returns the path of an element for the configured ignore and set
+// patterns. The path is inactive when no patterns are configured.
+func newAttributePath(config ComparisonConfig, level, element string) attributePath {
+	if len(config.IgnoreAttributes) == 0 && len(config.SetAttributes) == 0 {
+		return attributePath{}
+	}
+
+	var prefix string
+	switch level {
+	case "module_call":
+		prefix = "module"
+	case "resource":
+		prefix = "resource"
+	case "data_source":
+		prefix = "data"
+	default:
+		prefix = level
+	}
+
+	return attributePath{
+		patterns: splitPathPatterns(config.IgnoreAttributes),
+		sets:     splitPathPatterns(config.SetAttributes),
+		segments: append([]string{prefix}, strings.Split(element, ".")...),
+	}
+}
+
+// splitPathPatterns splits dotted path patterns into segments
+func splitPathPatterns(patterns []string) [][]string {
+	var result [][]string
+	for _, pattern := range patterns {
+		segments := strings.Split(pattern, ".")
+		// A leading * matches any element address and attribute path prefix
+		if segments[0] == "*" {
+			segments[0] = "**"
+		}
+		result = append(result, segments)
+	}
+	return result
+}
+
+// active reports whether any ignore or set pattern is configured
+func (p attributePath) active() bool {
+	return len(p.patterns) > 0 || len(p.sets) > 0
+}
+
+// child returns the path of an attribute or map key below the path
+func (p attributePath) child(key string) attributePath {
+	if !p.active() {
+		return p
+	}
+	segments := make([]string, len(p.segments), len(p.segments)+1)
+	copy(segments, p.segments)
+	return attributePath{patterns: p.patterns, sets: p.sets, segments: append(segments, key)}
+}
+
+// ignored reports whether the path matches any ignore pattern
+func (p attributePath) ignored() bool {
+	return matchesAnyPath(p.patterns, p.segments)
+}
+
+// isSet reports whether the path matches any set pattern, so that a list value at the path
+// is compared regardless of the order of its elements
+func (p attributePath) isSet() bool {
+	return matchesAnyPath(p.sets, p.segments)
+}
+
+// matchesAnyPath reports whether path segments match any of the patterns
+func matchesAnyPath(patterns [][]string, segments []string) bool {
+	for _, pattern := range patterns {
+		if matchPathSegments(pattern, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPathSegments matches path segments against pattern segments. * and other glob
+// characters match within one segment; ** matches any number of segments.
+func matchPathSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchPathSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(pattern[0], segments[0]); !matched {
+		return false
+	}
+	return matchPathSegments(pattern[1:], segments[1:])
+}
+
+// withoutIgnored returns a copy of a config map without its ignored attributes
+func withoutIgnored(config map[string]interface{}, at attributePath) map[string]interface{} {
+	if !at.active() || config == nil {
+		return config
+	}
+	result := make(map[string]interface{}, len(config))
+	for key, value := range config {
+		if !at.child(key).ignored() {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// withoutIgnoredValues returns a copy of a value map without its ignored entries
+func withoutIgnoredValues(values map[string]Value, at attributePath) map[string]Value {
+	if !at.active() || values == nil {
+		return values
+	}
+	result := make(map[string]Value, len(values))
+	for key, value := range values {
+		if !at.child(key).ignored() {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// withoutIgnoredBlocks returns copies of nested blocks without their ignored attributes
+func withoutIgnoredBlocks(blocks []map[string]interface{}, at attributePath) []map[string]interface{} {
+	if !at.active() {
+		return blocks
+	}
+	result := make([]map[string]interface{}, 0, len(blocks))
+	for _, block := range blocks {
+		result = append(result, withoutIgnored(block, at))
+	}
+	return result
+}
+
+// validatePathPatterns reports the first malformed dotted path pattern, such as an attribute
+// ignore pattern or an element address glob
+func validatePathPatterns(kind string, patterns []string) error {
+	for _, pattern := range patterns {
+		for _, segment := range strings.Split(pattern, ".") {
+			if segment == "" {
+				return fmt.Errorf("invalid %s pattern %q: empty path segment", kind, pattern)
+			}
+			if _, err := path.Match(segment, ""); err != nil {
+				return fmt.Errorf("invalid %s pattern %q: %w", kind, pattern, err)
+			}
+		}
+	}
+	return nil
+}
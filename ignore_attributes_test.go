@@ -0,0 +1,136 @@
+package tfdiff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAttributePathIgnored(t *testing.T) {
+	tests := []struct {
+		pattern string
+		level   string
+		element string
+		path    []string
+		want    bool
+	}{
+		{"resource.aws_instance.*.tags.Environment", "resource", "aws_instance.web", []string{"tags", "Environment"}, true},
+		{"resource.aws_instance.*.tags.Environment", "resource", "aws_instance.web", []string{"tags", "Name"}, false},
+		{"resource.aws_instance.*.tags.Environment", "resource", "aws_s3_bucket.web", []string{"tags", "Environment"}, false},
+		{"module.*.version", "module_call", "vpc", []string{"version"}, true},
+		{"module.*.version", "module_call", "vpc", []string{"source"}, false},
+		{"*.lifecycle.ignore_changes", "resource", "aws_instance.web", []string{"lifecycle", "ignore_changes"}, true},
+		{"*.lifecycle.ignore_changes", "data_source", "aws_ami.ubuntu", []string{"lifecycle", "ignore_changes"}, true},
+		{"*.lifecycle.ignore_changes", "resource", "aws_instance.web", []string{"lifecycle"}, false},
+		{"data.aws_ami.*.owners", "data_source", "aws_ami.ubuntu", []string{"owners"}, true},
+		{"resource.**.description", "resource", "aws_security_group.web", []string{"ingress", "description"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+strings.Join(tt.path, "."), func(t *testing.T) {
+			at := newAttributePath(ComparisonConfig{IgnoreAttributes: []string{tt.pattern}}, tt.level, tt.element)
+			for _, key := range tt.path {
+				at = at.child(key)
+			}
+			if got := at.ignored(); got != tt.want {
+				t.Errorf("expected ignored=%v for %s, got %v", tt.want, strings.Join(at.segments, "."), got)
+			}
+		})
+	}
+}
+
+func TestCompareModules_IgnoreAttributes(t *testing.T) {
+	staging := parseTestModule(t, map[string]string{
+		"main.tf": `
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.0.0"
+  name    = "main"
+}
+
+resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+  tags = {
+    Name        = "web"
+    Environment = "staging"
+  }
+
+  lifecycle {
+    ignore_changes = ["ami"]
+  }
+}
+`,
+	})
+	production := parseTestModule(t, map[string]string{
+		"main.tf": `
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.1.0"
+  name    = "main"
+}
+
+resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+  tags = {
+    Name        = "web"
+    Environment = "production"
+  }
+
+  lifecycle {
+    ignore_changes = ["ami", "tags"]
+  }
+}
+`,
+	})
+
+	levels := []ComparisonLevel{ComparisonLevelModuleCalls, ComparisonLevelResources}
+	tests := []struct {
+		name    string
+		ignores []string
+		want    []string
+	}{
+		{
+			name: "no ignores",
+			want: []string{"vpc", "aws_instance.web"},
+		},
+		{
+			name:    "module version",
+			ignores: []string{"module.*.version"},
+			want:    []string{"aws_instance.web"},
+		},
+		{
+			name:    "tag and lifecycle",
+			ignores: []string{"resource.aws_instance.*.tags.Environment", "*.lifecycle.ignore_changes"},
+			want:    []string{"vpc"},
+		},
+		{
+			name:    "tag only",
+			ignores: []string{"resource.aws_instance.*.tags.Environment"},
+			want:    []string{"vpc", "aws_instance.web"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := ComparisonConfig{Levels: levels, IgnoreAttributes: tt.ignores}
+			result := CompareModules(staging, production, config)
+
+			var elements []string
+			for _, diff := range result.Diffs {
+				elements = append(elements, diff.Element)
+			}
+			if strings.Join(elements, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected diffs %v, got %v", tt.want, elements)
+			}
+		})
+	}
+
+	// Ignored attributes are hidden from the attribute diff of a modified element
+	config := ComparisonConfig{Levels: levels, IgnoreAttributes: []string{"resource.aws_instance.*.tags.Environment"}}
+	output := FormatTextOutput(CompareModules(staging, production, config), config, true)
+	if strings.Contains(output, "Environment") {
+		t.Errorf("expected ignored tag to be hidden, got:\n%s", output)
+	}
+	if !strings.Contains(output, "ignore_changes") {
+		t.Errorf("expected lifecycle change to be shown, got:\n%s", output)
+	}
+}
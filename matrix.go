@@ -0,0 +1,140 @@
+package tfdiff
+
+import (
+	"sort"
+)
+
+// BuildDriftMatrix aligns the elements of several modules by the identity keys used by
+// CompareModules and collects the value of every attribute in every module.
+func BuildDriftMatrix(modules []*ModuleDefinition, config ComparisonConfig) *DriftMatrix {
+	matrix := &DriftMatrix{
+		Environments: make([]string, len(modules)),
+		Elements:     []MatrixElement{},
+	}
+	filtered := make([]*ModuleDefinition, len(modules))
+	for i, module := range modules {
+		matrix.Environments[i] = module.Path
+		filtered[i] = filterModule(module, config)
+	}
+	modules = filtered
+
+	for _, level := range expandComparisonLevels(config.Levels) {
+		levelConfig := config
+		levelConfig.Levels = []ComparisonLevel{level}
+
+		// Collect the elements of this level from every module
+		type elementKey struct{ level, element string }
+		var keys []elementKey
+		seen := make(map[elementKey]bool)
+		perModule := make([]map[elementKey]map[string]interface{}, len(modules))
+		for i, module := range modules {
+			perModule[i] = make(map[elementKey]map[string]interface{})
+			for _, element := range flattenModule(module, levelConfig) {
+				key := elementKey{element.Level, element.Element}
+				if !seen[key] {
+					seen[key] = true
+					keys = append(keys, key)
+				}
+				perModule[i][key] = element.Attributes
+			}
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].level != keys[j].level {
+				return keys[i].level < keys[j].level
+			}
+			return keys[i].element < keys[j].element
+		})
+
+		for _, key := range keys {
+			element := MatrixElement{
+				Level:   key.level,
+				Element: key.element,
+				Present: make([]bool, len(modules)),
+			}
+
+			paths := make(map[string]bool)
+			for i := range modules {
+				attrs, exists := perModule[i][key]
+				element.Present[i] = exists
+				for path := range attrs {
+					paths[path] = true
+				}
+			}
+
+			for _, path := range sortedKeys(paths) {
+				attribute := MatrixAttribute{
+					Path:   path,
+					Values: make([]interface{}, len(modules)),
+				}
+				for i := range modules {
+					if attrs, exists := perModule[i][key]; exists {
+						attribute.Values[i] = attrs[path]
+					}
+				}
+				attribute.Differs = !matrixValuesEqual(attribute.Values)
+				if attribute.Differs {
+					element.Differs = true
+				}
+				element.Attributes = append(element.Attributes, attribute)
+			}
+
+			for _, present := range element.Present {
+				if present != element.Present[0] {
+					element.Differs = true
+				}
+			}
+
+			matrix.Elements = append(matrix.Elements, element)
+		}
+	}
+
+	return matrix
+}
+
+// FilterDriftMatrix returns a copy of the matrix keeping only elements and attributes that differ
+func FilterDriftMatrix(matrix *DriftMatrix) *DriftMatrix {
+	filtered := &DriftMatrix{
+		Environments: matrix.Environments,
+		Elements:     []MatrixElement{},
+	}
+	for _, element := range matrix.Elements {
+		if !element.Differs {
+			continue
+		}
+		attributes := element.Attributes
+		element.Attributes = nil
+		for _, attribute := range attributes {
+			if attribute.Differs {
+				element.Attributes = append(element.Attributes, attribute)
+			}
+		}
+		filtered.Elements = append(filtered.Elements, element)
+	}
+	return filtered
+}
+
+// matrixValuesEqual reports whether all values of a matrix row are equal, treating absent values as distinct
+func matrixValuesEqual(values []interface{}) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, value := range values[1:] {
+		if (value == nil) != (values[0] == nil) {
+			return false
+		}
+		if value != nil && !valuesEqual(values[0], value) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedKeys returns the keys of a set in sorted order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
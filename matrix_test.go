@@ -0,0 +1,99 @@
+package tfdiff
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildDriftMatrix(t *testing.T) {
+	root := t.TempDir()
+	environments := map[string]string{
+		"dev": `
+resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+  monitoring    = false
+}
+`,
+		"stg": `
+resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+  monitoring    = false
+}
+`,
+		"prd": `
+resource "aws_instance" "web" {
+  instance_type = "t3.large"
+  monitoring    = false
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "prd-logs"
+}
+`,
+	}
+
+	var modules []*ModuleDefinition
+	for _, env := range []string{"dev", "stg", "prd"} {
+		dir := filepath.Join(root, env)
+		writeModuleFiles(t, dir, map[string]string{"main.tf": environments[env]})
+		module, err := ParseModuleHCL(dir)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", env, err)
+		}
+		modules = append(modules, module)
+	}
+
+	config := ComparisonConfig{Levels: []ComparisonLevel{ComparisonLevelResources}}
+	matrix := BuildDriftMatrix(modules, config)
+
+	if len(matrix.Environments) != 3 || matrix.Environments[2] != filepath.Join(root, "prd") {
+		t.Fatalf("unexpected environments: %v", matrix.Environments)
+	}
+	if len(matrix.Elements) != 2 {
+		t.Fatalf("expected 2 elements, got %d", len(matrix.Elements))
+	}
+
+	web := matrix.Elements[0]
+	if web.Level != "resource" || web.Element != "aws_instance.web" || !web.Differs {
+		t.Errorf("unexpected element: %+v", web)
+	}
+	expectedAttributes := map[string]bool{
+		"instance_type": true,
+		"monitoring":    false,
+	}
+	if len(web.Attributes) != len(expectedAttributes) {
+		t.Fatalf("expected %d attributes, got %+v", len(expectedAttributes), web.Attributes)
+	}
+	for _, attribute := range web.Attributes {
+		if attribute.Differs != expectedAttributes[attribute.Path] {
+			t.Errorf("attribute %s: expected differs=%v, got %v", attribute.Path, expectedAttributes[attribute.Path], attribute.Differs)
+		}
+	}
+
+	logs := matrix.Elements[1]
+	if logs.Element != "aws_s3_bucket.logs" || !logs.Differs {
+		t.Errorf("unexpected element: %+v", logs)
+	}
+	if logs.Present[0] || logs.Present[1] || !logs.Present[2] {
+		t.Errorf("expected bucket to be present only in prd, got %v", logs.Present)
+	}
+	if logs.Attributes[0].Values[0] != nil || logs.Attributes[0].Values[2] != "prd-logs" {
+		t.Errorf("unexpected bucket values: %v", logs.Attributes[0].Values)
+	}
+
+	output := FormatMatrixOutput(FilterDriftMatrix(matrix), true)
+	for _, want := range []string{
+		"~ resource aws_instance.web",
+		"~   instance_type",
+		"t3.large",
+		"(absent)",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "monitoring") {
+		t.Errorf("expected equal attributes to be filtered, got:\n%s", output)
+	}
+}
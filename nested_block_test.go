@@ -106,11 +106,11 @@ resource "aws_instance" "example" {
 	metadataBlock := metadataOptions[0]
 	
 	// Check that both http_endpoint and http_tokens are present
-	if metadataBlock["http_endpoint"] != "enabled" {
+	if metadataBlock["http_endpoint"] != StringValue("enabled") {
 		t.Errorf("Expected http_endpoint=enabled, got %v", metadataBlock["http_endpoint"])
 	}
 	
-	if metadataBlock["http_tokens"] != "required" {
+	if metadataBlock["http_tokens"] != StringValue("required") {
 		t.Errorf("Expected http_tokens=required, got %v", metadataBlock["http_tokens"])
 	}
 
@@ -1,6 +1,9 @@
 package tfdiff
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 )
@@ -16,6 +19,83 @@ func ParseModuleWithOptions(modulePath string, options ParseOptions) (*ModuleDef
 	return ParseModuleHCLWithOptions(modulePath, options)
 }
 
+// ValidateModuleSource validates a module source, which is a module directory, an archive
+// with an optional //SUBDIR suffix or a snapshot file. The contents of archives and
+// snapshots are validated when parsed.
+func ValidateModuleSource(source string) error {
+	file := source
+	if archivePath, _, ok := SplitArchiveSource(source); ok {
+		file = archivePath
+	} else if !isSnapshotPath(source) {
+		return ValidateModuleDirectory(source)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("not a file: %s", file)
+	}
+	return nil
+}
+
+// ParseModuleSource parses a module source, which is a module directory, an archive with an
+// optional //SUBDIR suffix or a snapshot file. Ignore options do not apply to snapshots.
+func ParseModuleSource(source string, options ParseOptions) (*ModuleDefinition, error) {
+	if archivePath, subdir, ok := SplitArchiveSource(source); ok {
+		return ParseModuleArchive(archivePath, subdir, options)
+	}
+	if isSnapshotPath(source) {
+		return ReadSnapshot(source)
+	}
+	return ParseModuleWithOptions(source, options)
+}
+
+// ParseModuleFS parses a Terraform module directory within a filesystem, such as an embedded
+// filesystem, an archive or an in-memory filesystem. dir is a slash-separated path within fsys
+// ("." for its root) and becomes the module path. Ignore patterns are matched against paths
+// within fsys, and Terragrunt includes are resolved within fsys.
+func ParseModuleFS(fsys fs.FS, dir string, options ParseOptions) (*ModuleDefinition, error) {
+	if err := checkFSDirectory(fsys, dir); err != nil {
+		return nil, err
+	}
+	return parseModuleFS(moduleFS{fsys: fsys, dir: dir, path: dir}, options)
+}
+
+// ValidateModuleFS validates that a directory exists within a filesystem and contains Terraform, Terraform Stacks or Terragrunt files
+func ValidateModuleFS(fsys fs.FS, dir string) error {
+	if err := checkFSDirectory(fsys, dir); err != nil {
+		return err
+	}
+	found, err := containsModuleFiles(moduleFS{fsys: fsys, dir: dir, path: dir})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fs.ErrNotExist
+	}
+	return nil
+}
+
+// checkFSDirectory checks that dir is a valid path of an existing directory within fsys
+func checkFSDirectory(fsys fs.FS, dir string) error {
+	if !fs.ValidPath(dir) {
+		return fmt.Errorf("invalid directory path: %s", dir)
+	}
+	info, err := fs.Stat(fsys, dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("directory does not exist: %s", dir)
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("not a directory: %s", dir)
+	}
+	return nil
+}
+
 // FindTerraformFiles finds all .tf files in the specified directory
 func FindTerraformFiles(path string) ([]string, error) {
 	files, err := filepath.Glob(filepath.Join(path, "*.tf"))
@@ -25,7 +105,7 @@ func FindTerraformFiles(path string) ([]string, error) {
 	return files, nil
 }
 
-// ValidateModuleDirectory validates that a directory exists and contains Terraform files
+// ValidateModuleDirectory validates that a directory exists and contains Terraform, Terraform Stacks or Terragrunt files
 func ValidateModuleDirectory(path string) error {
 	// Check if directory exists
 	info, err := os.Stat(path)
@@ -39,14 +119,40 @@ func ValidateModuleDirectory(path string) error {
 		return os.ErrNotExist
 	}
 
-	// Check if directory contains .tf files
-	files, err := FindTerraformFiles(path)
+	// Check if directory contains Terraform files
+	module, err := osModuleFS(path)
+	if err != nil {
+		return err
+	}
+	found, err := containsModuleFiles(module)
 	if err != nil {
 		return err
 	}
-	if len(files) == 0 {
+	if !found {
 		return os.ErrNotExist
 	}
 
 	return nil
 }
+
+// containsModuleFiles reports whether a directory contains .tf files, Terraform Stacks files or a terragrunt.hcl
+func containsModuleFiles(module moduleFS) (bool, error) {
+	files, err := module.findFiles("*.tf")
+	if err != nil {
+		return false, err
+	}
+	if len(files) > 0 {
+		return true, nil
+	}
+
+	// Stack and Terragrunt directories contain no .tf files
+	stackFiles, err := findStackFiles(module)
+	if err != nil {
+		return false, err
+	}
+	if len(stackFiles) > 0 {
+		return true, nil
+	}
+
+	return findTerragruntFile(module)
+}
@@ -0,0 +1,117 @@
+package tfdiff
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// moduleFS locates the files of a module directory inside a filesystem.
+// File names are slash-separated and relative to the module directory.
+type moduleFS struct {
+	fsys fs.FS
+	dir  string // slash-separated directory of the module within fsys
+	path string // module path used in the module definition, file names and ignore patterns
+}
+
+// osModuleFS returns the moduleFS of a directory on the local filesystem.
+// The filesystem is rooted at the volume root so that Terragrunt includes can reach parent directories.
+func osModuleFS(modulePath string) (moduleFS, error) {
+	abs, err := filepath.Abs(modulePath)
+	if err != nil {
+		return moduleFS{}, fmt.Errorf("failed to resolve %s: %w", modulePath, err)
+	}
+	root := filepath.VolumeName(abs) + string(filepath.Separator)
+	dir, err := filepath.Rel(root, abs)
+	if err != nil {
+		return moduleFS{}, fmt.Errorf("failed to resolve %s: %w", modulePath, err)
+	}
+	return moduleFS{
+		fsys: os.DirFS(root),
+		dir:  filepath.ToSlash(dir),
+		path: modulePath,
+	}, nil
+}
+
+// findFiles returns the sorted names of the files matching any of the glob patterns
+func (m moduleFS) findFiles(patterns ...string) ([]string, error) {
+	var names []string
+	for _, pattern := range patterns {
+		matches, err := fs.Glob(m.fsys, path.Join(m.dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			if info, err := fs.Stat(m.fsys, match); err != nil || info.IsDir() {
+				continue
+			}
+			names = append(names, m.name(match))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// name returns the module-relative name of a path within fsys
+func (m moduleFS) name(fsPath string) string {
+	if m.dir == "." {
+		return fsPath
+	}
+	rel, err := filepath.Rel(filepath.FromSlash("/"+m.dir), filepath.FromSlash("/"+fsPath))
+	if err != nil {
+		return fsPath
+	}
+	return filepath.ToSlash(rel)
+}
+
+// filename returns the display path of a module file
+func (m moduleFS) filename(name string) string {
+	return filepath.Join(m.path, filepath.FromSlash(name))
+}
+
+// readFile reads a module file
+func (m moduleFS) readFile(name string) ([]byte, error) {
+	content, err := fs.ReadFile(m.fsys, path.Join(m.dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", m.filename(name), err)
+	}
+	return content, nil
+}
+
+// filterIgnored removes module files whose display path matches the ignore patterns
+func (m moduleFS) filterIgnored(names []string, patterns []string) ([]string, error) {
+	if len(patterns) == 0 || len(names) == 0 {
+		return names, nil
+	}
+
+	byFilename := make(map[string]string)
+	filenames := make([]string, 0, len(names))
+	for _, name := range names {
+		filename := m.filename(name)
+		byFilename[filename] = name
+		filenames = append(filenames, filename)
+	}
+
+	filtered, err := filterIgnoredFiles(filenames, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, len(filtered))
+	for _, filename := range filtered {
+		result = append(result, byFilename[filename])
+	}
+	return result, nil
+}
+
+// filenames returns the display paths of module files
+func (m moduleFS) filenames(names []string) []string {
+	filenames := make([]string, 0, len(names))
+	for _, name := range names {
+		filenames = append(filenames, m.filename(name))
+	}
+	return filenames
+}
@@ -0,0 +1,99 @@
+package tfdiff
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseModuleFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"live/terragrunt.hcl": {Data: []byte(terragruntRootContent)},
+		"live/app/main.tf": {Data: []byte(`
+resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+}
+
+output "id" {
+  value = aws_instance.web.id
+}
+`)},
+		"live/app/generated.tf": {Data: []byte(`
+resource "aws_s3_bucket" "generated" {
+}
+`)},
+		"live/app/tests/main.tftest.hcl": {Data: []byte(`
+run "plan" {
+  command = plan
+}
+`)},
+		"live/app/terragrunt.hcl": {Data: []byte(`
+include "root" {
+  path = find_in_parent_folders()
+}
+`)},
+		"live/empty/README.md": {Data: []byte("no module here")},
+	}
+
+	if err := ValidateModuleFS(fsys, "live/app"); err != nil {
+		t.Fatalf("expected live/app to be a valid module: %v", err)
+	}
+	if err := ValidateModuleFS(fsys, "live/empty"); err == nil {
+		t.Errorf("expected live/empty to be rejected")
+	}
+	if _, err := ParseModuleFS(fsys, "live/missing", ParseOptions{}); err == nil {
+		t.Errorf("expected error for missing directory")
+	}
+	if _, err := ParseModuleFS(fsys, "/live/app", ParseOptions{}); err == nil {
+		t.Errorf("expected error for invalid directory path")
+	}
+
+	module, err := ParseModuleFS(fsys, "live/app", ParseOptions{IgnoreFiles: []string{"generated.tf"}})
+	if err != nil {
+		t.Fatalf("failed to parse module from fs: %v", err)
+	}
+
+	if module.Path != "live/app" {
+		t.Errorf("unexpected module path: %s", module.Path)
+	}
+	if len(module.Resources) != 1 || module.Resources[0].Name != "web" {
+		t.Errorf("expected only the web resource, got %+v", module.Resources)
+	}
+	if len(module.Outputs) != 1 {
+		t.Errorf("expected 1 output, got %d", len(module.Outputs))
+	}
+	if len(module.TestFiles) != 1 || module.TestFiles[0].Path != "tests/main.tftest.hcl" {
+		t.Errorf("unexpected test files: %+v", module.TestFiles)
+	}
+	if module.Terragrunt == nil || module.Terragrunt.RemoteState == nil || module.Terragrunt.Includes[0].Path != "../terragrunt.hcl" {
+		t.Errorf("expected terragrunt include to be resolved within the fs, got %+v", module.Terragrunt)
+	}
+
+	// The same files on disk parse to the same definitions
+	dir := t.TempDir()
+	files := make(map[string]string)
+	for name, file := range fsys {
+		files[name] = string(file.Data)
+	}
+	writeModuleFiles(t, dir, files)
+	osModule, err := ParseModuleWithOptions(dir+"/live/app", ParseOptions{IgnoreFiles: []string{"generated.tf"}})
+	if err != nil {
+		t.Fatalf("failed to parse module from disk: %v", err)
+	}
+	osModule.Path = module.Path
+	config := ComparisonConfig{Levels: []ComparisonLevel{ComparisonLevelAll}}
+	if result := CompareModules(osModule, module, config); len(result.Diffs) != 0 {
+		t.Errorf("expected no diffs between disk and fs, got %+v", result.Diffs)
+	}
+}
+
+func TestMemFS(t *testing.T) {
+	fsys := memFS{
+		"main.tf":                  []byte(`resource "a" "b" {}`),
+		"modules/vpc/main.tf":      []byte(`variable "cidr" {}`),
+		"modules/vpc/tests/a.hcl":  []byte(""),
+		"modules/vpc/variables.tf": []byte(""),
+	}
+	if err := fstest.TestFS(fsys, "main.tf", "modules/vpc/main.tf", "modules/vpc/tests/a.hcl", "modules/vpc/variables.tf"); err != nil {
+		t.Fatal(err)
+	}
+}
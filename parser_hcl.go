@@ -18,90 +18,121 @@ func ParseModuleHCL(modulePath string) (*ModuleDefinition, error) {
 }
 
 // ParseModuleHCLWithOptions parses a Terraform module directory using HCL parser directly and options.
+// It reads the local filesystem through the same parser as ParseModuleFS.
 func ParseModuleHCLWithOptions(modulePath string, options ParseOptions) (*ModuleDefinition, error) {
 	// Check if directory exists
 	if _, err := os.Stat(modulePath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("directory does not exist: %s", modulePath)
 	}
 
+	module, err := osModuleFS(modulePath)
+	if err != nil {
+		return nil, err
+	}
+	return parseModuleFS(module, options)
+}
+
+// parseModuleFS parses the Terraform, test, Stacks and Terragrunt files of a module directory
+func parseModuleFS(module moduleFS, options ParseOptions) (*ModuleDefinition, error) {
 	parser := hclparse.NewParser()
 
 	// Find all .tf files in the directory
-	files, err := filepath.Glob(filepath.Join(modulePath, "*.tf"))
+	files, err := module.findFiles("*.tf")
 	if err != nil {
 		return nil, fmt.Errorf("failed to find .tf files: %w", err)
 	}
 
 	def := &ModuleDefinition{
-		Path: modulePath,
+		Path: module.path,
 	}
 
 	patterns := loadIgnorePatterns(options.IgnoreFiles)
+	files, err = module.filterIgnored(files, patterns)
+	if err != nil {
+		return nil, err
+	}
 
-	if len(patterns) > 0 && len(files) > 0 {
-		cwd, err := os.Getwd()
+	// Parse each .tf file
+	for _, file := range files {
+		content, err := module.readFile(file)
 		if err != nil {
-			cwd = ""
+			return nil, err
 		}
-		cwdEval := ""
-		if cwd != "" {
-			if eval, err := filepath.EvalSymlinks(cwd); err == nil {
-				cwdEval = eval
-			}
+		if err := parseFile(parser, module.filename(file), content, def); err != nil {
+			return nil, fmt.Errorf("failed to parse file %s: %w", module.filename(file), err)
 		}
-		filtered := make([]string, 0, len(files))
-		for _, file := range files {
-			rel := file
-			if cwd != "" {
-				if relPath, err := filepath.Rel(cwd, file); err == nil {
-					rel = relPath
-				}
-			}
-			ignored, err := shouldIgnore(rel, filepath.Base(file), patterns)
-			if err != nil {
-				return nil, fmt.Errorf("invalid ignore pattern: %w", err)
-			}
-			if !ignored && cwdEval != "" {
-				fileEval := file
-				if eval, err := filepath.EvalSymlinks(file); err == nil {
-					fileEval = eval
-				}
-				if relAlt, err := filepath.Rel(cwdEval, fileEval); err == nil && relAlt != rel {
-					ignored, err = shouldIgnore(relAlt, filepath.Base(file), patterns)
-					if err != nil {
-						return nil, fmt.Errorf("invalid ignore pattern: %w", err)
-					}
-				}
-			}
-			if ignored {
-				continue
-			}
-			filtered = append(filtered, file)
+	}
+
+	// Parse Terraform test files (*.tftest.hcl)
+	if !options.SkipTests {
+		if err := parseTestFiles(parser, module, def, patterns); err != nil {
+			return nil, err
 		}
-		files = filtered
 	}
 
-	// If no .tf files found, return empty module definition
-	if len(files) == 0 {
-		return def, nil
+	// Parse Terraform Stacks files (*.tfstack.hcl, *.tfdeploy.hcl)
+	if err := parseStackFiles(parser, module, def, patterns); err != nil {
+		return nil, err
 	}
 
-	// Parse each .tf file
-	for _, file := range files {
-		if err := parseFile(parser, file, def); err != nil {
-			return nil, fmt.Errorf("failed to parse file %s: %w", file, err)
-		}
+	// Parse Terragrunt configuration (terragrunt.hcl)
+	if err := parseTerragruntFile(parser, module, def, patterns); err != nil {
+		return nil, err
 	}
 
 	return def, nil
 }
 
-func parseFile(parser *hclparse.Parser, filename string, def *ModuleDefinition) error {
-	content, err := os.ReadFile(filename)
+// filterIgnoredFiles removes files matching the ignore patterns.
+// Patterns are matched against paths relative to the current working directory.
+func filterIgnoredFiles(files []string, patterns []string) ([]string, error) {
+	if len(patterns) == 0 || len(files) == 0 {
+		return files, nil
+	}
+
+	cwd, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("failed to read file %s: %w", filename, err)
+		cwd = ""
 	}
+	cwdEval := ""
+	if cwd != "" {
+		if eval, err := filepath.EvalSymlinks(cwd); err == nil {
+			cwdEval = eval
+		}
+	}
+	filtered := make([]string, 0, len(files))
+	for _, file := range files {
+		rel := file
+		if cwd != "" {
+			if relPath, err := filepath.Rel(cwd, file); err == nil {
+				rel = relPath
+			}
+		}
+		ignored, err := shouldIgnore(rel, filepath.Base(file), patterns)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern: %w", err)
+		}
+		if !ignored && cwdEval != "" {
+			fileEval := file
+			if eval, err := filepath.EvalSymlinks(file); err == nil {
+				fileEval = eval
+			}
+			if relAlt, err := filepath.Rel(cwdEval, fileEval); err == nil && relAlt != rel {
+				ignored, err = shouldIgnore(relAlt, filepath.Base(file), patterns)
+				if err != nil {
+					return nil, fmt.Errorf("invalid ignore pattern: %w", err)
+				}
+			}
+		}
+		if ignored {
+			continue
+		}
+		filtered = append(filtered, file)
+	}
+	return filtered, nil
+}
 
+func parseFile(parser *hclparse.Parser, filename string, content []byte, def *ModuleDefinition) error {
 	file, diags := parser.ParseHCL(content, filename)
 	if diags.HasErrors() {
 		return fmt.Errorf("failed to parse HCL file %s: %s", filename, diags.Error())
@@ -116,15 +147,15 @@ func parseFile(parser *hclparse.Parser, filename string, def *ModuleDefinition)
 	for _, block := range body.Blocks {
 		switch block.Type {
 		case "module":
-			if err := parseModuleBlock(block, def, filename); err != nil {
+			if err := parseModuleBlock(block, def, filename, content); err != nil {
 				return fmt.Errorf("failed to parse module block: %w", err)
 			}
 		case "resource":
-			if err := parseResourceBlock(block, def, filename); err != nil {
+			if err := parseResourceBlock(block, def, filename, content); err != nil {
 				return fmt.Errorf("failed to parse resource block: %w", err)
 			}
 		case "data":
-			if err := parseDataBlock(block, def, filename); err != nil {
+			if err := parseDataBlock(block, def, filename, content); err != nil {
 				return fmt.Errorf("failed to parse data block: %w", err)
 			}
 		case "output":
@@ -132,41 +163,37 @@ func parseFile(parser *hclparse.Parser, filename string, def *ModuleDefinition)
 				return fmt.Errorf("failed to parse output block: %w", err)
 			}
 		case "variable":
-			if err := parseVariableBlock(block, def, filename); err != nil {
+			if err := parseVariableBlock(block, def, filename, content); err != nil {
 				return fmt.Errorf("failed to parse variable block: %w", err)
 			}
+		case "terraform":
+			parseTerraformBlock(block, def, filename, content)
 		}
 	}
 
 	return nil
 }
 
-func parseModuleBlock(block *hclsyntax.Block, def *ModuleDefinition, filename string) error {
+func parseModuleBlock(block *hclsyntax.Block, def *ModuleDefinition, filename string, content []byte) error {
 	if len(block.Labels) != 1 {
 		return fmt.Errorf("module block must have exactly one label")
 	}
 
 	moduleCall := ModuleCall{
 		Name:     block.Labels[0],
-		Args:     make(map[string]string),
+		Args:     make(map[string]Value),
 		Position: fmt.Sprintf("%s:%d", filepath.Base(filename), block.DefRange().Start.Line),
 	}
 
 	// Parse attributes
 	for name, attr := range block.Body.Attributes {
-		value, err := evaluateExpression(attr.Expr)
-		if err != nil {
-			// If we can't evaluate, store the raw expression
-			value = string(attr.Expr.Range().SliceBytes(block.Body.SrcRange.SliceBytes([]byte{})))
-		}
-
 		switch name {
 		case "source":
-			moduleCall.Source = value
+			moduleCall.Source = expressionString(attr.Expr, content)
 		case "version":
-			moduleCall.Version = value
+			moduleCall.Version = expressionString(attr.Expr, content)
 		default:
-			moduleCall.Args[name] = value
+			moduleCall.Args[name] = expressionValue(attr.Expr, content)
 		}
 	}
 
@@ -174,35 +201,20 @@ func parseModuleBlock(block *hclsyntax.Block, def *ModuleDefinition, filename st
 	return nil
 }
 
-// evaluateAttributeValue evaluates an HCL attribute expression and returns its value
-// It handles complex expressions, including JSON conversion when needed
-func evaluateAttributeValue(attr *hclsyntax.Attribute) interface{} {
-	value, err := evaluateExpression(attr.Expr)
-	if err != nil {
-		if val, diags := attr.Expr.Value(nil); !diags.HasErrors() {
-			if jsonStr, err := convertCtyToJSON(val); err == nil {
-				return jsonStr
-			}
-		}
-		return "<complex_expression>"
-	}
-	return value
-}
-
 // parseBlockAttributes parses attributes from an HCL block body
-func parseBlockAttributes(body *hclsyntax.Body) map[string]interface{} {
+func parseBlockAttributes(body *hclsyntax.Body, content []byte) map[string]interface{} {
 	config := make(map[string]interface{})
 
 	// Parse attributes
 	for name, attr := range body.Attributes {
-		config[name] = evaluateAttributeValue(attr)
+		config[name] = expressionValue(attr.Expr, content)
 	}
 
 	return config
 }
 
 // parseNestedBlocks recursively parses nested blocks from an HCL block body
-func parseNestedBlocks(body *hclsyntax.Body, maxDepth int) map[string][]map[string]interface{} {
+func parseNestedBlocks(body *hclsyntax.Body, content []byte, maxDepth int) map[string][]map[string]interface{} {
 	if maxDepth <= 0 || len(body.Blocks) == 0 {
 		return nil
 	}
@@ -210,7 +222,7 @@ func parseNestedBlocks(body *hclsyntax.Body, maxDepth int) map[string][]map[stri
 	nestedBlocks := make(map[string][]map[string]interface{})
 
 	for _, nestedBlock := range body.Blocks {
-		blockContent := parseBlockAttributes(nestedBlock.Body)
+		blockContent := parseBlockAttributes(nestedBlock.Body, content)
 
 		// Handle labels as identifiers for the block
 		if len(nestedBlock.Labels) > 0 {
@@ -218,7 +230,7 @@ func parseNestedBlocks(body *hclsyntax.Body, maxDepth int) map[string][]map[stri
 		}
 
 		// Handle nested blocks within nested blocks (recursively)
-		if innerBlocks := parseNestedBlocks(nestedBlock.Body, maxDepth-1); innerBlocks != nil {
+		if innerBlocks := parseNestedBlocks(nestedBlock.Body, content, maxDepth-1); innerBlocks != nil {
 			blockContent["_blocks"] = innerBlocks
 		}
 
@@ -228,7 +240,7 @@ func parseNestedBlocks(body *hclsyntax.Body, maxDepth int) map[string][]map[stri
 	return nestedBlocks
 }
 
-func parseResourceBlock(block *hclsyntax.Block, def *ModuleDefinition, filename string) error {
+func parseResourceBlock(block *hclsyntax.Block, def *ModuleDefinition, filename string, content []byte) error {
 	if len(block.Labels) != 2 {
 		return fmt.Errorf("resource block must have exactly two labels")
 	}
@@ -241,12 +253,12 @@ func parseResourceBlock(block *hclsyntax.Block, def *ModuleDefinition, filename
 	}
 
 	// Parse attributes using common function
-	for name, value := range parseBlockAttributes(block.Body) {
+	for name, value := range parseBlockAttributes(block.Body, content) {
 		resource.Config[name] = value
 	}
 
 	// Parse nested blocks using common function (maxDepth=2 for typical Terraform configs)
-	if nestedBlocks := parseNestedBlocks(block.Body, 2); nestedBlocks != nil {
+	if nestedBlocks := parseNestedBlocks(block.Body, content, 2); nestedBlocks != nil {
 		resource.Config["_blocks"] = nestedBlocks
 	}
 
@@ -254,7 +266,7 @@ func parseResourceBlock(block *hclsyntax.Block, def *ModuleDefinition, filename
 	return nil
 }
 
-func parseDataBlock(block *hclsyntax.Block, def *ModuleDefinition, filename string) error {
+func parseDataBlock(block *hclsyntax.Block, def *ModuleDefinition, filename string, content []byte) error {
 	if len(block.Labels) != 2 {
 		return fmt.Errorf("data block must have exactly two labels")
 	}
@@ -267,12 +279,12 @@ func parseDataBlock(block *hclsyntax.Block, def *ModuleDefinition, filename stri
 	}
 
 	// Parse attributes using common function
-	for name, value := range parseBlockAttributes(block.Body) {
+	for name, value := range parseBlockAttributes(block.Body, content) {
 		dataSource.Config[name] = value
 	}
 
 	// Parse nested blocks using common function (maxDepth=2 for typical Terraform configs)
-	if nestedBlocks := parseNestedBlocks(block.Body, 2); nestedBlocks != nil {
+	if nestedBlocks := parseNestedBlocks(block.Body, content, 2); nestedBlocks != nil {
 		dataSource.Config["_blocks"] = nestedBlocks
 	}
 
@@ -311,7 +323,7 @@ func parseOutputBlock(block *hclsyntax.Block, def *ModuleDefinition, filename st
 	return nil
 }
 
-func parseVariableBlock(block *hclsyntax.Block, def *ModuleDefinition, filename string) error {
+func parseVariableBlock(block *hclsyntax.Block, def *ModuleDefinition, filename string, content []byte) error {
 	if len(block.Labels) != 1 {
 		return fmt.Errorf("variable block must have exactly one label")
 	}
@@ -325,11 +337,8 @@ func parseVariableBlock(block *hclsyntax.Block, def *ModuleDefinition, filename
 	for name, attr := range block.Body.Attributes {
 		switch name {
 		case "type":
-			value, err := evaluateExpression(attr.Expr)
-			if err != nil {
-				value = "<complex_expression>"
-			}
-			variable.Type = value
+			// Type constraints are kept as written, such as list(string)
+			variable.Type = normalizeSource(expressionSource(attr.Expr, content))
 		case "description":
 			value, err := evaluateExpression(attr.Expr)
 			if err != nil {
@@ -337,25 +346,79 @@ func parseVariableBlock(block *hclsyntax.Block, def *ModuleDefinition, filename
 			}
 			variable.Description = value
 		case "default":
-			// Use evaluateAttributeValue for complex values like arrays
-			value := evaluateAttributeValue(attr)
-			if str, ok := value.(string); ok {
-				variable.DefaultValue = str
-			} else {
-				// Convert complex values to JSON string for storage
-				if jsonBytes, err := json.Marshal(value); err == nil {
-					variable.DefaultValue = string(jsonBytes)
-				} else {
-					variable.DefaultValue = "<complex_expression>"
-				}
+			value := expressionValue(attr.Expr, content)
+			variable.DefaultValue = &value
+		case "nullable":
+			if value := expressionValue(attr.Expr, content); !value.IsExpression() && value.Val.Type() == cty.Bool && !value.IsNull() {
+				nullable := value.Val.True()
+				variable.Nullable = &nullable
+			}
+		case "sensitive":
+			if value := expressionValue(attr.Expr, content); !value.IsExpression() && value.Val.Type() == cty.Bool && !value.IsNull() {
+				variable.Sensitive = value.Val.True()
 			}
 		}
 	}
 
+	// Parse validation blocks, with conditions kept as written
+	for _, nested := range block.Body.Blocks {
+		if nested.Type != "validation" {
+			continue
+		}
+		var validation VariableValidation
+		if attr, ok := nested.Body.Attributes["condition"]; ok {
+			validation.Condition = normalizeSource(expressionSource(attr.Expr, content))
+		}
+		if attr, ok := nested.Body.Attributes["error_message"]; ok {
+			message, err := evaluateExpression(attr.Expr)
+			if err != nil {
+				message = normalizeSource(expressionSource(attr.Expr, content))
+			}
+			validation.ErrorMessage = message
+		}
+		variable.Validations = append(variable.Validations, validation)
+	}
+
 	def.Variables = append(def.Variables, variable)
 	return nil
 }
 
+// parseTerraformBlock parses the provider requirements of a terraform block. A requirement is
+// an object of source, version and configuration_aliases, or a version constraint string in
+// the legacy syntax.
+func parseTerraformBlock(block *hclsyntax.Block, def *ModuleDefinition, filename string, content []byte) {
+	for _, nested := range block.Body.Blocks {
+		if nested.Type != "required_providers" {
+			continue
+		}
+		names := make(map[string]bool)
+		for name := range nested.Body.Attributes {
+			names[name] = true
+		}
+		for _, name := range sortedKeys(names) {
+			attr := nested.Body.Attributes[name]
+			provider := RequiredProvider{
+				Name:     name,
+				Position: fmt.Sprintf("%s:%d", filepath.Base(filename), attr.SrcRange.Start.Line),
+			}
+			switch requirement := expressionValue(attr.Expr, content).Interface().(type) {
+			case string:
+				provider.Version = requirement
+			case map[string]interface{}:
+				provider.Source, _ = requirement["source"].(string)
+				provider.Version, _ = requirement["version"].(string)
+				aliases, _ := requirement["configuration_aliases"].([]interface{})
+				for _, alias := range aliases {
+					if ref, ok := alias.(Value); ok {
+						provider.ConfigurationAliases = append(provider.ConfigurationAliases, normalizeSource(ref.Source))
+					}
+				}
+			}
+			def.RequiredProviders = append(def.RequiredProviders, provider)
+		}
+	}
+}
+
 // evaluateExpression tries to evaluate a simple HCL expression to a string
 func evaluateExpression(expr hcl.Expression) (string, error) {
 	// Handle simple literal values
@@ -366,8 +429,7 @@ func evaluateExpression(expr hcl.Expression) (string, error) {
 		case cty.String:
 			return val.AsString(), nil
 		case cty.Number:
-			f, _ := val.AsBigFloat().Float64()
-			return fmt.Sprintf("%g", f), nil
+			return numberString(val), nil
 		case cty.Bool:
 			if val.True() {
 				return "true", nil
@@ -389,6 +451,48 @@ func evaluateExpression(expr hcl.Expression) (string, error) {
 	return "", fmt.Errorf("complex expression cannot be evaluated")
 }
 
+// expressionSource returns the source text of an expression
+func expressionSource(expr hcl.Expression, content []byte) string {
+	return string(expr.Range().SliceBytes(content))
+}
+
+// expressionString returns the value of a literal expression, the JSON encoding of a
+// constant collection, or the source text of any other expression
+func expressionString(expr hcl.Expression, content []byte) string {
+	if value, err := evaluateExpression(expr); err == nil {
+		return value
+	}
+	if val, diags := expr.Value(nil); !diags.HasErrors() {
+		if jsonStr, err := convertCtyToJSON(val); err == nil {
+			return jsonStr
+		}
+	}
+	return expressionSource(expr, content)
+}
+
+// objectExpressionValues returns the items of an object expression as values keyed by
+// attribute name, so that objects containing references can still be compared key by key.
+// Any other expression is stored as a whole under the "*" key.
+func objectExpressionValues(expr hclsyntax.Expression, content []byte) map[string]Value {
+	result := make(map[string]Value)
+
+	obj, ok := expr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		result["*"] = expressionValue(expr, content)
+		return result
+	}
+
+	for _, item := range obj.Items {
+		key := expressionSource(item.KeyExpr, content)
+		if val, diags := item.KeyExpr.Value(nil); !diags.HasErrors() && val.Type() == cty.String {
+			key = val.AsString()
+		}
+		result[key] = expressionValue(item.ValueExpr, content)
+	}
+
+	return result
+}
+
 // convertCtyToJSON converts a cty.Value to JSON string
 func convertCtyToJSON(val cty.Value) (string, error) {
 	if val.IsNull() {
@@ -399,8 +503,7 @@ func convertCtyToJSON(val cty.Value) (string, error) {
 	case val.Type() == cty.String:
 		return val.AsString(), nil
 	case val.Type() == cty.Number:
-		f, _ := val.AsBigFloat().Float64()
-		return fmt.Sprintf("%g", f), nil
+		return numberString(val), nil
 	case val.Type() == cty.Bool:
 		if val.True() {
 			return "true", nil
@@ -445,8 +548,7 @@ func convertCtyValue(val cty.Value) (interface{}, error) {
 	case val.Type() == cty.String:
 		return val.AsString(), nil
 	case val.Type() == cty.Number:
-		f, _ := val.AsBigFloat().Float64()
-		return f, nil
+		return json.Number(numberString(val)), nil
 	case val.Type() == cty.Bool:
 		return val.True(), nil
 	case val.Type().IsObjectType() || val.Type().IsMapType():
@@ -0,0 +1,192 @@
+package tfdiff
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// FindStackFiles finds all Terraform Stacks configuration files (.tfstack.hcl and .tfdeploy.hcl)
+func FindStackFiles(path string) ([]string, error) {
+	module, err := osModuleFS(path)
+	if err != nil {
+		return nil, err
+	}
+	files, err := findStackFiles(module)
+	if err != nil {
+		return nil, err
+	}
+	return module.filenames(files), nil
+}
+
+// findStackFiles returns the names of the stack and deployment files of a directory
+func findStackFiles(module moduleFS) ([]string, error) {
+	return module.findFiles("*.tfstack.hcl", "*.tfdeploy.hcl")
+}
+
+// parseStackFiles discovers and parses the stack and deployment files of a directory
+func parseStackFiles(parser *hclparse.Parser, module moduleFS, def *ModuleDefinition, patterns []string) error {
+	files, err := findStackFiles(module)
+	if err != nil {
+		return fmt.Errorf("failed to find stack files: %w", err)
+	}
+
+	files, err = module.filterIgnored(files, patterns)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		content, err := module.readFile(file)
+		if err != nil {
+			return err
+		}
+		filename := module.filename(file)
+		if err := parseStackFile(parser, filename, content, def); err != nil {
+			return fmt.Errorf("failed to parse stack file %s: %w", filename, err)
+		}
+	}
+
+	return nil
+}
+
+// parseStackFile parses a single .tfstack.hcl or .tfdeploy.hcl file.
+// Stack variables and outputs are added to the module definition like their module counterparts.
+func parseStackFile(parser *hclparse.Parser, filename string, content []byte, def *ModuleDefinition) error {
+	file, diags := parser.ParseHCL(content, filename)
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to parse HCL file %s: %s", filename, diags.Error())
+	}
+
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return fmt.Errorf("expected HCL syntax body in file %s", filename)
+	}
+
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "component":
+			if err := parseComponentBlock(block, def, filename, content); err != nil {
+				return fmt.Errorf("failed to parse component block: %w", err)
+			}
+		case "deployment":
+			if err := parseDeploymentBlock(block, def, filename, content); err != nil {
+				return fmt.Errorf("failed to parse deployment block: %w", err)
+			}
+		case "orchestrate":
+			if err := parseOrchestrateBlock(block, def, filename, content); err != nil {
+				return fmt.Errorf("failed to parse orchestrate block: %w", err)
+			}
+		case "identity_token":
+			if err := parseIdentityTokenBlock(block, def, filename, content); err != nil {
+				return fmt.Errorf("failed to parse identity_token block: %w", err)
+			}
+		case "output":
+			if err := parseOutputBlock(block, def, filename); err != nil {
+				return fmt.Errorf("failed to parse output block: %w", err)
+			}
+		case "variable":
+			if err := parseVariableBlock(block, def, filename, content); err != nil {
+				return fmt.Errorf("failed to parse variable block: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseComponentBlock(block *hclsyntax.Block, def *ModuleDefinition, filename string, content []byte) error {
+	if len(block.Labels) != 1 {
+		return fmt.Errorf("component block must have exactly one label")
+	}
+
+	component := Component{
+		Name:     block.Labels[0],
+		Position: fmt.Sprintf("%s:%d", filepath.Base(filename), block.DefRange().Start.Line),
+	}
+
+	for name, attr := range block.Body.Attributes {
+		switch name {
+		case "source":
+			component.Source = expressionString(attr.Expr, content)
+		case "version":
+			component.Version = expressionString(attr.Expr, content)
+		case "for_each":
+			component.ForEach = expressionSource(attr.Expr, content)
+		case "inputs":
+			component.Inputs = objectExpressionValues(attr.Expr, content)
+		case "providers":
+			component.Providers = objectExpressionValues(attr.Expr, content)
+		}
+	}
+
+	def.Components = append(def.Components, component)
+	return nil
+}
+
+func parseDeploymentBlock(block *hclsyntax.Block, def *ModuleDefinition, filename string, content []byte) error {
+	if len(block.Labels) != 1 {
+		return fmt.Errorf("deployment block must have exactly one label")
+	}
+
+	deployment := Deployment{
+		Name:     block.Labels[0],
+		Position: fmt.Sprintf("%s:%d", filepath.Base(filename), block.DefRange().Start.Line),
+	}
+
+	if attr, ok := block.Body.Attributes["inputs"]; ok {
+		deployment.Inputs = objectExpressionValues(attr.Expr, content)
+	}
+
+	def.Deployments = append(def.Deployments, deployment)
+	return nil
+}
+
+func parseOrchestrateBlock(block *hclsyntax.Block, def *ModuleDefinition, filename string, content []byte) error {
+	if len(block.Labels) != 2 {
+		return fmt.Errorf("orchestrate block must have exactly two labels")
+	}
+
+	rule := OrchestrateRule{
+		Type:     block.Labels[0],
+		Name:     block.Labels[1],
+		Position: fmt.Sprintf("%s:%d", filepath.Base(filename), block.DefRange().Start.Line),
+	}
+
+	for _, nested := range block.Body.Blocks {
+		if nested.Type != "check" {
+			continue
+		}
+		check := OrchestrateCheck{}
+		if attr, ok := nested.Body.Attributes["condition"]; ok {
+			check.Condition = expressionSource(attr.Expr, content)
+		}
+		if attr, ok := nested.Body.Attributes["reason"]; ok {
+			check.Reason = expressionString(attr.Expr, content)
+		}
+		rule.Checks = append(rule.Checks, check)
+	}
+
+	def.OrchestrateRules = append(def.OrchestrateRules, rule)
+	return nil
+}
+
+func parseIdentityTokenBlock(block *hclsyntax.Block, def *ModuleDefinition, filename string, content []byte) error {
+	if len(block.Labels) != 1 {
+		return fmt.Errorf("identity_token block must have exactly one label")
+	}
+
+	token := IdentityToken{
+		Name:     block.Labels[0],
+		Position: fmt.Sprintf("%s:%d", filepath.Base(filename), block.DefRange().Start.Line),
+	}
+
+	if attr, ok := block.Body.Attributes["audience"]; ok {
+		token.Audience = expressionString(attr.Expr, content)
+	}
+
+	def.IdentityTokens = append(def.IdentityTokens, token)
+	return nil
+}
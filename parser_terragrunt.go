@@ -0,0 +1,238 @@
+package tfdiff
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// TerragruntConfigFile is the name of the Terragrunt configuration file
+const TerragruntConfigFile = "terragrunt.hcl"
+
+// FindTerragruntFile returns the path of the terragrunt.hcl file in the directory, or an empty string
+func FindTerragruntFile(path string) (string, error) {
+	module, err := osModuleFS(path)
+	if err != nil {
+		return "", err
+	}
+	found, err := findTerragruntFile(module)
+	if err != nil || !found {
+		return "", err
+	}
+	return module.filename(TerragruntConfigFile), nil
+}
+
+// findTerragruntFile reports whether the module directory contains a terragrunt.hcl file
+func findTerragruntFile(module moduleFS) (bool, error) {
+	info, err := fs.Stat(module.fsys, path.Join(module.dir, TerragruntConfigFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !info.IsDir(), nil
+}
+
+// parseTerragruntFile parses the terragrunt.hcl file of a directory, merging local includes
+func parseTerragruntFile(parser *hclparse.Parser, module moduleFS, def *ModuleDefinition, patterns []string) error {
+	found, err := findTerragruntFile(module)
+	if err != nil {
+		return fmt.Errorf("failed to find %s: %w", TerragruntConfigFile, err)
+	}
+	if !found {
+		return nil
+	}
+
+	files, err := module.filterIgnored([]string{TerragruntConfigFile}, patterns)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	filename := module.filename(TerragruntConfigFile)
+	config, err := parseTerragruntConfig(parser, module.fsys, path.Join(module.dir, TerragruntConfigFile), filename)
+	if err != nil {
+		return fmt.Errorf("failed to parse terragrunt file %s: %w", filename, err)
+	}
+
+	// Merge included configurations; the including configuration takes precedence
+	for i, include := range config.Includes {
+		if !include.Resolved {
+			continue
+		}
+		// Show include paths relative to the module for stable comparison across directories
+		rel := module.name(include.Path)
+		includeFilename := module.filename(rel)
+		parent, err := parseTerragruntConfig(parser, module.fsys, include.Path, includeFilename)
+		if err != nil {
+			return fmt.Errorf("failed to parse included terragrunt file %s: %w", includeFilename, err)
+		}
+		config.mergeInclude(parent)
+		config.Includes[i].Path = rel
+	}
+
+	def.Terragrunt = config
+	return nil
+}
+
+// parseTerragruntConfig parses a single Terragrunt configuration file without resolving includes.
+// fsPath locates the file within fsys; filename is used in positions and messages.
+func parseTerragruntConfig(parser *hclparse.Parser, fsys fs.FS, fsPath, filename string) (*TerragruntConfig, error) {
+	content, err := fs.ReadFile(fsys, fsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", filename, err)
+	}
+
+	file, diags := parser.ParseHCL(content, filename)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse HCL file %s: %s", filename, diags.Error())
+	}
+
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, fmt.Errorf("expected HCL syntax body in file %s", filename)
+	}
+
+	config := &TerragruntConfig{}
+
+	if attr, ok := body.Attributes["inputs"]; ok {
+		config.Inputs = objectExpressionValues(attr.Expr, content)
+	}
+
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "terraform":
+			if attr, ok := block.Body.Attributes["source"]; ok {
+				config.Source = expressionString(attr.Expr, content)
+			}
+		case "include":
+			include := TerragruntInclude{}
+			if len(block.Labels) > 0 {
+				include.Name = block.Labels[0]
+			}
+			if attr, ok := block.Body.Attributes["path"]; ok {
+				include.Path, include.Resolved = resolveTerragruntIncludePath(attr.Expr, fsys, path.Dir(fsPath), content)
+			}
+			config.Includes = append(config.Includes, include)
+		case "dependency":
+			if len(block.Labels) != 1 {
+				return nil, fmt.Errorf("dependency block must have exactly one label")
+			}
+			dependency := TerragruntDependency{
+				Name:     block.Labels[0],
+				Position: fmt.Sprintf("%s:%d", filepath.Base(filename), block.DefRange().Start.Line),
+			}
+			if attr, ok := block.Body.Attributes["config_path"]; ok {
+				dependency.ConfigPath = expressionString(attr.Expr, content)
+			}
+			config.Dependencies = append(config.Dependencies, dependency)
+		case "remote_state":
+			remoteState := &TerragruntRemoteState{}
+			if attr, ok := block.Body.Attributes["backend"]; ok {
+				remoteState.Backend = expressionString(attr.Expr, content)
+			}
+			if attr, ok := block.Body.Attributes["config"]; ok {
+				remoteState.Config = objectExpressionValues(attr.Expr, content)
+			}
+			config.RemoteState = remoteState
+		}
+	}
+
+	return config, nil
+}
+
+// resolveTerragruntIncludePath resolves the path of an include block within the filesystem.
+// Literal paths and find_in_parent_folders() are supported; other expressions are returned
+// as source text and reported as unresolved.
+func resolveTerragruntIncludePath(expr hclsyntax.Expression, fsys fs.FS, dir string, content []byte) (string, bool) {
+	if call, ok := expr.(*hclsyntax.FunctionCallExpr); ok && call.Name == "find_in_parent_folders" {
+		name := TerragruntConfigFile
+		if len(call.Args) > 0 {
+			val, diags := call.Args[0].Value(nil)
+			if diags.HasErrors() || val.Type() != cty.String {
+				return expressionSource(expr, content), false
+			}
+			name = val.AsString()
+		}
+		if found := findInParentFolders(fsys, dir, name); found != "" {
+			return found, true
+		}
+		return expressionSource(expr, content), false
+	}
+
+	literal, err := evaluateExpression(expr)
+	if err != nil {
+		return expressionSource(expr, content), false
+	}
+	includePath := filepath.ToSlash(literal)
+	if filepath.IsAbs(literal) {
+		includePath = strings.TrimPrefix(includePath, "/")
+	} else {
+		includePath = path.Join(dir, includePath)
+	}
+	if !fs.ValidPath(includePath) {
+		return literal, false
+	}
+	if _, err := fs.Stat(fsys, includePath); err != nil {
+		return literal, false
+	}
+	return includePath, true
+}
+
+// findInParentFolders searches the parent directories of dir for a file with the given name
+func findInParentFolders(fsys fs.FS, dir, name string) string {
+	current := dir
+	for current != "." {
+		current = path.Dir(current)
+		candidate := path.Join(current, name)
+		if info, err := fs.Stat(fsys, candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// mergeInclude merges an included configuration into the including configuration.
+// Values defined in the including configuration take precedence (shallow merge).
+func (c *TerragruntConfig) mergeInclude(parent *TerragruntConfig) {
+	if c.Source == "" {
+		c.Source = parent.Source
+	}
+	if c.RemoteState == nil {
+		c.RemoteState = parent.RemoteState
+	}
+
+	if len(parent.Inputs) > 0 {
+		merged := make(map[string]Value)
+		for key, value := range parent.Inputs {
+			merged[key] = value
+		}
+		for key, value := range c.Inputs {
+			merged[key] = value
+		}
+		c.Inputs = merged
+	}
+
+	for _, dependency := range parent.Dependencies {
+		found := false
+		for _, existing := range c.Dependencies {
+			if existing.Name == dependency.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.Dependencies = append(c.Dependencies, dependency)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package tfdiff
 
 import (
-	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
@@ -297,7 +296,7 @@ resource "aws_instance" "test" {
 
 	resource := module.Resources[0]
 
-	// Verify tags are stored as JSON
+	// Verify tags are stored as an object value
 	tagsValue, exists := resource.Config["tags"]
 	if !exists {
 		t.Fatal("tags not found in resource config")
@@ -305,31 +304,22 @@ resource "aws_instance" "test" {
 
 	t.Logf("Tags value: %s", tagsValue)
 
-	// Verify it can be parsed as JSON
-	var tags map[string]interface{}
-	tagsStr, ok := tagsValue.(string)
+	value, ok := tagsValue.(Value)
 	if !ok {
-		t.Errorf("Expected tags value to be string, got %T", tagsValue)
-		return
+		t.Fatalf("Expected tags value to be a Value, got %T", tagsValue)
 	}
-	if err := json.Unmarshal([]byte(tagsStr), &tags); err != nil {
-		// Case when it's <complex_expression>
-		if tagsStr == "<complex_expression>" {
-			t.Errorf("Tags were not parsed as JSON, got: %s", tagsStr)
-		} else {
-			t.Errorf("Failed to parse tags as JSON: %v, value: %s", err, tagsStr)
-		}
-	} else {
-		// Verification when JSON is correctly parsed
-		if tags["Name"] != "TestServer" {
-			t.Errorf("Expected Name=TestServer, got %v", tags["Name"])
-		}
-		if tags["Environment"] != "production" {
-			t.Errorf("Expected Environment=production, got %v", tags["Environment"])
-		}
-		if tags["Team"] != "backend" {
-			t.Errorf("Expected Team=backend, got %v", tags["Team"])
-		}
+	tags, ok := value.Interface().(map[string]interface{})
+	if !ok {
+		t.Fatalf("Tags were not parsed as an object, got: %s", value)
+	}
+	if tags["Name"] != "TestServer" {
+		t.Errorf("Expected Name=TestServer, got %v", tags["Name"])
+	}
+	if tags["Environment"] != "production" {
+		t.Errorf("Expected Environment=production, got %v", tags["Environment"])
+	}
+	if tags["Team"] != "backend" {
+		t.Errorf("Expected Team=backend, got %v", tags["Team"])
 	}
 }
 
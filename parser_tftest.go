@@ -0,0 +1,186 @@
+package tfdiff
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// DefaultTestDirectory is the directory Terraform searches for test files in addition to the module root
+const DefaultTestDirectory = "tests"
+
+// FindTestFiles finds all .tftest.hcl files in the module root and its tests directory
+func FindTestFiles(modulePath string) ([]string, error) {
+	module, err := osModuleFS(modulePath)
+	if err != nil {
+		return nil, err
+	}
+	files, err := findTestFiles(module)
+	if err != nil {
+		return nil, err
+	}
+	return module.filenames(files), nil
+}
+
+// findTestFiles returns the names of the .tftest.hcl files in the module root and its tests directory
+func findTestFiles(module moduleFS) ([]string, error) {
+	return module.findFiles("*.tftest.hcl", path.Join(DefaultTestDirectory, "*.tftest.hcl"))
+}
+
+// parseTestFiles discovers and parses the Terraform test files of a module
+func parseTestFiles(parser *hclparse.Parser, module moduleFS, def *ModuleDefinition, patterns []string) error {
+	files, err := findTestFiles(module)
+	if err != nil {
+		return fmt.Errorf("failed to find .tftest.hcl files: %w", err)
+	}
+
+	files, err = module.filterIgnored(files, patterns)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		content, err := module.readFile(file)
+		if err != nil {
+			return err
+		}
+		filename := module.filename(file)
+		testFile, err := parseTestFile(parser, filename, file, content)
+		if err != nil {
+			return fmt.Errorf("failed to parse test file %s: %w", filename, err)
+		}
+		def.TestFiles = append(def.TestFiles, *testFile)
+	}
+
+	return nil
+}
+
+// parseTestFile parses a single .tftest.hcl file into a TestFile
+func parseTestFile(parser *hclparse.Parser, filename, relPath string, content []byte) (*TestFile, error) {
+	file, diags := parser.ParseHCL(content, filename)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse HCL file %s: %s", filename, diags.Error())
+	}
+
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, fmt.Errorf("expected HCL syntax body in file %s", filename)
+	}
+
+	testFile := &TestFile{
+		Path: relPath,
+	}
+
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "run":
+			if len(block.Labels) != 1 {
+				return nil, fmt.Errorf("run block must have exactly one label")
+			}
+			testFile.Runs = append(testFile.Runs, parseTestRunBlock(block, filename, content))
+		case "variables":
+			testFile.Variables = parseTestVariables(block.Body, content)
+		case "provider", "mock_provider":
+			if len(block.Labels) != 1 {
+				return nil, fmt.Errorf("%s block must have exactly one label", block.Type)
+			}
+			testFile.Providers = append(testFile.Providers, parseTestProviderBlock(block, content))
+		}
+	}
+
+	return testFile, nil
+}
+
+func parseTestRunBlock(block *hclsyntax.Block, filename string, content []byte) TestRun {
+	run := TestRun{
+		Name:     block.Labels[0],
+		Command:  "apply",
+		Position: fmt.Sprintf("%s:%d", filepath.Base(filename), block.DefRange().Start.Line),
+	}
+
+	for name, attr := range block.Body.Attributes {
+		switch name {
+		case "command":
+			run.Command = expressionString(attr.Expr, content)
+		case "expect_failures":
+			run.ExpectFailures = expressionListSource(attr.Expr, content)
+		}
+	}
+
+	for _, nested := range block.Body.Blocks {
+		switch nested.Type {
+		case "variables":
+			run.Variables = parseTestVariables(nested.Body, content)
+		case "module":
+			if attr, ok := nested.Body.Attributes["source"]; ok {
+				run.Module = expressionString(attr.Expr, content)
+			}
+		case "assert":
+			assertion := TestAssertion{}
+			if attr, ok := nested.Body.Attributes["condition"]; ok {
+				assertion.Condition = expressionSource(attr.Expr, content)
+			}
+			if attr, ok := nested.Body.Attributes["error_message"]; ok {
+				assertion.ErrorMessage = expressionString(attr.Expr, content)
+			}
+			run.Assertions = append(run.Assertions, assertion)
+		}
+	}
+
+	return run
+}
+
+func parseTestVariables(body *hclsyntax.Body, content []byte) map[string]Value {
+	if len(body.Attributes) == 0 {
+		return nil
+	}
+	variables := make(map[string]Value)
+	for name, attr := range body.Attributes {
+		variables[name] = expressionValue(attr.Expr, content)
+	}
+	return variables
+}
+
+func parseTestProviderBlock(block *hclsyntax.Block, content []byte) TestProvider {
+	provider := TestProvider{
+		Name: block.Labels[0],
+		Mock: block.Type == "mock_provider",
+	}
+
+	if attr, ok := block.Body.Attributes["alias"]; ok {
+		provider.Alias = expressionString(attr.Expr, content)
+	}
+
+	// Record which resources and data sources are mocked or overridden
+	for _, nested := range block.Body.Blocks {
+		switch nested.Type {
+		case "mock_resource", "mock_data", "override_resource", "override_data":
+			name := nested.Type
+			if len(nested.Labels) > 0 {
+				name = fmt.Sprintf("%s.%s", nested.Type, strings.Join(nested.Labels, "."))
+			}
+			provider.Mocks = append(provider.Mocks, name)
+		}
+	}
+	sort.Strings(provider.Mocks)
+
+	return provider
+}
+
+// expressionListSource returns the source text of each element of a tuple expression
+func expressionListSource(expr hclsyntax.Expression, content []byte) []string {
+	tuple, ok := expr.(*hclsyntax.TupleConsExpr)
+	if !ok {
+		return []string{expressionSource(expr, content)}
+	}
+	values := make([]string, 0, len(tuple.Exprs))
+	for _, item := range tuple.Exprs {
+		values = append(values, expressionSource(item, content))
+	}
+	return values
+}
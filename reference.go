@@ -0,0 +1,75 @@
+package tfdiff
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ExpandInstancePaths expands glob patterns into the directories they match.
+// Patterns without glob matches are kept as is so that validation reports them.
+func ExpandInstancePaths(patterns []string) ([]string, error) {
+	var paths []string
+	seen := make(map[string]bool)
+
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			matches = []string{pattern}
+		}
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && !info.IsDir() {
+				continue
+			}
+			if !seen[match] {
+				seen[match] = true
+				paths = append(paths, match)
+			}
+		}
+	}
+
+	return paths, nil
+}
+
+// CompareReference compares every instance directory against a reference module and
+// aggregates the results. An instance diverges when its comparison has any diff.
+func CompareReference(referencePath string, instancePaths []string, options ParseOptions, config ComparisonConfig) (*ReferenceComparisonResult, error) {
+	if err := ValidateModuleDirectory(referencePath); err != nil {
+		return nil, fmt.Errorf("reference directory validation failed: %w", err)
+	}
+	reference, err := ParseModuleWithOptions(referencePath, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse reference module: %w", err)
+	}
+
+	result := &ReferenceComparisonResult{
+		ReferencePath: referencePath,
+		Results:       []*ComparisonResult{},
+	}
+
+	var allDiffs []Diff
+	for _, instancePath := range instancePaths {
+		if err := ValidateModuleDirectory(instancePath); err != nil {
+			return nil, fmt.Errorf("instance directory validation failed for %s: %w", instancePath, err)
+		}
+		instance, err := ParseModuleWithOptions(instancePath, options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse instance module %s: %w", instancePath, err)
+		}
+
+		comparison := CompareModules(reference, instance, config)
+		SortDiffs(comparison.Diffs)
+		result.Results = append(result.Results, comparison)
+
+		if len(comparison.Diffs) > 0 {
+			result.Diverged++
+		}
+		allDiffs = append(allDiffs, comparison.Diffs...)
+	}
+	result.Summary = summarizeDiffs(allDiffs)
+
+	return result, nil
+}
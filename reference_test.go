@@ -0,0 +1,62 @@
+package tfdiff
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompareReference(t *testing.T) {
+	root := t.TempDir()
+	golden := `
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+`
+	writeModuleFiles(t, filepath.Join(root, "golden"), map[string]string{"main.tf": golden})
+	writeModuleFiles(t, filepath.Join(root, "teams", "alpha"), map[string]string{"main.tf": golden})
+	writeModuleFiles(t, filepath.Join(root, "teams", "beta"), map[string]string{
+		"main.tf": golden + `
+resource "aws_s3_bucket" "debug" {
+  bucket = "debug"
+}
+`,
+	})
+	writeModuleFiles(t, filepath.Join(root, "teams"), map[string]string{"README.md": "not a module"})
+
+	instances, err := ExpandInstancePaths([]string{filepath.Join(root, "teams", "*")})
+	if err != nil {
+		t.Fatalf("failed to expand instance paths: %v", err)
+	}
+	if len(instances) != 2 {
+		t.Fatalf("expected 2 instance directories, got %v", instances)
+	}
+
+	config := ComparisonConfig{Levels: []ComparisonLevel{ComparisonLevelResources}}
+	result, err := CompareReference(filepath.Join(root, "golden"), instances, ParseOptions{}, config)
+	if err != nil {
+		t.Fatalf("CompareReference failed: %v", err)
+	}
+
+	if len(result.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(result.Results))
+	}
+	if result.Diverged != 1 {
+		t.Errorf("expected 1 diverging instance, got %d", result.Diverged)
+	}
+	if result.Summary.Added != 1 || result.Summary.Total != 1 {
+		t.Errorf("unexpected summary: %+v", result.Summary)
+	}
+
+	output := FormatReferenceOutput(result, config, true)
+	for _, want := range []string{
+		"no differences",
+		"1 added, 0 removed, 0 modified",
+		"1 of 2 instances diverge from the reference",
+		`+resource "aws_s3_bucket" "debug" {`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, output)
+		}
+	}
+}
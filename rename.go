@@ -0,0 +1,194 @@
+package tfdiff
+
+import (
+	"fmt"
+	"sort"
+)
+
+// renameCandidate is a removed and an added element that may be one renamed element
+type renameCandidate struct {
+	removed, added int
+	similarity     float64
+}
+
+// detectRenames replaces pairs of removed and added module calls, outputs, resources and data
+// sources whose attributes are at least threshold similar by a single renamed diff. Resources
+// and data sources are only paired with elements of the same type. Pairs are matched greedily
+// from the most similar one.
+func detectRenames(diffs []Diff, threshold float64) []Diff {
+	var candidates []renameCandidate
+	for i, removed := range diffs {
+		if removed.Type != DiffTypeRemoved {
+			continue
+		}
+		for j, added := range diffs {
+			if added.Type != DiffTypeAdded || added.Level != removed.Level || !renameComparable(removed.Before, added.After) {
+				continue
+			}
+			similarity := elementSimilarity(renameAttributes(removed.Before), renameAttributes(added.After))
+			if similarity >= threshold {
+				candidates = append(candidates, renameCandidate{i, j, similarity})
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return diffs
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].similarity != candidates[j].similarity {
+			return candidates[i].similarity > candidates[j].similarity
+		}
+		if diffs[candidates[i].removed].Element != diffs[candidates[j].removed].Element {
+			return diffs[candidates[i].removed].Element < diffs[candidates[j].removed].Element
+		}
+		return diffs[candidates[i].added].Element < diffs[candidates[j].added].Element
+	})
+
+	renamed := make(map[int]Diff)
+	matched := make(map[int]bool)
+	for _, candidate := range candidates {
+		if matched[candidate.removed] || matched[candidate.added] {
+			continue
+		}
+		matched[candidate.removed] = true
+		matched[candidate.added] = true
+
+		removed, added := diffs[candidate.removed], diffs[candidate.added]
+		renamed[candidate.added] = Diff{
+			Type:        DiffTypeRenamed,
+			Level:       added.Level,
+			Element:     added.Element,
+			RenamedFrom: removed.Element,
+			Similarity:  candidate.similarity,
+			Before:      removed.Before,
+			After:       added.After,
+			Message:     fmt.Sprintf("%s '%s' was renamed to '%s'", renameLevelName(added.Level), removed.Element, added.Element),
+		}
+	}
+
+	result := make([]Diff, 0, len(diffs)-len(renamed))
+	for i, diff := range diffs {
+		if rename, ok := renamed[i]; ok {
+			result = append(result, rename)
+		} else if !matched[i] {
+			result = append(result, diff)
+		}
+	}
+	return result
+}
+
+// renameComparable reports whether two elements may be the same element under another name
+func renameComparable(before, after interface{}) bool {
+	switch b := before.(type) {
+	case Resource:
+		a, ok := after.(Resource)
+		return ok && a.Type == b.Type
+	case DataSource:
+		a, ok := after.(DataSource)
+		return ok && a.Type == b.Type
+	case ModuleCall:
+		_, ok := after.(ModuleCall)
+		return ok
+	case Output:
+		_, ok := after.(Output)
+		return ok
+	default:
+		return false
+	}
+}
+
+// renameAttributes flattens the attributes of an element the way the drift matrix does.
+// Arguments are always included, as they are what identifies a renamed element.
+func renameAttributes(element interface{}) map[string]interface{} {
+	def := &ModuleDefinition{}
+	var level ComparisonLevel
+	switch e := element.(type) {
+	case ModuleCall:
+		def.ModuleCalls, level = []ModuleCall{e}, ComparisonLevelModuleCalls
+	case Output:
+		def.Outputs, level = []Output{e}, ComparisonLevelOutputs
+	case Resource:
+		def.Resources, level = []Resource{e}, ComparisonLevelResources
+	case DataSource:
+		def.DataSources, level = []DataSource{e}, ComparisonLevelDataSources
+	default:
+		return nil
+	}
+
+	elements := flattenModule(def, ComparisonConfig{Levels: []ComparisonLevel{level}})
+	if len(elements) == 0 {
+		return nil
+	}
+	return elements[0].Attributes
+}
+
+// elementSimilarity scores how similar two flattened elements are, from 0 to 1, as the share
+// of attributes with equal values among the attributes of both elements. Values that could not
+// be evaluated are left out, as they look alike whatever they reference. Elements without an
+// equal attribute other than a flag, such as sensitive, have no similarity.
+func elementSimilarity(left, right map[string]interface{}) float64 {
+	left, right = evaluatedAttributes(left), evaluatedAttributes(right)
+	equal, significant := 0, false
+	for key, value := range left {
+		if other, ok := right[key]; ok && matrixValuesEqual([]interface{}{value, other}) {
+			equal++
+			significant = significant || !isFlagValue(value)
+		}
+	}
+	if !significant {
+		return 0
+	}
+	return float64(2*equal) / float64(len(left)+len(right))
+}
+
+// evaluatedAttributes returns the attributes whose values could be evaluated
+func evaluatedAttributes(attrs map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(attrs))
+	for key, value := range attrs {
+		if value != "<complex_expression>" {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// isFlagValue reports whether a flattened value is a bool, which alone does not identify an
+// element
+func isFlagValue(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return true
+	case string:
+		return v == "true" || v == "false"
+	default:
+		return false
+	}
+}
+
+// isRenameableLevel reports whether elements of a level may be renamed or matched by
+// another name
+func isRenameableLevel(level string) bool {
+	switch level {
+	case "module_call", "output", "resource", "data_source":
+		return true
+	default:
+		return false
+	}
+}
+
+// renameLevelName names an element level in rename messages
+func renameLevelName(level string) string {
+	switch level {
+	case "module_call":
+		return "Module call"
+	case "output":
+		return "Output"
+	case "resource":
+		return "Resource"
+	case "data_source":
+		return "Data source"
+	default:
+		return level
+	}
+}
@@ -0,0 +1,137 @@
+package tfdiff
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCompareModules_Renames(t *testing.T) {
+	left := parseTestModule(t, map[string]string{
+		"main.tf": `
+resource "aws_s3_bucket" "logs" {
+  bucket        = "my-logs"
+  force_destroy = true
+  tags = {
+    Team = "platform"
+  }
+}
+
+resource "aws_iam_role" "old" {
+  name = "deployer"
+}
+
+output "bucket_arn" {
+  value       = aws_s3_bucket.logs.arn
+  description = "Bucket ARN"
+}
+`,
+	})
+	right := parseTestModule(t, map[string]string{
+		"main.tf": `
+resource "aws_s3_bucket" "access_logs" {
+  bucket        = "my-logs"
+  force_destroy = false
+  tags = {
+    Team = "platform"
+  }
+}
+
+resource "aws_iam_policy" "old_policy" {
+  name = "deployer"
+}
+
+output "logs_bucket_arn" {
+  value       = aws_s3_bucket.logs.arn
+  description = "Bucket ARN"
+}
+`,
+	})
+	levels := []ComparisonLevel{ComparisonLevelOutputs, ComparisonLevelResources}
+
+	t.Run("disabled", func(t *testing.T) {
+		result := CompareModules(left, right, ComparisonConfig{Levels: levels})
+		if result.Summary.Renamed != 0 || result.Summary.Added != 3 || result.Summary.Removed != 3 {
+			t.Errorf("expected 3 added and 3 removed elements, got %+v", result.Summary)
+		}
+	})
+
+	t.Run("detected", func(t *testing.T) {
+		result := CompareModules(left, right, ComparisonConfig{Levels: levels, RenameThreshold: 0.6})
+		if result.Summary.Renamed != 2 || result.Summary.Added != 1 || result.Summary.Removed != 1 {
+			t.Fatalf("expected 2 renamed, 1 added and 1 removed elements, got %+v", result.Summary)
+		}
+
+		renames := make(map[string]Diff)
+		for _, diff := range result.Diffs {
+			if diff.Type == DiffTypeRenamed {
+				renames[diff.RenamedFrom] = diff
+			}
+		}
+		if diff := renames["aws_s3_bucket.logs"]; diff.Element != "aws_s3_bucket.access_logs" || diff.Similarity >= 1 {
+			t.Errorf("expected aws_s3_bucket.logs renamed with changes, got %+v", diff)
+		}
+		if diff := renames["bucket_arn"]; diff.Element != "logs_bucket_arn" || diff.Similarity != 1 {
+			t.Errorf("expected bucket_arn renamed without changes, got %+v", diff)
+		}
+
+		output := FormatTextOutput(result, ComparisonConfig{Levels: levels}, true)
+		for _, want := range []string{
+			"-resource \"aws_s3_bucket\" \"logs\" {",
+			"+resource \"aws_s3_bucket\" \"access_logs\" {",
+			"force_destroy = true",
+			"force_destroy = false",
+			"-output \"bucket_arn\" {",
+			"+output \"logs_bucket_arn\" {",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("expected output to contain %q, got:\n%s", want, output)
+			}
+		}
+		if strings.Contains(output, "Team") {
+			t.Errorf("expected unchanged attributes to be hidden, got:\n%s", output)
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), `"renamed_from":"aws_s3_bucket.logs"`) {
+			t.Errorf("expected renamed_from in JSON output, got %s", data)
+		}
+	})
+
+	t.Run("below threshold", func(t *testing.T) {
+		result := CompareModules(left, right, ComparisonConfig{Levels: levels, RenameThreshold: 0.9})
+		if result.Summary.Renamed != 1 {
+			t.Errorf("expected only the unchanged output to be renamed, got %+v", result.Summary)
+		}
+	})
+
+	t.Run("unevaluable values", func(t *testing.T) {
+		left := parseTestModule(t, map[string]string{"outputs.tf": `
+output "bucket_arn" {
+  value = aws_s3_bucket.logs.arn
+}
+
+output "token" {
+  value     = random_password.token.result
+  sensitive = true
+}
+`})
+		right := parseTestModule(t, map[string]string{"outputs.tf": `
+output "db_password" {
+  value = random_password.db.result
+}
+
+output "api_token" {
+  value     = random_password.api.result
+  sensitive = true
+}
+`})
+		result := CompareModules(left, right, ComparisonConfig{Levels: levels, RenameThreshold: 0.8})
+		if result.Summary.Renamed != 0 || result.Summary.Added != 2 || result.Summary.Removed != 2 {
+			t.Errorf("expected 2 added and 2 removed outputs, got %+v", result.Summary)
+		}
+	})
+}
@@ -0,0 +1,436 @@
+package tfdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Rules holds the comparison rules of a rules file
+type Rules struct {
+	Substitutions    []Substitution  `hcl:"substitute,block"`
+	IgnoreAttributes []string        `hcl:"ignore_attributes,optional"`
+	SetAttributes    []string        `hcl:"set_attributes,optional"`
+	Identities       []IdentityRule  `hcl:"identity,block"`
+	Matches          []ResourceMatch `hcl:"match,block"`
+	BlockKeys        []BlockKey      `hcl:"block_key,block"`
+}
+
+// Substitution rewrites right-side values before they are compared, so that values that
+// differ only by a naming convention compare equal. From is replaced literally and Regex as
+// a regular expression whose replacement may reference groups ($1). Levels, Elements and
+// Attributes optionally restrict the substitution to element levels (resource, module_call,
+// ...), element names and attribute paths; Elements and Attributes are glob patterns.
+type Substitution struct {
+	From       string   `hcl:"from,optional" json:"from,omitempty"`
+	Regex      string   `hcl:"regex,optional" json:"regex,omitempty"`
+	To         string   `hcl:"to" json:"to"`
+	Levels     []string `hcl:"levels,optional" json:"levels,omitempty"`
+	Elements   []string `hcl:"elements,optional" json:"elements,omitempty"`
+	Attributes []string `hcl:"attributes,optional" json:"attributes,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// ReadRules reads and validates a rules file
+func ReadRules(rulesPath string) (*Rules, error) {
+	file, diags := hclparse.NewParser().ParseHCLFile(rulesPath)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse rules file: %s", diags.Error())
+	}
+
+	var rules Rules
+	if diags := gohcl.DecodeBody(file.Body, nil, &rules); diags.HasErrors() {
+		return nil, fmt.Errorf("failed to decode rules file: %s", diags.Error())
+	}
+
+	for i := range rules.Substitutions {
+		if err := rules.Substitutions[i].compile(); err != nil {
+			return nil, fmt.Errorf("invalid substitute block %d in %s: %w", i+1, rulesPath, err)
+		}
+	}
+	if err := validatePathPatterns("attribute", rules.IgnoreAttributes); err != nil {
+		return nil, fmt.Errorf("invalid ignore_attributes in %s: %w", rulesPath, err)
+	}
+	if err := validatePathPatterns("attribute", rules.SetAttributes); err != nil {
+		return nil, fmt.Errorf("invalid set_attributes in %s: %w", rulesPath, err)
+	}
+	for i, match := range rules.Matches {
+		if err := match.validate(); err != nil {
+			return nil, fmt.Errorf("invalid match block %d in %s: %w", i+1, rulesPath, err)
+		}
+	}
+	return &rules, nil
+}
+
+// compile validates a substitution and compiles its regular expression
+func (s *Substitution) compile() error {
+	if (s.From == "") == (s.Regex == "") {
+		return fmt.Errorf("exactly one of from and regex is required")
+	}
+	if s.Regex != "" {
+		pattern, err := regexp.Compile(s.Regex)
+		if err != nil {
+			return fmt.Errorf("invalid regex %q: %w", s.Regex, err)
+		}
+		s.pattern = pattern
+	}
+	for _, pattern := range append(append([]string{}, s.Elements...), s.Attributes...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// appliesTo reports whether the substitution is scoped to an attribute of an element
+func (s *Substitution) appliesTo(level, element, attribute string) bool {
+	if len(s.Levels) > 0 && !stringSliceContains(s.Levels, level) {
+		return false
+	}
+	return matchesAnyGlob(s.Elements, element) && matchesAnyGlob(s.Attributes, attribute)
+}
+
+// replace applies the substitution to a value
+func (s *Substitution) replace(value string) string {
+	if s.Regex == "" {
+		return strings.ReplaceAll(value, s.From, s.To)
+	}
+	// Substitutions built without ReadRules are compiled on first use
+	if s.pattern == nil {
+		if err := s.compile(); err != nil {
+			return value
+		}
+	}
+	return s.pattern.ReplaceAllString(value, s.To)
+}
+
+// matchesAnyGlob reports whether a name matches any of the glob patterns; no patterns match everything
+func matchesAnyGlob(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// stringSliceContains reports whether a slice contains a string
+func stringSliceContains(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+// compareWithSubstitutions compares two modules, treating elements and attributes as equal
+// when they are equal after applying the substitutions to the right module. The diffs keep
+// the original values of both modules.
+func compareWithSubstitutions(left, right *ModuleDefinition, config ComparisonConfig) *ComparisonResult {
+	substitutions := config.Substitutions
+	config.Substitutions = nil
+
+	result := CompareModules(left, right, config)
+	substituted := CompareModules(left, substituteModule(right, substitutions), config)
+
+	type diffKey struct {
+		diffType       DiffType
+		level, element string
+	}
+	remaining := make(map[diffKey]Diff)
+	for _, diff := range substituted.Diffs {
+		remaining[diffKey{diff.Type, diff.Level, diff.Element}] = diff
+	}
+
+	diffs := []Diff{}
+	for _, diff := range result.Diffs {
+		substitutedDiff, ok := remaining[diffKey{diff.Type, diff.Level, diff.Element}]
+		if !ok {
+			continue
+		}
+		// Attributes equal after substitution are not reported as changed
+		if diff.Type == DiffTypeModified {
+			diff.Changes = remainingChanges(diff.Changes, substitutedDiff.Changes)
+		}
+		diffs = append(diffs, diff)
+	}
+	classifyDiffs(diffs)
+	result.Diffs = diffs
+	result.Summary = summarizeDiffs(diffs)
+	result.Compatibility = overallCompatibility(diffs)
+	return result
+}
+
+// remainingChanges returns the changes whose paths still differ after substitution, that is
+// the changes with a path equal to, within or containing the path of a substituted change
+func remainingChanges(changes, substituted []AttributeChange) []AttributeChange {
+	var result []AttributeChange
+	for _, change := range changes {
+		for _, other := range substituted {
+			if pathsOverlap(change.Path, other.Path) {
+				result = append(result, change)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// pathsOverlap reports whether two attribute paths are equal or one is within the other
+func pathsOverlap(a, b string) bool {
+	within := func(path, parent string) bool {
+		return strings.HasPrefix(path, parent+".") || strings.HasPrefix(path, parent+"[")
+	}
+	return a == b || within(a, b) || within(b, a)
+}
+
+// substituteModule returns a copy of a module whose values are rewritten by the substitutions.
+// Levels, elements and attribute paths are named like flattenModule names them.
+func substituteModule(def *ModuleDefinition, substitutions []Substitution) *ModuleDefinition {
+	apply := func(level, element, attribute, value string) string {
+		for i := range substitutions {
+			if substitutions[i].appliesTo(level, element, attribute) {
+				value = substitutions[i].replace(value)
+			}
+		}
+		return value
+	}
+	applyValues := func(level, element, prefix string, values map[string]Value) map[string]Value {
+		if values == nil {
+			return nil
+		}
+		result := make(map[string]Value, len(values))
+		for key, value := range values {
+			result[key] = substituteTypedValue(level, element, joinAttributePath(prefix, key), value, apply)
+		}
+		return result
+	}
+
+	copied := *def
+
+	copied.ModuleCalls = make([]ModuleCall, len(def.ModuleCalls))
+	for i, mc := range def.ModuleCalls {
+		mc.Source = apply("module_call", mc.Name, "source", mc.Source)
+		mc.Version = apply("module_call", mc.Name, "version", mc.Version)
+		mc.Args = applyValues("module_call", mc.Name, "args", mc.Args)
+		copied.ModuleCalls[i] = mc
+	}
+
+	copied.Outputs = make([]Output, len(def.Outputs))
+	for i, output := range def.Outputs {
+		output.Description = apply("output", output.Name, "description", output.Description)
+		output.Value = substituteString("output", output.Name, "value", output.Value, apply)
+		copied.Outputs[i] = output
+	}
+
+	copied.Resources = make([]Resource, len(def.Resources))
+	for i, r := range def.Resources {
+		r.Config = substituteConfig("resource", resourceKey(r), "", r.Config, apply)
+		copied.Resources[i] = r
+	}
+
+	copied.DataSources = make([]DataSource, len(def.DataSources))
+	for i, ds := range def.DataSources {
+		ds.Config = substituteConfig("data_source", dataSourceKey(ds), "", ds.Config, apply)
+		copied.DataSources[i] = ds
+	}
+
+	copied.Variables = make([]Variable, len(def.Variables))
+	for i, v := range def.Variables {
+		v.Description = apply("variable", v.Name, "description", v.Description)
+		if v.DefaultValue != nil {
+			value := substituteTypedValue("variable", v.Name, "default", *v.DefaultValue, apply)
+			v.DefaultValue = &value
+		}
+		copied.Variables[i] = v
+	}
+
+	copied.RequiredProviders = make([]RequiredProvider, len(def.RequiredProviders))
+	for i, p := range def.RequiredProviders {
+		p.Source = apply("required_provider", p.Name, "source", p.Source)
+		p.Version = apply("required_provider", p.Name, "version", p.Version)
+		copied.RequiredProviders[i] = p
+	}
+
+	copied.TestFiles = make([]TestFile, len(def.TestFiles))
+	for i, tf := range def.TestFiles {
+		tf.Variables = applyValues("test_file", tf.Path, "variables", tf.Variables)
+		runs := make([]TestRun, len(tf.Runs))
+		for j, run := range tf.Runs {
+			run.Variables = applyValues("test_run", testRunKey(tf.Path, run.Name), "variables", run.Variables)
+			runs[j] = run
+		}
+		tf.Runs = runs
+		copied.TestFiles[i] = tf
+	}
+
+	copied.Components = make([]Component, len(def.Components))
+	for i, c := range def.Components {
+		c.Source = apply("component", c.Name, "source", c.Source)
+		c.Version = apply("component", c.Name, "version", c.Version)
+		c.Inputs = applyValues("component", c.Name, "inputs", c.Inputs)
+		c.Providers = applyValues("component", c.Name, "providers", c.Providers)
+		copied.Components[i] = c
+	}
+
+	copied.Deployments = make([]Deployment, len(def.Deployments))
+	for i, d := range def.Deployments {
+		d.Inputs = applyValues("deployment", d.Name, "inputs", d.Inputs)
+		copied.Deployments[i] = d
+	}
+
+	copied.IdentityTokens = make([]IdentityToken, len(def.IdentityTokens))
+	for i, token := range def.IdentityTokens {
+		token.Audience = substituteString("identity_token", token.Name, "audience", token.Audience, apply)
+		copied.IdentityTokens[i] = token
+	}
+
+	if def.Terragrunt != nil {
+		tg := *def.Terragrunt
+		tg.Source = apply("terragrunt", "terraform", "source", tg.Source)
+		tg.Inputs = applyValues("terragrunt", "inputs", "", tg.Inputs)
+		tg.Dependencies = make([]TerragruntDependency, len(def.Terragrunt.Dependencies))
+		for i, dependency := range def.Terragrunt.Dependencies {
+			dependency.ConfigPath = apply("terragrunt", "dependency."+dependency.Name, "config_path", dependency.ConfigPath)
+			tg.Dependencies[i] = dependency
+		}
+		if tg.RemoteState != nil {
+			remoteState := *tg.RemoteState
+			remoteState.Backend = apply("terragrunt", "remote_state", "backend", remoteState.Backend)
+			remoteState.Config = applyValues("terragrunt", "remote_state", "config", remoteState.Config)
+			tg.RemoteState = &remoteState
+		}
+		copied.Terragrunt = &tg
+	}
+
+	return &copied
+}
+
+// substituteString applies substitutions to a string value. JSON objects and arrays are
+// rewritten value by value, with their keys and indices appended to the attribute path.
+func substituteString(level, element, attribute, value string, apply func(level, element, attribute, value string) string) string {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(value), &decoded); err == nil {
+		switch decoded.(type) {
+		case map[string]interface{}, []interface{}:
+			rewritten := substituteValue(level, element, attribute, decoded, apply)
+			if data, err := json.Marshal(rewritten); err == nil && !valuesEqual(value, string(data)) {
+				return string(data)
+			}
+			return value
+		}
+	}
+	return apply(level, element, attribute, value)
+}
+
+// substituteConfig applies substitutions to a resource or data source config.
+// Nested blocks are addressed like flattenConfig addresses them.
+func substituteConfig(level, element, prefix string, config map[string]interface{}, apply func(level, element, attribute, value string) string) map[string]interface{} {
+	if config == nil {
+		return nil
+	}
+	result := make(map[string]interface{}, len(config))
+	for key, value := range config {
+		if blocks, ok := value.(map[string][]map[string]interface{}); ok && key == "_blocks" {
+			rewritten := make(map[string][]map[string]interface{}, len(blocks))
+			for blockType, list := range blocks {
+				for i, block := range list {
+					blockPath := joinAttributePath(prefix, fmt.Sprintf("%s[%d]", blockType, i))
+					rewritten[blockType] = append(rewritten[blockType], substituteConfig(level, element, blockPath, block, apply))
+				}
+			}
+			result[key] = rewritten
+			continue
+		}
+		if key == "_labels" {
+			result[key] = value
+			continue
+		}
+		result[key] = substituteValue(level, element, joinAttributePath(prefix, key), value, apply)
+	}
+	return result
+}
+
+// substituteValue applies substitutions to the strings within a decoded value
+func substituteValue(level, element, attribute string, value interface{}, apply func(level, element, attribute, value string) string) interface{} {
+	switch v := value.(type) {
+	case Value:
+		return substituteTypedValue(level, element, attribute, v, apply)
+	case string:
+		return substituteString(level, element, attribute, v, apply)
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = substituteValue(level, element, joinAttributePath(attribute, key), item, apply)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = substituteValue(level, element, fmt.Sprintf("%s[%d]", attribute, i), item, apply)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// substituteTypedValue applies substitutions to the strings within a value, keeping its
+// type, or to the source text of an expression
+func substituteTypedValue(level, element, attribute string, value Value, apply func(level, element, attribute, value string) string) Value {
+	if value.IsExpression() {
+		return Value{Val: value.Val, Source: apply(level, element, attribute, value.Source)}
+	}
+	return Value{Val: substituteCty(level, element, attribute, value.Val, apply)}
+}
+
+// substituteCty applies substitutions to the strings within a cty value. Object attributes
+// and map keys are appended to the attribute path like JSON keys, and list elements as indices.
+func substituteCty(level, element, attribute string, val cty.Value, apply func(level, element, attribute, value string) string) cty.Value {
+	if val.IsNull() || !val.IsKnown() || !val.Type().IsPrimitiveType() && val.LengthInt() == 0 {
+		return val
+	}
+
+	typ := val.Type()
+	switch {
+	case typ == cty.String:
+		return cty.StringVal(substituteString(level, element, attribute, val.AsString(), apply))
+	case typ.IsObjectType() || typ.IsMapType():
+		items := make(map[string]cty.Value, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			key, item := it.Element()
+			items[key.AsString()] = substituteCty(level, element, joinAttributePath(attribute, key.AsString()), item, apply)
+		}
+		if typ.IsMapType() {
+			return cty.MapVal(items)
+		}
+		return cty.ObjectVal(items)
+	case typ.IsListType() || typ.IsSetType() || typ.IsTupleType():
+		var items []cty.Value
+		for it := val.ElementIterator(); it.Next(); {
+			_, item := it.Element()
+			items = append(items, substituteCty(level, element, fmt.Sprintf("%s[%d]", attribute, len(items)), item, apply))
+		}
+		switch {
+		case typ.IsListType():
+			return cty.ListVal(items)
+		case typ.IsSetType():
+			return cty.SetVal(items)
+		default:
+			return cty.TupleVal(items)
+		}
+	default:
+		return val
+	}
+}
@@ -0,0 +1,259 @@
+package tfdiff
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadRules(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		want        int
+		errContains string
+	}{
+		{
+			name: "literal and regex substitutions",
+			content: `
+substitute {
+  from = "production"
+  to   = "staging"
+}
+
+substitute {
+  regex      = "-prd$"
+  to         = "-stg"
+  levels     = ["resource"]
+  elements   = ["aws_s3_bucket.*"]
+  attributes = ["bucket"]
+}
+`,
+			want: 2,
+		},
+		{
+			name: "from and regex together",
+			content: `
+substitute {
+  from  = "production"
+  regex = "prd"
+  to    = "staging"
+}
+`,
+			errContains: "exactly one of from and regex",
+		},
+		{
+			name: "invalid regex",
+			content: `
+substitute {
+  regex = "("
+  to    = "staging"
+}
+`,
+			errContains: "invalid regex",
+		},
+		{
+			name: "ignore attributes",
+			content: `
+ignore_attributes = ["resource.aws_instance.*.tags.Environment", "module.*.version"]
+
+substitute {
+  from = "production"
+  to   = "staging"
+}
+`,
+			want: 1,
+		},
+		{
+			name:        "invalid ignore attribute",
+			content:     `ignore_attributes = ["resource..tags"]`,
+			errContains: "empty path segment",
+		},
+		{
+			name: "identity and match blocks",
+			content: `
+identity "aws_s3_bucket" {
+  attribute = "bucket"
+}
+
+match {
+  left  = "aws_iam_role.stg_deployer"
+  right = "aws_iam_role.prd_deployer"
+}
+`,
+			want: 0,
+		},
+		{
+			name: "block keys",
+			content: `
+block_key "ingress" {
+  attributes = ["from_port", "to_port", "protocol"]
+}
+`,
+			want: 0,
+		},
+		{
+			name: "invalid match address",
+			content: `
+match {
+  left  = "stg_deployer"
+  right = "aws_iam_role.prd_deployer"
+}
+`,
+			errContains: "expected TYPE.NAME",
+		},
+		{
+			name: "missing to",
+			content: `
+substitute {
+  from = "production"
+}
+`,
+			errContains: "failed to decode rules file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rulesPath := filepath.Join(t.TempDir(), "rules.hcl")
+			if err := os.WriteFile(rulesPath, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			rules, err := ReadRules(rulesPath)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("expected error containing %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(rules.Substitutions) != tt.want {
+				t.Errorf("expected %d substitutions, got %d", tt.want, len(rules.Substitutions))
+			}
+		})
+	}
+}
+
+func TestCompareModules_Substitutions(t *testing.T) {
+	staging := parseTestModule(t, map[string]string{
+		"main.tf": `
+module "app" {
+  source      = "./modules/app"
+  environment = "staging"
+}
+
+resource "aws_s3_bucket" "assets" {
+  bucket = "my-app-stg"
+  tags = {
+    Environment = "staging"
+  }
+}
+
+resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+  tags = {
+    Environment = "staging"
+  }
+}
+`,
+	})
+	production := parseTestModule(t, map[string]string{
+		"main.tf": `
+module "app" {
+  source      = "./modules/app"
+  environment = "production"
+}
+
+resource "aws_s3_bucket" "assets" {
+  bucket = "my-app-prd"
+  tags = {
+    Environment = "production"
+  }
+}
+
+resource "aws_instance" "web" {
+  instance_type = "t3.large"
+  tags = {
+    Environment = "production"
+  }
+}
+`,
+	})
+
+	levels := []ComparisonLevel{ComparisonLevelModuleCalls, ComparisonLevelResources}
+	environment := Substitution{From: "production", To: "staging"}
+	suffix := Substitution{Regex: "-prd$", To: "-stg", Levels: []string{"resource"}, Attributes: []string{"bucket"}}
+
+	tests := []struct {
+		name          string
+		substitutions []Substitution
+		want          []string
+	}{
+		{
+			name: "no substitutions",
+			want: []string{"app", "aws_instance.web", "aws_s3_bucket.assets"},
+		},
+		{
+			name:          "environment name",
+			substitutions: []Substitution{environment},
+			want:          []string{"aws_instance.web", "aws_s3_bucket.assets"},
+		},
+		{
+			name:          "environment name and bucket suffix",
+			substitutions: []Substitution{environment, suffix},
+			want:          []string{"aws_instance.web"},
+		},
+		{
+			name:          "scoped to other elements",
+			substitutions: []Substitution{{From: "production", To: "staging", Elements: []string{"aws_instance.*"}}, suffix},
+			want:          []string{"app", "aws_instance.web", "aws_s3_bucket.assets"},
+		},
+		{
+			name:          "scoped to a nested attribute path",
+			substitutions: []Substitution{{From: "production", To: "staging", Attributes: []string{"tags.Environment"}}, suffix},
+			want:          []string{"app", "aws_instance.web"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := ComparisonConfig{Levels: levels, Substitutions: tt.substitutions}
+			result := CompareModules(staging, production, config)
+			SortDiffs(result.Diffs)
+
+			var elements []string
+			for _, diff := range result.Diffs {
+				elements = append(elements, diff.Element)
+			}
+			if strings.Join(elements, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected diffs %v, got %v", tt.want, elements)
+			}
+			if result.Summary.Total != len(tt.want) {
+				t.Errorf("expected summary total %d, got %d", len(tt.want), result.Summary.Total)
+			}
+		})
+	}
+
+	// Reported values stay the original right-side values
+	config := ComparisonConfig{Levels: levels, Substitutions: []Substitution{environment, suffix}}
+	result := CompareModules(staging, production, config)
+	if after, ok := result.Diffs[0].After.(Resource); !ok || !strings.Contains(after.Config["tags"].(Value).String(), "production") {
+		t.Errorf("expected the original right value, got %+v", result.Diffs[0].After)
+	}
+
+	// Attributes equal after substitution are not reported as changed
+	var paths []string
+	for _, change := range result.Diffs[0].Changes {
+		paths = append(paths, change.Path)
+	}
+	if strings.Join(paths, ",") != "instance_type" {
+		t.Errorf("expected only instance_type to change, got %v", paths)
+	}
+	output := FormatDiffOutput(result, config, true)
+	if strings.Contains(output, "Environment") || !strings.Contains(output, "instance_type") {
+		t.Errorf("expected only instance_type in the output, got:\n%s", output)
+	}
+}
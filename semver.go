@@ -0,0 +1,119 @@
+package tfdiff
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// VersionBump is the part of a semantic version a release increments
+type VersionBump string
+
+const (
+	VersionBumpMajor VersionBump = "major"
+	VersionBumpMinor VersionBump = "minor"
+	VersionBumpPatch VersionBump = "patch"
+	VersionBumpNone  VersionBump = "none"
+)
+
+// VersionRecommendation is the next semantic version recommended for a module, with the
+// bump each interface diff calls for
+type VersionRecommendation struct {
+	PreviousVersion string          `json:"previous_version"`
+	NextVersion     string          `json:"next_version"`
+	Bump            VersionBump     `json:"bump"`
+	Reasons         []VersionReason `json:"reasons"`
+}
+
+// VersionReason is the bump one diff calls for
+type VersionReason struct {
+	Bump    VersionBump `json:"bump"`
+	Level   string      `json:"level"`
+	Element string      `json:"element"`
+	Reason  string      `json:"reason"`
+}
+
+// APIResult is the result of the api command: the interface comparison and, given the
+// previous version, the recommended next version
+type APIResult struct {
+	*ComparisonResult
+	Recommendation *VersionRecommendation `json:"recommendation,omitempty"`
+}
+
+// semanticVersionPattern matches a semantic version with an optional v prefix
+var semanticVersionPattern = regexp.MustCompile(`^(v?)(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// rank orders bumps from the smallest to the largest
+func (b VersionBump) rank() int {
+	switch b {
+	case VersionBumpPatch:
+		return 1
+	case VersionBumpMinor:
+		return 2
+	case VersionBumpMajor:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// RecommendVersion recommends the version following previous for the interface changes of
+// a comparison: a major bump for breaking changes, a minor bump for compatible ones and a
+// patch bump for any other change. Before 1.0.0, breaking changes bump the minor version.
+// The v prefix of previous is kept, and a prerelease is released as its own version when
+// the bump allows it, so 2.0.0-rc.1 is followed by 2.0.0.
+func RecommendVersion(result *ComparisonResult, previous string) (*VersionRecommendation, error) {
+	match := semanticVersionPattern.FindStringSubmatch(previous)
+	if match == nil {
+		return nil, fmt.Errorf("invalid semantic version %q", previous)
+	}
+	prefix, prerelease := match[1], match[5]
+	major, _ := strconv.Atoi(match[2])
+	minor, _ := strconv.Atoi(match[3])
+	patch, _ := strconv.Atoi(match[4])
+
+	recommendation := &VersionRecommendation{
+		PreviousVersion: previous,
+		Bump:            VersionBumpNone,
+		Reasons:         []VersionReason{},
+	}
+	for _, diff := range result.Diffs {
+		reason := VersionReason{Level: diff.Level, Element: diff.Element, Reason: diff.CompatibilityReason}
+		switch diff.Compatibility {
+		case CompatibilityBreaking:
+			reason.Bump = VersionBumpMajor
+			if major == 0 {
+				reason.Bump = VersionBumpMinor
+				reason.Reason += " (breaking before 1.0.0)"
+			}
+		case CompatibilityCompatible:
+			reason.Bump = VersionBumpMinor
+		default:
+			reason.Bump = VersionBumpPatch
+		}
+		if reason.Bump.rank() > recommendation.Bump.rank() {
+			recommendation.Bump = reason.Bump
+		}
+		recommendation.Reasons = append(recommendation.Reasons, reason)
+	}
+
+	switch recommendation.Bump {
+	case VersionBumpMajor:
+		if prerelease == "" || minor != 0 || patch != 0 {
+			major, minor, patch = major+1, 0, 0
+		}
+	case VersionBumpMinor:
+		if prerelease == "" || patch != 0 {
+			minor, patch = minor+1, 0
+		}
+	case VersionBumpPatch:
+		if prerelease == "" {
+			patch++
+		}
+	default:
+		recommendation.NextVersion = previous
+		return recommendation, nil
+	}
+	recommendation.NextVersion = fmt.Sprintf("%s%d.%d.%d", prefix, major, minor, patch)
+	return recommendation, nil
+}
@@ -0,0 +1,86 @@
+package tfdiff
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRecommendVersion(t *testing.T) {
+	breaking := Diff{Level: "variable", Element: "cidr", Compatibility: CompatibilityBreaking, CompatibilityReason: "new variable without a default is required"}
+	compatible := Diff{Level: "output", Element: "vpc", Compatibility: CompatibilityCompatible, CompatibilityReason: "new output"}
+	patch := Diff{Level: "variable", Element: "region", Compatibility: CompatibilityPatch, CompatibilityReason: "description changed"}
+
+	tests := []struct {
+		name     string
+		previous string
+		diffs    []Diff
+		want     string
+		bump     VersionBump
+	}{
+		{name: "breaking", previous: "1.4.2", diffs: []Diff{patch, breaking, compatible}, want: "2.0.0", bump: VersionBumpMajor},
+		{name: "compatible", previous: "v1.4.2", diffs: []Diff{compatible, patch}, want: "v1.5.0", bump: VersionBumpMinor},
+		{name: "patch", previous: "1.4.2", diffs: []Diff{patch}, want: "1.4.3", bump: VersionBumpPatch},
+		{name: "no changes", previous: "1.4.2", want: "1.4.2", bump: VersionBumpNone},
+		{name: "breaking before 1.0.0", previous: "0.3.1", diffs: []Diff{breaking}, want: "0.4.0", bump: VersionBumpMinor},
+		{name: "prerelease of a major version", previous: "2.0.0-rc.1", diffs: []Diff{breaking}, want: "2.0.0", bump: VersionBumpMajor},
+		{name: "prerelease of a patch version", previous: "2.0.1-rc.1+build.5", diffs: []Diff{compatible}, want: "2.1.0", bump: VersionBumpMinor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RecommendVersion(&ComparisonResult{Diffs: tt.diffs}, tt.previous)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.NextVersion != tt.want || got.Bump != tt.bump {
+				t.Errorf("expected %s (%s), got %s (%s)", tt.want, tt.bump, got.NextVersion, got.Bump)
+			}
+			if len(got.Reasons) != len(tt.diffs) {
+				t.Errorf("expected %d reasons, got %d", len(tt.diffs), len(got.Reasons))
+			}
+		})
+	}
+}
+
+func TestRecommendVersion_Reasons(t *testing.T) {
+	result := &ComparisonResult{Diffs: []Diff{
+		{Level: "variable", Element: "cidr", Compatibility: CompatibilityBreaking, CompatibilityReason: "new variable without a default is required"},
+		{Level: "variable", Element: "region", Compatibility: CompatibilityPatch, CompatibilityReason: "description changed"},
+	}}
+
+	recommendation, err := RecommendVersion(result, "0.9.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := json.Marshal(recommendation)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	want := `{"previous_version":"0.9.0","next_version":"0.10.0","bump":"minor","reasons":[` +
+		`{"bump":"minor","level":"variable","element":"cidr","reason":"new variable without a default is required (breaking before 1.0.0)"},` +
+		`{"bump":"patch","level":"variable","element":"region","reason":"description changed"}]}`
+	if string(data) != want {
+		t.Errorf("expected JSON\n%s\ngot\n%s", want, data)
+	}
+
+	output := FormatVersionRecommendation(recommendation, true)
+	for _, line := range []string{
+		"Recommended version: 0.10.0 (minor, previous 0.9.0)",
+		`  minor  variable "cidr": new variable without a default is required (breaking before 1.0.0)`,
+		`  patch  variable "region": description changed`,
+	} {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+}
+
+func TestRecommendVersion_InvalidVersion(t *testing.T) {
+	for _, version := range []string{"", "1.4", "1.04.0", "version1", "1.4.0-"} {
+		if _, err := RecommendVersion(&ComparisonResult{}, version); err == nil {
+			t.Errorf("expected an error for %q", version)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package tfdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// SnapshotSchemaVersion is the version of the snapshot file format written by WriteSnapshot.
+// It is incremented whenever a change to ModuleDefinition would make older snapshots
+// compare differently.
+const SnapshotSchemaVersion = 5
+
+// Snapshot is a parsed module definition serialized for a later comparison
+type Snapshot struct {
+	SchemaVersion int               `json:"schema_version"`
+	ToolVersion   string            `json:"tfdiff_version,omitempty"`
+	Module        *ModuleDefinition `json:"module"`
+}
+
+// isSnapshotPath reports whether a module source is a snapshot file
+func isSnapshotPath(source string) bool {
+	return strings.HasSuffix(strings.ToLower(source), ".json")
+}
+
+// WriteSnapshot writes a module definition as a snapshot
+func WriteSnapshot(w io.Writer, def *ModuleDefinition) error {
+	snapshot := Snapshot{
+		SchemaVersion: SnapshotSchemaVersion,
+		ToolVersion:   Version,
+		Module:        def,
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// ReadSnapshot reads the module definition of a snapshot file. The module path of the result
+// is the path of the snapshot file.
+func ReadSnapshot(snapshotPath string) (*ModuleDefinition, error) {
+	data, err := os.ReadFile(snapshotPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", snapshotPath, err)
+	}
+	if snapshot.SchemaVersion == 0 || snapshot.Module == nil {
+		return nil, fmt.Errorf("%s is not a tfdiff snapshot", snapshotPath)
+	}
+	// Older schemas lack information later versions compare, such as value types and required
+	// providers, so they cannot be upgraded without reporting spurious differences
+	if snapshot.SchemaVersion < SnapshotSchemaVersion {
+		return nil, fmt.Errorf("unsupported snapshot schema version %d in %s (supported: %d): the snapshot was written by %s, regenerate it with `tfdiff snapshot`", snapshot.SchemaVersion, snapshotPath, SnapshotSchemaVersion, snapshotToolVersion(snapshot))
+	}
+	if snapshot.SchemaVersion > SnapshotSchemaVersion {
+		return nil, fmt.Errorf("unsupported snapshot schema version %d in %s (supported: %d): the snapshot was written by %s, upgrade tfdiff to read it", snapshot.SchemaVersion, snapshotPath, SnapshotSchemaVersion, snapshotToolVersion(snapshot))
+	}
+
+	def := snapshot.Module
+	for i := range def.Resources {
+		restoreConfig(def.Resources[i].Config)
+	}
+	for i := range def.DataSources {
+		restoreConfig(def.DataSources[i].Config)
+	}
+	def.Path = snapshotPath
+	return def, nil
+}
+
+// snapshotToolVersion describes the version of tfdiff that wrote a snapshot
+func snapshotToolVersion(snapshot Snapshot) string {
+	if snapshot.ToolVersion == "" {
+		return "another version of tfdiff"
+	}
+	return "tfdiff " + snapshot.ToolVersion
+}
+
+// restoreConfig restores the Go types of the attribute values, nested blocks and labels of a
+// resource or data source configuration, which JSON decodes as generic slices and maps
+func restoreConfig(config map[string]interface{}) {
+	for key, value := range config {
+		if key == "_labels" || key == "_blocks" {
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+		var restored Value
+		if err := json.Unmarshal(data, &restored); err == nil {
+			config[key] = restored
+		}
+	}
+
+	if labels, ok := config["_labels"].([]interface{}); ok {
+		restored := make([]string, 0, len(labels))
+		for _, label := range labels {
+			restored = append(restored, fmt.Sprint(label))
+		}
+		config["_labels"] = restored
+	}
+
+	blocks, ok := config["_blocks"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	restored := make(map[string][]map[string]interface{}, len(blocks))
+	for blockType, value := range blocks {
+		list, _ := value.([]interface{})
+		for _, item := range list {
+			block, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			restoreConfig(block)
+			restored[blockType] = append(restored[blockType], block)
+		}
+	}
+	config["_blocks"] = restored
+}
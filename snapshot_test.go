@@ -0,0 +1,121 @@
+package tfdiff
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	moduleDir := filepath.Join(dir, "production")
+	writeModuleFiles(t, moduleDir, map[string]string{
+		"main.tf": `
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.0.0"
+  cidr    = "10.0.0.0/16"
+}
+
+resource "aws_security_group" "web" {
+  name = "web"
+
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+
+  dynamic "egress" {
+    for_each = var.rules
+    content {
+      from_port = egress.value
+    }
+  }
+}
+
+variable "rules" {
+  type    = list(number)
+  default = [80, 443]
+}
+
+output "sg_id" {
+  value = aws_security_group.web.id
+}
+`,
+	})
+
+	module, err := ParseModuleWithOptions(moduleDir, ParseOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse module: %v", err)
+	}
+
+	snapshotPath := filepath.Join(dir, "production.json")
+	file, err := os.Create(snapshotPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteSnapshot(file, module); err != nil {
+		t.Fatalf("failed to write snapshot: %v", err)
+	}
+	file.Close()
+
+	if err := ValidateModuleSource(snapshotPath); err != nil {
+		t.Fatalf("expected snapshot to be valid: %v", err)
+	}
+	restored, err := ParseModuleSource(snapshotPath, ParseOptions{})
+	if err != nil {
+		t.Fatalf("failed to read snapshot: %v", err)
+	}
+	if restored.Path != snapshotPath {
+		t.Errorf("expected snapshot path as module path, got %s", restored.Path)
+	}
+
+	config := ComparisonConfig{Levels: []ComparisonLevel{ComparisonLevelAll}}
+	if result := CompareModules(module, restored, config); len(result.Diffs) != 0 {
+		t.Errorf("expected snapshot to match the directory, got %+v", result.Diffs)
+	}
+}
+
+func TestReadSnapshot_Invalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		errContains string
+	}{
+		{
+			name:        "newer schema version",
+			content:     `{"schema_version": 99, "module": {"path": "production"}}`,
+			errContains: "unsupported snapshot schema version 99",
+		},
+		{
+			name:        "older schema version",
+			content:     `{"schema_version": 1, "tfdiff_version": "0.5.0", "module": {"path": "production"}}`,
+			errContains: "written by tfdiff 0.5.0, regenerate it with `tfdiff snapshot`",
+		},
+		{
+			name:        "not a snapshot",
+			content:     `{"diffs": []}`,
+			errContains: "is not a tfdiff snapshot",
+		},
+		{
+			name:        "invalid JSON",
+			content:     `{`,
+			errContains: "failed to parse snapshot",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshotPath := filepath.Join(t.TempDir(), "snapshot.json")
+			if err := os.WriteFile(snapshotPath, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := ReadSnapshot(snapshotPath)
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("expected error containing %q, got %v", tt.errContains, err)
+			}
+		})
+	}
+}
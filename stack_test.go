@@ -0,0 +1,175 @@
+package tfdiff
+
+import (
+	"strings"
+	"testing"
+)
+
+const stackComponentsContent = `
+variable "regions" {
+  type = set(string)
+}
+
+component "s3" {
+  for_each = var.regions
+  source   = "./s3"
+
+  inputs = {
+    region = each.value
+    prefix = "app"
+  }
+
+  providers = {
+    aws = provider.aws.configurations[each.value]
+  }
+}
+`
+
+const stackDeploymentsContent = `
+identity_token "aws" {
+  audience = ["aws.workload.identity"]
+}
+
+deployment "production" {
+  inputs = {
+    regions  = ["us-east-1"]
+    role_arn = "arn:aws:iam::123456789012:role/prd"
+    token    = identity_token.aws.jwt
+  }
+}
+
+orchestrate "auto_approve" "safe_plans" {
+  check {
+    condition = context.plan.changes.remove == 0
+    reason    = "Plan removes resources"
+  }
+}
+`
+
+func TestParseStackFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeModuleFiles(t, dir, map[string]string{
+		"components.tfstack.hcl":   stackComponentsContent,
+		"deployments.tfdeploy.hcl": stackDeploymentsContent,
+	})
+
+	if err := ValidateModuleDirectory(dir); err != nil {
+		t.Fatalf("expected stack directory to be valid: %v", err)
+	}
+
+	module, err := ParseModuleHCL(dir)
+	if err != nil {
+		t.Fatalf("failed to parse stack: %v", err)
+	}
+
+	if len(module.Components) != 1 {
+		t.Fatalf("expected 1 component, got %d", len(module.Components))
+	}
+	component := module.Components[0]
+	if component.Source != "./s3" || component.ForEach != "var.regions" {
+		t.Errorf("unexpected component: %+v", component)
+	}
+	if component.Inputs["region"].Source != "each.value" || !component.Inputs["prefix"].Equal(StringValue("app")) {
+		t.Errorf("unexpected component inputs: %v", component.Inputs)
+	}
+	if component.Providers["aws"].Source != "provider.aws.configurations[each.value]" {
+		t.Errorf("unexpected component providers: %v", component.Providers)
+	}
+
+	if len(module.Variables) != 1 || module.Variables[0].Name != "regions" {
+		t.Errorf("expected stack variable regions, got %+v", module.Variables)
+	}
+
+	if len(module.Deployments) != 1 {
+		t.Fatalf("expected 1 deployment, got %d", len(module.Deployments))
+	}
+	inputs := module.Deployments[0].Inputs
+	if inputs["regions"].String() != `["us-east-1"]` || inputs["token"].Source != "identity_token.aws.jwt" {
+		t.Errorf("unexpected deployment inputs: %v", inputs)
+	}
+
+	if len(module.IdentityTokens) != 1 || module.IdentityTokens[0].Audience != `["aws.workload.identity"]` {
+		t.Errorf("unexpected identity tokens: %+v", module.IdentityTokens)
+	}
+	if len(module.OrchestrateRules) != 1 || len(module.OrchestrateRules[0].Checks) != 1 {
+		t.Fatalf("unexpected orchestrate rules: %+v", module.OrchestrateRules)
+	}
+	if module.OrchestrateRules[0].Checks[0].Reason != "Plan removes resources" {
+		t.Errorf("unexpected orchestrate check: %+v", module.OrchestrateRules[0].Checks[0])
+	}
+}
+
+func TestCompareStacks(t *testing.T) {
+	leftDir := t.TempDir()
+	rightDir := t.TempDir()
+
+	writeModuleFiles(t, leftDir, map[string]string{
+		"components.tfstack.hcl":   stackComponentsContent,
+		"deployments.tfdeploy.hcl": stackDeploymentsContent,
+	})
+	writeModuleFiles(t, rightDir, map[string]string{
+		"components.tfstack.hcl": strings.Replace(stackComponentsContent, `prefix = "app"`, `prefix = "svc"`, 1),
+		"deployments.tfdeploy.hcl": strings.Replace(stackDeploymentsContent, `["us-east-1"]`, `["us-east-1", "eu-west-1"]`, 1) + `
+deployment "staging" {
+  inputs = {
+    regions = ["us-east-1"]
+  }
+}
+`,
+	})
+
+	leftDef, err := ParseModuleHCL(leftDir)
+	if err != nil {
+		t.Fatalf("failed to parse left stack: %v", err)
+	}
+	rightDef, err := ParseModuleHCL(rightDir)
+	if err != nil {
+		t.Fatalf("failed to parse right stack: %v", err)
+	}
+
+	config := ComparisonConfig{
+		Levels: []ComparisonLevel{ComparisonLevelComponents, ComparisonLevelDeployments},
+	}
+	result := CompareModules(leftDef, rightDef, config)
+	SortDiffs(result.Diffs)
+
+	expected := []struct {
+		level    string
+		diffType DiffType
+		element  string
+	}{
+		{"component", DiffTypeModified, "s3"},
+		{"deployment", DiffTypeAdded, "staging"},
+		{"deployment", DiffTypeModified, "production"},
+	}
+
+	if len(result.Diffs) != len(expected) {
+		for _, diff := range result.Diffs {
+			t.Logf("Diff: %s %s %s", diff.Type, diff.Level, diff.Element)
+		}
+		t.Fatalf("expected %d diffs, got %d", len(expected), len(result.Diffs))
+	}
+	for i, want := range expected {
+		diff := result.Diffs[i]
+		if diff.Level != want.level || diff.Type != want.diffType || diff.Element != want.element {
+			t.Errorf("diff %d: expected %s %s %s, got %s %s %s", i, want.diffType, want.level, want.element, diff.Type, diff.Level, diff.Element)
+		}
+	}
+
+	output := FormatTextOutput(result, config, true)
+	if !strings.Contains(output, `+   regions[1] = "eu-west-1"`) {
+		t.Errorf("expected deployment input change in output, got:\n%s", output)
+	}
+	if !strings.Contains(output, `+   prefix = "svc"`) {
+		t.Errorf("expected component input change in output, got:\n%s", output)
+	}
+	// Ignoring arguments ignores the inputs of components and deployments alike
+	config.IgnoreArguments = true
+	result = CompareModules(leftDef, rightDef, config)
+	if len(result.Diffs) != 1 || result.Diffs[0].Element != "staging" || result.Diffs[0].Type != DiffTypeAdded {
+		for _, diff := range result.Diffs {
+			t.Logf("Diff: %s %s %s", diff.Type, diff.Level, diff.Element)
+		}
+		t.Errorf("expected only the added staging deployment when ignoring arguments, got %d diffs", len(result.Diffs))
+	}
+}
@@ -0,0 +1,163 @@
+package tfdiff
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const terragruntRootContent = `
+remote_state {
+  backend = "s3"
+  config = {
+    bucket = "tfstate"
+    key    = "${path_relative_to_include()}/terraform.tfstate"
+  }
+}
+
+inputs = {
+  region = "ap-northeast-1"
+}
+`
+
+func TestParseTerragruntFile(t *testing.T) {
+	root := t.TempDir()
+	writeModuleFiles(t, root, map[string]string{
+		"terragrunt.hcl": terragruntRootContent,
+		"app/terragrunt.hcl": `
+include "root" {
+  path = find_in_parent_folders()
+}
+
+terraform {
+  source = "git::https://example.com/modules.git//app?ref=v1.0.0"
+}
+
+dependency "vpc" {
+  config_path = "../vpc"
+}
+
+inputs = {
+  region        = "us-east-1"
+  instance_type = "t3.micro"
+  vpc_id        = dependency.vpc.outputs.vpc_id
+}
+`,
+	})
+
+	dir := filepath.Join(root, "app")
+	if err := ValidateModuleDirectory(dir); err != nil {
+		t.Fatalf("expected terragrunt directory to be valid: %v", err)
+	}
+
+	module, err := ParseModuleHCL(dir)
+	if err != nil {
+		t.Fatalf("failed to parse terragrunt directory: %v", err)
+	}
+
+	config := module.Terragrunt
+	if config == nil {
+		t.Fatal("expected terragrunt configuration to be parsed")
+	}
+	if config.Source != "git::https://example.com/modules.git//app?ref=v1.0.0" {
+		t.Errorf("unexpected source: %s", config.Source)
+	}
+	if !config.Inputs["region"].Equal(StringValue("us-east-1")) {
+		t.Errorf("expected child input to override included input, got %s", config.Inputs["region"])
+	}
+	if config.Inputs["vpc_id"].Source != "dependency.vpc.outputs.vpc_id" {
+		t.Errorf("unexpected vpc_id input: %s", config.Inputs["vpc_id"])
+	}
+	if len(config.Dependencies) != 1 || config.Dependencies[0].ConfigPath != "../vpc" {
+		t.Errorf("unexpected dependencies: %+v", config.Dependencies)
+	}
+	if len(config.Includes) != 1 || !config.Includes[0].Resolved || config.Includes[0].Path != "../terragrunt.hcl" {
+		t.Errorf("unexpected includes: %+v", config.Includes)
+	}
+	if config.RemoteState == nil || config.RemoteState.Backend != "s3" {
+		t.Fatalf("expected remote state from included configuration, got %+v", config.RemoteState)
+	}
+	if !config.RemoteState.Config["bucket"].Equal(StringValue("tfstate")) {
+		t.Errorf("unexpected remote state config: %v", config.RemoteState.Config)
+	}
+}
+
+func TestCompareTerragrunt(t *testing.T) {
+	leftDir := t.TempDir()
+	rightDir := t.TempDir()
+
+	writeModuleFiles(t, leftDir, map[string]string{
+		"terragrunt.hcl": `
+terraform {
+  source = "../modules/app"
+}
+
+dependency "vpc" {
+  config_path = "../vpc"
+}
+
+inputs = {
+  env           = "staging"
+  instance_type = "t3.micro"
+}
+`,
+	})
+	writeModuleFiles(t, rightDir, map[string]string{
+		"terragrunt.hcl": `
+terraform {
+  source = "../modules/app"
+}
+
+dependency "network" {
+  config_path = "../network"
+}
+
+inputs = {
+  env           = "production"
+  instance_type = "t3.micro"
+  replicas      = 3
+}
+`,
+	})
+
+	leftDef, err := ParseModuleHCL(leftDir)
+	if err != nil {
+		t.Fatalf("failed to parse left directory: %v", err)
+	}
+	rightDef, err := ParseModuleHCL(rightDir)
+	if err != nil {
+		t.Fatalf("failed to parse right directory: %v", err)
+	}
+
+	config := ComparisonConfig{Levels: []ComparisonLevel{ComparisonLevelTerragrunt}}
+	result := CompareModules(leftDef, rightDef, config)
+	SortDiffs(result.Diffs)
+
+	expected := []struct {
+		diffType DiffType
+		element  string
+	}{
+		{DiffTypeAdded, "dependency.network"},
+		{DiffTypeAdded, "inputs.replicas"},
+		{DiffTypeModified, "inputs.env"},
+		{DiffTypeRemoved, "dependency.vpc"},
+	}
+
+	if len(result.Diffs) != len(expected) {
+		for _, diff := range result.Diffs {
+			t.Logf("Diff: %s %s %s", diff.Type, diff.Level, diff.Element)
+		}
+		t.Fatalf("expected %d diffs, got %d", len(expected), len(result.Diffs))
+	}
+	for i, want := range expected {
+		diff := result.Diffs[i]
+		if diff.Level != "terragrunt" || diff.Type != want.diffType || diff.Element != want.element {
+			t.Errorf("diff %d: expected %s %s, got %s %s", i, want.diffType, want.element, diff.Type, diff.Element)
+		}
+	}
+
+	output := FormatTextOutput(result, config, true)
+	if !strings.Contains(output, " inputs {\n-  env = \"staging\"\n+  env = \"production\"\n }") {
+		t.Errorf("expected input change in output, got:\n%s", output)
+	}
+}
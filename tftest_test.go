@@ -0,0 +1,248 @@
+package tfdiff
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeModuleFiles writes the given files (relative path -> content) into dir
+func writeModuleFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory for %s: %v", path, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+}
+
+const testFileContent = `
+variables {
+  bucket_prefix = "test"
+}
+
+mock_provider "aws" {
+  mock_resource "aws_s3_bucket" {
+    defaults = {
+      arn = "arn:aws:s3:::test"
+    }
+  }
+}
+
+run "setup" {
+  command = plan
+
+  module {
+    source = "./tests/setup"
+  }
+}
+
+run "bucket_name" {
+  variables {
+    bucket_name = "logs"
+  }
+
+  assert {
+    condition     = aws_s3_bucket.this.bucket == "test-logs"
+    error_message = "invalid bucket name"
+  }
+
+  assert {
+    condition     = length(aws_s3_bucket.this.tags) > 0
+    error_message = "tags are required"
+  }
+}
+`
+
+func TestParseTestFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeModuleFiles(t, dir, map[string]string{
+		"main.tf":                   `resource "aws_s3_bucket" "this" {}`,
+		"tests/main.tftest.hcl":     testFileContent,
+		"root.tftest.hcl":           `run "smoke" {}`,
+		"tests/ignored.tftest.json": `{}`,
+	})
+
+	module, err := ParseModuleHCL(dir)
+	if err != nil {
+		t.Fatalf("failed to parse module: %v", err)
+	}
+
+	if len(module.TestFiles) != 2 {
+		t.Fatalf("expected 2 test files, got %d", len(module.TestFiles))
+	}
+	if module.TestFiles[0].Path != "root.tftest.hcl" || module.TestFiles[1].Path != "tests/main.tftest.hcl" {
+		t.Fatalf("unexpected test file paths: %s, %s", module.TestFiles[0].Path, module.TestFiles[1].Path)
+	}
+
+	testFile := module.TestFiles[1]
+	if !testFile.Variables["bucket_prefix"].Equal(StringValue("test")) {
+		t.Errorf("expected file variable bucket_prefix=test, got %s", testFile.Variables["bucket_prefix"])
+	}
+	if len(testFile.Providers) != 1 || !testFile.Providers[0].Mock || testFile.Providers[0].Name != "aws" {
+		t.Fatalf("expected one aws mock provider, got %+v", testFile.Providers)
+	}
+	if len(testFile.Providers[0].Mocks) != 1 || testFile.Providers[0].Mocks[0] != "mock_resource.aws_s3_bucket" {
+		t.Errorf("unexpected mocks: %v", testFile.Providers[0].Mocks)
+	}
+
+	if len(testFile.Runs) != 2 {
+		t.Fatalf("expected 2 runs, got %d", len(testFile.Runs))
+	}
+	setup := testFile.Runs[0]
+	if setup.Command != "plan" || setup.Module != "./tests/setup" {
+		t.Errorf("unexpected setup run: %+v", setup)
+	}
+	bucket := testFile.Runs[1]
+	if bucket.Command != "apply" {
+		t.Errorf("expected default command apply, got %s", bucket.Command)
+	}
+	if !bucket.Variables["bucket_name"].Equal(StringValue("logs")) {
+		t.Errorf("expected run variable bucket_name=logs, got %s", bucket.Variables["bucket_name"])
+	}
+	if len(bucket.Assertions) != 2 {
+		t.Fatalf("expected 2 assertions, got %d", len(bucket.Assertions))
+	}
+	if bucket.Assertions[0].Condition != `aws_s3_bucket.this.bucket == "test-logs"` {
+		t.Errorf("unexpected condition: %s", bucket.Assertions[0].Condition)
+	}
+	if bucket.Assertions[0].ErrorMessage != "invalid bucket name" {
+		t.Errorf("unexpected error message: %s", bucket.Assertions[0].ErrorMessage)
+	}
+}
+
+func TestCompareTestFiles(t *testing.T) {
+	leftDir := t.TempDir()
+	rightDir := t.TempDir()
+
+	weakened := strings.Replace(testFileContent, `
+  assert {
+    condition     = length(aws_s3_bucket.this.tags) > 0
+    error_message = "tags are required"
+  }
+`, "", 1)
+
+	writeModuleFiles(t, leftDir, map[string]string{
+		"main.tf":               `resource "aws_s3_bucket" "this" {}`,
+		"tests/main.tftest.hcl": testFileContent,
+		"tests/old.tftest.hcl":  `run "legacy" {}`,
+	})
+	writeModuleFiles(t, rightDir, map[string]string{
+		"main.tf":               `resource "aws_s3_bucket" "this" {}`,
+		"tests/main.tftest.hcl": weakened,
+	})
+
+	leftDef, err := ParseModuleHCL(leftDir)
+	if err != nil {
+		t.Fatalf("failed to parse left module: %v", err)
+	}
+	rightDef, err := ParseModuleHCL(rightDir)
+	if err != nil {
+		t.Fatalf("failed to parse right module: %v", err)
+	}
+
+	config := ComparisonConfig{Levels: []ComparisonLevel{ComparisonLevelTests}}
+	result := CompareModules(leftDef, rightDef, config)
+	SortDiffs(result.Diffs)
+
+	if len(result.Diffs) != 2 {
+		for _, diff := range result.Diffs {
+			t.Logf("Diff: %s %s %s", diff.Type, diff.Level, diff.Element)
+		}
+		t.Fatalf("expected 2 diffs, got %d", len(result.Diffs))
+	}
+
+	if diff := result.Diffs[0]; diff.Level != "test_file" || diff.Type != DiffTypeRemoved || diff.Element != "tests/old.tftest.hcl" {
+		t.Errorf("unexpected first diff: %s %s %s", diff.Type, diff.Level, diff.Element)
+	}
+	if diff := result.Diffs[1]; diff.Level != "test_run" || diff.Type != DiffTypeModified || diff.Element != "tests/main.tftest.hcl:run.bucket_name" {
+		t.Errorf("unexpected second diff: %s %s %s", diff.Type, diff.Level, diff.Element)
+	}
+
+	output := FormatTextOutput(result, config, true)
+	if !strings.Contains(output, `-   [1] = {"condition":"length(aws_s3_bucket.this.tags) > 0"`) {
+		t.Errorf("expected removed assertion in output, got:\n%s", output)
+	}
+}
+
+func TestTestRunsEqual_AssertionOrder(t *testing.T) {
+	left := TestRun{
+		Name:    "check",
+		Command: "apply",
+		Assertions: []TestAssertion{
+			{Condition: "a == 1"},
+			{Condition: "b == 2"},
+		},
+	}
+	right := TestRun{
+		Name:    "check",
+		Command: "apply",
+		Assertions: []TestAssertion{
+			{Condition: "b == 2"},
+			{Condition: "a == 1"},
+		},
+	}
+
+	if !testRunsEqual(left, right) {
+		t.Error("expected runs with reordered assertions to be equal")
+	}
+
+	right.Assertions[0].Condition = "b == 3"
+	if testRunsEqual(left, right) {
+		t.Error("expected runs with different assertions to differ")
+	}
+}
+
+func TestTestFilesEqual_ProviderOrder(t *testing.T) {
+	left := TestFile{
+		Path: "main.tftest.hcl",
+		Providers: []TestProvider{
+			{Name: "aws"},
+			{Name: "aws", Alias: "east"},
+			{Name: "random", Mock: true},
+		},
+	}
+	right := TestFile{
+		Path: "main.tftest.hcl",
+		Providers: []TestProvider{
+			{Name: "random", Mock: true},
+			{Name: "aws", Alias: "east"},
+			{Name: "aws"},
+		},
+	}
+
+	if !testFilesEqual(left, right) {
+		t.Error("expected test files with reordered providers to be equal")
+	}
+
+	right.Providers[1].Mock = true
+	if testFilesEqual(left, right) {
+		t.Error("expected test files with different providers to differ")
+	}
+}
+
+func TestParseTestFiles_SkipTests(t *testing.T) {
+	dir := t.TempDir()
+	writeModuleFiles(t, dir, map[string]string{
+		"main.tf":                 `resource "aws_s3_bucket" "this" {}`,
+		"tests/broken.tftest.hcl": `run "broken" {`,
+	})
+
+	if _, err := ParseModuleWithOptions(dir, ParseOptions{}); err == nil {
+		t.Error("expected an error for the malformed test file")
+	}
+
+	module, err := ParseModuleWithOptions(dir, ParseOptions{SkipTests: true})
+	if err != nil {
+		t.Fatalf("expected the test files to be skipped, got: %v", err)
+	}
+	if len(module.Resources) != 1 || len(module.TestFiles) != 0 {
+		t.Errorf("unexpected module: %+v", module)
+	}
+}
@@ -0,0 +1,143 @@
+package tfdiff
+
+// CompareThreeWay compares two modules forked from a common base. Each side is compared
+// against the base with CompareModules, and every changed element and attribute is
+// classified as changed on the left only, on the right only, on both sides identically,
+// or as a conflict.
+func CompareThreeWay(base, left, right *ModuleDefinition, config ComparisonConfig) *ThreeWayResult {
+	leftResult := CompareModules(base, left, config)
+	rightResult := CompareModules(base, right, config)
+	SortDiffs(leftResult.Diffs)
+	SortDiffs(rightResult.Diffs)
+
+	result := &ThreeWayResult{
+		BasePath:   base.Path,
+		LeftPath:   left.Path,
+		RightPath:  right.Path,
+		LeftDiffs:  leftResult.Diffs,
+		RightDiffs: rightResult.Diffs,
+		Changes:    []ThreeWayChange{},
+	}
+
+	matrix := BuildDriftMatrix([]*ModuleDefinition{base, left, right}, config)
+	for _, element := range matrix.Elements {
+		if !element.Differs {
+			continue
+		}
+		result.Changes = append(result.Changes, classifyElement(element)...)
+	}
+
+	for _, change := range result.Changes {
+		switch change.Class {
+		case ThreeWayLeftOnly:
+			result.Summary.LeftOnly++
+		case ThreeWayRightOnly:
+			result.Summary.RightOnly++
+		case ThreeWayBothIdentical:
+			result.Summary.BothIdentical++
+		case ThreeWayConflict:
+			result.Summary.Conflicts++
+		}
+	}
+
+	return result
+}
+
+// classifyElement classifies the changes of one element of a base/left/right drift matrix
+func classifyElement(element MatrixElement) []ThreeWayChange {
+	presence := make([]interface{}, 3)
+	for i, present := range element.Present {
+		if present {
+			presence[i] = elementPresent
+		}
+	}
+	elementChange := func(class ThreeWayClass) []ThreeWayChange {
+		return []ThreeWayChange{{
+			Class:   class,
+			Level:   element.Level,
+			Element: element.Element,
+			Base:    presence[0],
+			Left:    presence[1],
+			Right:   presence[2],
+		}}
+	}
+
+	base, left, right := element.Present[0], element.Present[1], element.Present[2]
+	switch {
+	case !base && left && !right:
+		return elementChange(ThreeWayLeftOnly)
+	case !base && !left && right:
+		return elementChange(ThreeWayRightOnly)
+	case base && !left && !right:
+		return elementChange(ThreeWayBothIdentical)
+	case base && !left && right:
+		// Removed on the left; a conflict if the right side modified it
+		if attributesChanged(element, 0, 2) {
+			return elementChange(ThreeWayConflict)
+		}
+		return elementChange(ThreeWayLeftOnly)
+	case base && left && !right:
+		if attributesChanged(element, 0, 1) {
+			return elementChange(ThreeWayConflict)
+		}
+		return elementChange(ThreeWayRightOnly)
+	case !base && left && right:
+		// Added on both sides; identical unless any attribute differs between them
+		var changes []ThreeWayChange
+		for _, attribute := range element.Attributes {
+			if !matrixValuesEqual([]interface{}{attribute.Values[1], attribute.Values[2]}) {
+				changes = append(changes, attributeChange(element, attribute, ThreeWayConflict))
+			}
+		}
+		if len(changes) == 0 {
+			return elementChange(ThreeWayBothIdentical)
+		}
+		return changes
+	}
+
+	// Present on all sides: classify each attribute
+	var changes []ThreeWayChange
+	for _, attribute := range element.Attributes {
+		if !attribute.Differs {
+			continue
+		}
+		values := attribute.Values
+		leftChanged := !matrixValuesEqual([]interface{}{values[0], values[1]})
+		rightChanged := !matrixValuesEqual([]interface{}{values[0], values[2]})
+
+		switch {
+		case leftChanged && !rightChanged:
+			changes = append(changes, attributeChange(element, attribute, ThreeWayLeftOnly))
+		case !leftChanged && rightChanged:
+			changes = append(changes, attributeChange(element, attribute, ThreeWayRightOnly))
+		case matrixValuesEqual([]interface{}{values[1], values[2]}):
+			changes = append(changes, attributeChange(element, attribute, ThreeWayBothIdentical))
+		default:
+			changes = append(changes, attributeChange(element, attribute, ThreeWayConflict))
+		}
+	}
+	return changes
+}
+
+// attributeChange builds the three-way change of one attribute
+func attributeChange(element MatrixElement, attribute MatrixAttribute, class ThreeWayClass) ThreeWayChange {
+	return ThreeWayChange{
+		Class:     class,
+		Level:     element.Level,
+		Element:   element.Element,
+		Attribute: attribute.Path,
+		Base:      attribute.Values[0],
+		Left:      attribute.Values[1],
+		Right:     attribute.Values[2],
+	}
+}
+
+// attributesChanged reports whether any attribute of the element differs between two columns
+func attributesChanged(element MatrixElement, from, to int) bool {
+	for _, attribute := range element.Attributes {
+		if !matrixValuesEqual([]interface{}{attribute.Values[from], attribute.Values[to]}) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package tfdiff
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompareThreeWay(t *testing.T) {
+	root := t.TempDir()
+	base := `
+resource "aws_instance" "web" {
+  ami           = "ami-1"
+  instance_type = "t3.micro"
+  monitoring    = false
+}
+
+resource "aws_s3_bucket" "old" {
+  bucket = "old"
+}
+
+resource "aws_s3_bucket" "shared" {
+  bucket = "shared"
+}
+`
+	left := `
+resource "aws_instance" "web" {
+  ami           = "ami-2"
+  instance_type = "t3.large"
+  monitoring    = false
+}
+
+resource "aws_s3_bucket" "shared" {
+  bucket = "shared"
+}
+
+resource "aws_sqs_queue" "jobs" {
+  name = "jobs"
+}
+`
+	right := `
+resource "aws_instance" "web" {
+  ami           = "ami-2"
+  instance_type = "t3.medium"
+  monitoring    = true
+}
+
+resource "aws_s3_bucket" "old" {
+  bucket = "old"
+}
+
+resource "aws_s3_bucket" "shared" {
+  bucket = "shared-renamed"
+}
+
+resource "aws_sqs_queue" "jobs" {
+  name = "jobs"
+}
+`
+
+	var modules []*ModuleDefinition
+	for name, content := range map[string]string{"base": base, "left": left, "right": right} {
+		writeModuleFiles(t, filepath.Join(root, name), map[string]string{"main.tf": content})
+	}
+	for _, name := range []string{"base", "left", "right"} {
+		module, err := ParseModuleHCL(filepath.Join(root, name))
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", name, err)
+		}
+		modules = append(modules, module)
+	}
+
+	config := ComparisonConfig{Levels: []ComparisonLevel{ComparisonLevelResources}}
+	result := CompareThreeWay(modules[0], modules[1], modules[2], config)
+
+	expected := []struct {
+		class     ThreeWayClass
+		element   string
+		attribute string
+	}{
+		{ThreeWayBothIdentical, "aws_instance.web", "ami"},
+		{ThreeWayConflict, "aws_instance.web", "instance_type"},
+		{ThreeWayRightOnly, "aws_instance.web", "monitoring"},
+		{ThreeWayLeftOnly, "aws_s3_bucket.old", ""},
+		{ThreeWayRightOnly, "aws_s3_bucket.shared", "bucket"},
+		{ThreeWayBothIdentical, "aws_sqs_queue.jobs", ""},
+	}
+
+	if len(result.Changes) != len(expected) {
+		for _, change := range result.Changes {
+			t.Logf("Change: %+v", change)
+		}
+		t.Fatalf("expected %d changes, got %d", len(expected), len(result.Changes))
+	}
+	for i, want := range expected {
+		change := result.Changes[i]
+		if change.Class != want.class || change.Element != want.element || change.Attribute != want.attribute {
+			t.Errorf("change %d: expected %s %s %s, got %s %s %s", i, want.class, want.element, want.attribute, change.Class, change.Element, change.Attribute)
+		}
+	}
+
+	if result.Summary.Conflicts != 1 || result.Summary.LeftOnly != 1 || result.Summary.RightOnly != 2 || result.Summary.BothIdentical != 2 {
+		t.Errorf("unexpected summary: %+v", result.Summary)
+	}
+	if len(result.LeftDiffs) != 3 || len(result.RightDiffs) != 3 {
+		t.Errorf("expected 3 diffs per side, got %d and %d", len(result.LeftDiffs), len(result.RightDiffs))
+	}
+
+	output := FormatThreeWayOutput(result, true)
+	if !strings.Contains(output, `! resource aws_instance.web instance_type: base "t3.micro", left "t3.large", right "t3.medium"`) {
+		t.Errorf("expected conflict in output, got:\n%s", output)
+	}
+}
+
+func TestCompareThreeWay_RemovedAndModified(t *testing.T) {
+	base := &ModuleDefinition{Path: "base", Resources: []Resource{
+		{Type: "aws_s3_bucket", Name: "logs", Config: map[string]interface{}{"bucket": "logs"}},
+	}}
+	left := &ModuleDefinition{Path: "left"}
+	right := &ModuleDefinition{Path: "right", Resources: []Resource{
+		{Type: "aws_s3_bucket", Name: "logs", Config: map[string]interface{}{"bucket": "access-logs"}},
+	}}
+
+	config := ComparisonConfig{Levels: []ComparisonLevel{ComparisonLevelResources}}
+	result := CompareThreeWay(base, left, right, config)
+
+	if len(result.Changes) != 1 || result.Changes[0].Class != ThreeWayConflict || result.Changes[0].Attribute != "" {
+		t.Errorf("expected a removed/modified conflict, got %+v", result.Changes)
+	}
+}
@@ -0,0 +1,142 @@
+package tfdiff
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FindModuleDirectories walks a directory tree and returns the paths, relative to root,
+// of every directory containing Terraform files. Hidden directories such as .terraform
+// and .terragrunt-cache are skipped.
+func FindModuleDirectories(root string) ([]string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", root)
+	}
+
+	var dirs []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		module, err := osModuleFS(path)
+		if err != nil {
+			return err
+		}
+		found, err := containsModuleFiles(module)
+		if err != nil {
+			return err
+		}
+		if found {
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			dirs = append(dirs, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// CompareTrees discovers module directories under two tree roots, pairs them by relative
+// path and compares each pair. Directories present on only one side are reported as
+// added or removed directories.
+func CompareTrees(leftRoot, rightRoot string, options ParseOptions, config ComparisonConfig) (*TreeComparisonResult, error) {
+	leftDirs, err := FindModuleDirectories(leftRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover left modules: %w", err)
+	}
+	rightDirs, err := FindModuleDirectories(rightRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover right modules: %w", err)
+	}
+
+	result := &TreeComparisonResult{
+		LeftPath:    leftRoot,
+		RightPath:   rightRoot,
+		Directories: []Diff{},
+		Results:     []*ComparisonResult{},
+	}
+
+	leftSet := make(map[string]bool)
+	rightSet := make(map[string]bool)
+	for _, dir := range leftDirs {
+		leftSet[dir] = true
+	}
+	for _, dir := range rightDirs {
+		rightSet[dir] = true
+	}
+
+	// Find added directories
+	for _, dir := range rightDirs {
+		if !leftSet[dir] {
+			result.Directories = append(result.Directories, Diff{
+				Type:    DiffTypeAdded,
+				Level:   "directory",
+				Element: dir,
+				After:   filepath.Join(rightRoot, dir),
+				Message: fmt.Sprintf("Directory '%s' was added", dir),
+			})
+		}
+	}
+
+	// Find removed directories
+	for _, dir := range leftDirs {
+		if !rightSet[dir] {
+			result.Directories = append(result.Directories, Diff{
+				Type:    DiffTypeRemoved,
+				Level:   "directory",
+				Element: dir,
+				Before:  filepath.Join(leftRoot, dir),
+				Message: fmt.Sprintf("Directory '%s' was removed", dir),
+			})
+		}
+	}
+
+	// Compare directories present on both sides
+	for _, dir := range leftDirs {
+		if !rightSet[dir] {
+			continue
+		}
+		leftModule, err := ParseModuleWithOptions(filepath.Join(leftRoot, dir), options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse left module %s: %w", dir, err)
+		}
+		rightModule, err := ParseModuleWithOptions(filepath.Join(rightRoot, dir), options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse right module %s: %w", dir, err)
+		}
+		comparison := CompareModules(leftModule, rightModule, config)
+		SortDiffs(comparison.Diffs)
+		result.Results = append(result.Results, comparison)
+	}
+
+	// Calculate summary over directories and all module diffs
+	allDiffs := append([]Diff{}, result.Directories...)
+	for _, comparison := range result.Results {
+		allDiffs = append(allDiffs, comparison.Diffs...)
+	}
+	result.Summary = summarizeDiffs(allDiffs)
+
+	return result, nil
+}
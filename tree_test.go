@@ -0,0 +1,87 @@
+package tfdiff
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindModuleDirectories(t *testing.T) {
+	root := t.TempDir()
+	writeModuleFiles(t, root, map[string]string{
+		"main.tf":                         `resource "null_resource" "root" {}`,
+		"env/staging/app/main.tf":         `resource "null_resource" "app" {}`,
+		"env/staging/db/terragrunt.hcl":   `inputs = {}`,
+		"env/staging/README.md":           `docs`,
+		"env/staging/app/.terraform/x.tf": `resource "null_resource" "cached" {}`,
+		"stacks/web/web.tfstack.hcl":      `component "web" { source = "./web" }`,
+	})
+
+	dirs, err := FindModuleDirectories(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{".", "env/staging/app", "env/staging/db", "stacks/web"}
+	if !reflect.DeepEqual(dirs, expected) {
+		t.Errorf("expected %v, got %v", expected, dirs)
+	}
+}
+
+func TestCompareTrees(t *testing.T) {
+	tmpDir := t.TempDir()
+	leftRoot := filepath.Join(tmpDir, "staging")
+	rightRoot := filepath.Join(tmpDir, "production")
+
+	writeModuleFiles(t, leftRoot, map[string]string{
+		"app/main.tf":    `resource "aws_instance" "web" {}`,
+		"db/main.tf":     `resource "aws_db_instance" "main" {}`,
+		"legacy/main.tf": `resource "aws_instance" "old" {}`,
+	})
+	writeModuleFiles(t, rightRoot, map[string]string{
+		"app/main.tf":     `resource "aws_instance" "web" {}`,
+		"db/main.tf":      `resource "aws_rds_cluster" "main" {}`,
+		"network/main.tf": `resource "aws_vpc" "main" {}`,
+	})
+
+	config := ComparisonConfig{Levels: []ComparisonLevel{ComparisonLevelResources}}
+	result, err := CompareTrees(leftRoot, rightRoot, ParseOptions{}, config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Directories) != 2 {
+		t.Fatalf("expected 2 directory diffs, got %d", len(result.Directories))
+	}
+	if result.Directories[0].Type != DiffTypeAdded || result.Directories[0].Element != "network" {
+		t.Errorf("expected network directory to be added, got %s %s", result.Directories[0].Type, result.Directories[0].Element)
+	}
+	if result.Directories[1].Type != DiffTypeRemoved || result.Directories[1].Element != "legacy" {
+		t.Errorf("expected legacy directory to be removed, got %s %s", result.Directories[1].Type, result.Directories[1].Element)
+	}
+
+	if len(result.Results) != 2 {
+		t.Fatalf("expected 2 paired results, got %d", len(result.Results))
+	}
+	if len(result.Results[0].Diffs) != 0 {
+		t.Errorf("expected no diffs for app, got %d", len(result.Results[0].Diffs))
+	}
+	if len(result.Results[1].Diffs) != 2 {
+		t.Errorf("expected 2 diffs for db, got %d", len(result.Results[1].Diffs))
+	}
+
+	if result.Summary.Added != 2 || result.Summary.Removed != 2 || result.Summary.Total != 4 {
+		t.Errorf("unexpected summary: %+v", result.Summary)
+	}
+
+	output := FormatTreeOutput(result, config, true)
+	for _, want := range []string{`+directory "network"`, `-directory "legacy"`, `+resource "aws_rds_cluster" "main" {`} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, filepath.Join(leftRoot, "app")) {
+		t.Errorf("expected directories without differences to be omitted, got:\n%s", output)
+	}
+}
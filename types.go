@@ -5,7 +5,7 @@ type ModuleCall struct {
 	Name     string            `json:"name"`
 	Source   string            `json:"source"`
 	Version  string            `json:"version,omitempty"`
-	Args     map[string]string `json:"args,omitempty"`
+	Args     map[string]Value  `json:"args,omitempty"`
 	Position string            `json:"position,omitempty"`
 }
 
@@ -18,7 +18,9 @@ type Output struct {
 	Position    string `json:"position,omitempty"`
 }
 
-// Resource represents a Terraform resource
+// Resource represents a Terraform resource. Config holds the attributes as Values, the
+// nested blocks by type under the "_blocks" key and the labels of a nested block under the
+// "_labels" key.
 type Resource struct {
 	Type      string                 `json:"type"`
 	Name      string                 `json:"name"`
@@ -39,36 +41,190 @@ type Variable struct {
 	Name         string `json:"name"`
 	Type         string `json:"type,omitempty"`
 	Description  string `json:"description,omitempty"`
-	DefaultValue string `json:"default_value,omitempty"`
-	Position     string `json:"position,omitempty"`
+	DefaultValue *Value `json:"default_value,omitempty"`
+	// Nullable is unset when the variable does not set nullable, which makes it nullable
+	Nullable    *bool                `json:"nullable,omitempty"`
+	Sensitive   bool                 `json:"sensitive,omitempty"`
+	Validations []VariableValidation `json:"validations,omitempty"`
+	Position    string               `json:"position,omitempty"`
+}
+
+// IsNullable reports whether the variable accepts null, which it does unless nullable is false
+func (v Variable) IsNullable() bool {
+	return v.Nullable == nil || *v.Nullable
+}
+
+// VariableValidation represents a validation block in a variable block
+type VariableValidation struct {
+	Condition    string `json:"condition"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// RequiredProvider represents a provider requirement in the required_providers block of a
+// terraform block
+type RequiredProvider struct {
+	Name                 string   `json:"name"`
+	Source               string   `json:"source,omitempty"`
+	Version              string   `json:"version,omitempty"`
+	ConfigurationAliases []string `json:"configuration_aliases,omitempty"`
+	Position             string   `json:"position,omitempty"`
+}
+
+// TestFile represents a Terraform test file (*.tftest.hcl)
+type TestFile struct {
+	Path      string           `json:"path"`
+	Variables map[string]Value `json:"variables,omitempty"`
+	Providers []TestProvider   `json:"providers,omitempty"`
+	Runs      []TestRun        `json:"runs,omitempty"`
+}
+
+// TestProvider represents a provider or mock_provider block in a test file
+type TestProvider struct {
+	Name  string   `json:"name"`
+	Alias string   `json:"alias,omitempty"`
+	Mock  bool     `json:"mock,omitempty"`
+	Mocks []string `json:"mocks,omitempty"`
+}
+
+// TestRun represents a run block in a test file
+type TestRun struct {
+	Name           string           `json:"name"`
+	Command        string           `json:"command,omitempty"`
+	Module         string           `json:"module,omitempty"`
+	Variables      map[string]Value `json:"variables,omitempty"`
+	ExpectFailures []string         `json:"expect_failures,omitempty"`
+	Assertions     []TestAssertion  `json:"assertions,omitempty"`
+	Position       string           `json:"position,omitempty"`
+}
+
+// TestAssertion represents an assert block in a run block
+type TestAssertion struct {
+	Condition    string `json:"condition"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// Component represents a component block in a Terraform Stacks configuration (*.tfstack.hcl)
+type Component struct {
+	Name      string           `json:"name"`
+	Source    string           `json:"source"`
+	Version   string           `json:"version,omitempty"`
+	ForEach   string           `json:"for_each,omitempty"`
+	Inputs    map[string]Value `json:"inputs,omitempty"`
+	Providers map[string]Value `json:"providers,omitempty"`
+	Position  string           `json:"position,omitempty"`
+}
+
+// Deployment represents a deployment block in a Terraform Stacks deployment file (*.tfdeploy.hcl)
+type Deployment struct {
+	Name     string           `json:"name"`
+	Inputs   map[string]Value `json:"inputs,omitempty"`
+	Position string           `json:"position,omitempty"`
+}
+
+// OrchestrateRule represents an orchestrate block in a Terraform Stacks deployment file
+type OrchestrateRule struct {
+	Type     string             `json:"type"`
+	Name     string             `json:"name"`
+	Checks   []OrchestrateCheck `json:"checks,omitempty"`
+	Position string             `json:"position,omitempty"`
+}
+
+// OrchestrateCheck represents a check block in an orchestrate rule
+type OrchestrateCheck struct {
+	Condition string `json:"condition"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// IdentityToken represents an identity_token block in a Terraform Stacks deployment file
+type IdentityToken struct {
+	Name     string `json:"name"`
+	Audience string `json:"audience,omitempty"`
+	Position string `json:"position,omitempty"`
+}
+
+// TerragruntConfig represents a terragrunt.hcl configuration with its local includes merged
+type TerragruntConfig struct {
+	Source       string                 `json:"source,omitempty"`
+	Inputs       map[string]Value       `json:"inputs,omitempty"`
+	Dependencies []TerragruntDependency `json:"dependencies,omitempty"`
+	Includes     []TerragruntInclude    `json:"includes,omitempty"`
+	RemoteState  *TerragruntRemoteState `json:"remote_state,omitempty"`
+}
+
+// TerragruntDependency represents a dependency block in a Terragrunt configuration
+type TerragruntDependency struct {
+	Name       string `json:"name"`
+	ConfigPath string `json:"config_path"`
+	Position   string `json:"position,omitempty"`
+}
+
+// TerragruntInclude represents an include block in a Terragrunt configuration
+type TerragruntInclude struct {
+	Name     string `json:"name,omitempty"`
+	Path     string `json:"path"`
+	Resolved bool   `json:"resolved"`
+}
+
+// TerragruntRemoteState represents a remote_state block in a Terragrunt configuration
+type TerragruntRemoteState struct {
+	Backend string           `json:"backend"`
+	Config  map[string]Value `json:"config,omitempty"`
 }
 
 // ModuleDefinition represents the complete definition of a Terraform module
 type ModuleDefinition struct {
-	Path        string            `json:"path"`
-	ModuleCalls []ModuleCall      `json:"module_calls,omitempty"`
-	Outputs     []Output          `json:"outputs,omitempty"`
-	Resources   []Resource        `json:"resources,omitempty"`
-	DataSources []DataSource      `json:"data_sources,omitempty"`
-	Variables   []Variable        `json:"variables,omitempty"`
+	Path              string             `json:"path"`
+	ModuleCalls       []ModuleCall       `json:"module_calls,omitempty"`
+	Outputs           []Output           `json:"outputs,omitempty"`
+	Resources         []Resource         `json:"resources,omitempty"`
+	DataSources       []DataSource       `json:"data_sources,omitempty"`
+	Variables         []Variable         `json:"variables,omitempty"`
+	RequiredProviders []RequiredProvider `json:"required_providers,omitempty"`
+	TestFiles         []TestFile         `json:"test_files,omitempty"`
+	Components        []Component        `json:"components,omitempty"`
+	Deployments       []Deployment       `json:"deployments,omitempty"`
+	OrchestrateRules  []OrchestrateRule  `json:"orchestrate_rules,omitempty"`
+	IdentityTokens    []IdentityToken    `json:"identity_tokens,omitempty"`
+	Terragrunt        *TerragruntConfig  `json:"terragrunt,omitempty"`
 }
 
 // ComparisonLevel defines what elements to compare
 type ComparisonLevel string
 
 const (
-	ComparisonLevelModuleCalls ComparisonLevel = "module_calls"
-	ComparisonLevelOutputs     ComparisonLevel = "outputs"
-	ComparisonLevelResources   ComparisonLevel = "resources"
-	ComparisonLevelDataSources ComparisonLevel = "data_sources"
-	ComparisonLevelVariables   ComparisonLevel = "variables"
-	ComparisonLevelAll         ComparisonLevel = "all"
+	ComparisonLevelModuleCalls       ComparisonLevel = "module_calls"
+	ComparisonLevelOutputs           ComparisonLevel = "outputs"
+	ComparisonLevelResources         ComparisonLevel = "resources"
+	ComparisonLevelDataSources       ComparisonLevel = "data_sources"
+	ComparisonLevelVariables         ComparisonLevel = "variables"
+	ComparisonLevelRequiredProviders ComparisonLevel = "required_providers"
+	ComparisonLevelTests             ComparisonLevel = "tests"
+	ComparisonLevelComponents        ComparisonLevel = "components"
+	ComparisonLevelDeployments       ComparisonLevel = "deployments"
+	ComparisonLevelTerragrunt        ComparisonLevel = "terragrunt"
+	ComparisonLevelAll               ComparisonLevel = "all"
 )
 
 // ComparisonConfig defines configuration for comparison
 type ComparisonConfig struct {
 	Levels          []ComparisonLevel `json:"levels"`
 	IgnoreArguments bool              `json:"ignore_arguments"`
+	Substitutions   []Substitution    `json:"substitutions,omitempty"`
+	// IgnoreAttributes excludes attribute paths such as resource.aws_instance.*.tags.Environment
+	IgnoreAttributes []string `json:"ignore_attributes,omitempty"`
+	// SetAttributes compares list attribute paths such as *.cidr_blocks regardless of order
+	SetAttributes []string `json:"set_attributes,omitempty"`
+	// Include and Exclude select elements by address globs such as aws_iam_*.* or module.vpc
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+	// RenameThreshold reports removed and added elements at least this similar (0 to 1) as
+	// renamed; 0 disables rename detection
+	RenameThreshold float64 `json:"rename_threshold,omitempty"`
+	// Identities and ResourceMatches pair resources of different names across modules
+	Identities      []IdentityRule  `json:"identities,omitempty"`
+	ResourceMatches []ResourceMatch `json:"resource_matches,omitempty"`
+	// BlockKeys overrides the key attributes pairing repeated nested blocks in diff output
+	BlockKeys []BlockKey `json:"block_keys,omitempty"`
 }
 
 // DefaultComparisonConfig returns default comparison configuration
@@ -92,6 +248,7 @@ const (
 	DiffTypeAdded    DiffType = "added"
 	DiffTypeRemoved  DiffType = "removed"
 	DiffTypeModified DiffType = "modified"
+	DiffTypeRenamed  DiffType = "renamed"
 )
 
 // Diff represents a difference between two elements
@@ -102,17 +259,183 @@ type Diff struct {
 	Before   interface{} `json:"before,omitempty"`
 	After    interface{} `json:"after,omitempty"`
 	Message  string      `json:"message,omitempty"`
+
+	// Changes lists the attribute changes of a modified or renamed element
+	Changes []AttributeChange `json:"changes,omitempty"`
+
+	// RenamedFrom and Similarity describe a renamed element, named Element on the right side
+	RenamedFrom string  `json:"renamed_from,omitempty"`
+	Similarity  float64 `json:"similarity,omitempty"`
+
+	// Compatibility classifies the effect of the difference on the callers of the module,
+	// for the reason given in CompatibilityReason
+	Compatibility       Compatibility `json:"compatibility,omitempty"`
+	CompatibilityReason string        `json:"compatibility_reason,omitempty"`
+}
+
+// DiffSummary represents the number of differences by type
+type DiffSummary struct {
+	Added    int `json:"added"`
+	Removed  int `json:"removed"`
+	Modified int `json:"modified"`
+	Renamed  int `json:"renamed"`
+	Total    int `json:"total"`
 }
 
 // ComparisonResult represents the result of comparing two modules
 type ComparisonResult struct {
-	LeftPath  string `json:"left_path"`
-	RightPath string `json:"right_path"`
-	Diffs     []Diff `json:"diffs"`
-	Summary   struct {
-		Added    int `json:"added"`
-		Removed  int `json:"removed"`
-		Modified int `json:"modified"`
-		Total    int `json:"total"`
-	} `json:"summary"`
-}
\ No newline at end of file
+	LeftPath  string      `json:"left_path"`
+	RightPath string      `json:"right_path"`
+	Diffs     []Diff      `json:"diffs"`
+	Summary   DiffSummary `json:"summary"`
+
+	// Compatibility is the strictest compatibility of the diffs, empty without diffs
+	Compatibility Compatibility `json:"compatibility,omitempty"`
+}
+
+// TreeComparisonResult represents the result of comparing two directory trees of modules.
+// Directories holds directories present on only one side; Results holds one result per
+// directory present on both sides.
+type TreeComparisonResult struct {
+	LeftPath    string              `json:"left_path"`
+	RightPath   string              `json:"right_path"`
+	Directories []Diff              `json:"directories"`
+	Results     []*ComparisonResult `json:"results"`
+	Summary     DiffSummary         `json:"summary"`
+}
+
+// DriftMatrix represents the values of every element and attribute across N module directories
+type DriftMatrix struct {
+	Environments []string        `json:"environments"`
+	Elements     []MatrixElement `json:"elements"`
+}
+
+// MatrixElement represents one element across environments.
+// Present is indexed like DriftMatrix.Environments.
+type MatrixElement struct {
+	Level      string            `json:"level"`
+	Element    string            `json:"element"`
+	Present    []bool            `json:"present"`
+	Differs    bool              `json:"differs"`
+	Attributes []MatrixAttribute `json:"attributes,omitempty"`
+}
+
+// MatrixAttribute represents the values of one attribute across environments.
+// Values is indexed like DriftMatrix.Environments; nil marks an absent attribute.
+type MatrixAttribute struct {
+	Path    string        `json:"path"`
+	Values  []interface{} `json:"values"`
+	Differs bool          `json:"differs"`
+}
+
+// ConsensusResult represents the directories that deviate from the majority of N module directories
+type ConsensusResult struct {
+	Directories []string            `json:"directories"`
+	Outliers    []Outlier           `json:"outliers"`
+	NoConsensus []ConsensusConflict `json:"no_consensus"`
+}
+
+// Outlier represents a value of one directory that differs from the majority value.
+// Attribute is empty when the element itself is missing or extra; Value and Consensus
+// are nil when the element or attribute is absent.
+type Outlier struct {
+	Directory string      `json:"directory"`
+	Level     string      `json:"level"`
+	Element   string      `json:"element"`
+	Attribute string      `json:"attribute,omitempty"`
+	Value     interface{} `json:"value"`
+	Consensus interface{} `json:"consensus"`
+}
+
+// ConsensusConflict represents an element or attribute for which no value is held by a majority
+type ConsensusConflict struct {
+	Level     string `json:"level"`
+	Element   string `json:"element"`
+	Attribute string `json:"attribute,omitempty"`
+}
+
+// ReferenceComparisonResult represents the result of comparing several instance directories
+// against one reference module. Results holds one result per instance in the order given.
+type ReferenceComparisonResult struct {
+	ReferencePath string              `json:"reference_path"`
+	Results       []*ComparisonResult `json:"results"`
+	Diverged      int                 `json:"diverged"`
+	Summary       DiffSummary         `json:"summary"`
+}
+
+// ThreeWayClass classifies a change in a three-way comparison
+type ThreeWayClass string
+
+const (
+	ThreeWayLeftOnly      ThreeWayClass = "changed_left_only"
+	ThreeWayRightOnly     ThreeWayClass = "changed_right_only"
+	ThreeWayBothIdentical ThreeWayClass = "changed_both_identically"
+	ThreeWayConflict      ThreeWayClass = "conflict"
+)
+
+// ThreeWayChange represents an element or attribute that changed on at least one side.
+// Attribute is empty for changes of the element itself; values are nil where absent.
+type ThreeWayChange struct {
+	Class     ThreeWayClass `json:"class"`
+	Level     string        `json:"level"`
+	Element   string        `json:"element"`
+	Attribute string        `json:"attribute,omitempty"`
+	Base      interface{}   `json:"base"`
+	Left      interface{}   `json:"left"`
+	Right     interface{}   `json:"right"`
+}
+
+// ThreeWaySummary represents the number of three-way changes by class
+type ThreeWaySummary struct {
+	LeftOnly      int `json:"changed_left_only"`
+	RightOnly     int `json:"changed_right_only"`
+	BothIdentical int `json:"changed_both_identically"`
+	Conflicts     int `json:"conflicts"`
+}
+
+// ThreeWayResult represents the result of comparing two modules forked from a common base.
+// LeftDiffs and RightDiffs hold the diffs of each side against the base.
+type ThreeWayResult struct {
+	BasePath   string           `json:"base_path"`
+	LeftPath   string           `json:"left_path"`
+	RightPath  string           `json:"right_path"`
+	LeftDiffs  []Diff           `json:"left_diffs"`
+	RightDiffs []Diff           `json:"right_diffs"`
+	Changes    []ThreeWayChange `json:"changes"`
+	Summary    ThreeWaySummary  `json:"summary"`
+}
+
+// Baseline records the accepted differences between two modules
+type Baseline struct {
+	SchemaVersion int             `json:"schema_version"`
+	LeftPath      string          `json:"left_path"`
+	RightPath     string          `json:"right_path"`
+	Entries       []BaselineEntry `json:"entries"`
+}
+
+// BaselineEntry represents one difference between two modules. Attribute is empty when the
+// element exists on one side only or was renamed from RenamedFrom; values are nil where absent.
+type BaselineEntry struct {
+	Level       string      `json:"level"`
+	Element     string      `json:"element"`
+	RenamedFrom string      `json:"renamed_from,omitempty"`
+	Attribute   string      `json:"attribute,omitempty"`
+	Left        interface{} `json:"left"`
+	Right       interface{} `json:"right"`
+}
+
+// BaselineChange represents an accepted difference whose values are no longer those recorded
+type BaselineChange struct {
+	Current  BaselineEntry `json:"current"`
+	Baseline BaselineEntry `json:"baseline"`
+}
+
+// BaselineResult represents the differences between two modules classified against a baseline
+type BaselineResult struct {
+	LeftPath  string           `json:"left_path"`
+	RightPath string           `json:"right_path"`
+	New       []BaselineEntry  `json:"new"`
+	Changed   []BaselineChange `json:"changed"`
+	Resolved  []BaselineEntry  `json:"resolved"`
+	Accepted  int              `json:"accepted"`
+}
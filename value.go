@@ -0,0 +1,290 @@
+package tfdiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// Value is an attribute value with its type preserved. Constant expressions are evaluated
+// to cty values, keeping their types, exact numbers and null; expressions that need a
+// Terraform context to evaluate, such as references and function calls, are kept as their
+// source text. An unset attribute has no Value.
+type Value struct {
+	// Val is the evaluated value, or an unknown value of dynamic type for an expression
+	Val cty.Value
+	// Source is the source text of an expression that cannot be evaluated
+	Source string
+}
+
+// valueJSON is the JSON encoding of a Value. The type and the value are encoded like
+// Terraform encodes them in plan JSON.
+type valueJSON struct {
+	Type   json.RawMessage `json:"type"`
+	Value  json.RawMessage `json:"value,omitempty"`
+	Source string          `json:"source,omitempty"`
+}
+
+// ParseValue parses the source text of an HCL expression into a value
+func ParseValue(source string) (Value, error) {
+	expr, diags := hclsyntax.ParseExpression([]byte(source), "value.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		return Value{}, fmt.Errorf("failed to parse value %q: %s", source, diags.Error())
+	}
+	return expressionValue(expr, []byte(source)), nil
+}
+
+// StringValue returns a string value
+func StringValue(s string) Value {
+	return Value{Val: cty.StringVal(s)}
+}
+
+// expressionValue evaluates an expression without a context. Expressions that cannot be
+// evaluated are kept as their source text.
+func expressionValue(expr hcl.Expression, content []byte) Value {
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() || !val.IsWhollyKnown() {
+		return Value{Val: cty.DynamicVal, Source: expressionSource(expr, content)}
+	}
+	return Value{Val: val}
+}
+
+// IsExpression reports whether the value is an expression that cannot be evaluated
+func (v Value) IsExpression() bool {
+	return !v.Val.IsKnown()
+}
+
+// IsNull reports whether the value is an explicit null
+func (v Value) IsNull() bool {
+	return v.Val.IsKnown() && v.Val.IsNull()
+}
+
+// TypeName names the type of the value, such as string, number or list of string
+func (v Value) TypeName() string {
+	if v.IsExpression() {
+		return "expression"
+	}
+	if v.IsNull() {
+		return "null"
+	}
+	return v.Val.Type().FriendlyName()
+}
+
+// Equal reports whether two values have the same type and value. Numbers are compared
+// exactly, object and tuple constructors item by item, and other expressions by their source
+// text regardless of whitespace.
+func (v Value) Equal(other Value) bool {
+	if v.IsExpression() || other.IsExpression() {
+		if !v.IsExpression() || !other.IsExpression() {
+			return false
+		}
+		if left, right := v.Interface(), other.Interface(); isCollectionPair(left, right) {
+			return valuesEqual(left, right)
+		}
+		return normalizeSource(v.Source) == normalizeSource(other.Source)
+	}
+	if v.Val.IsNull() || other.Val.IsNull() {
+		return v.Val.IsNull() && other.Val.IsNull()
+	}
+	return v.Val.Type().Equals(other.Val.Type()) && v.Val.RawEquals(other.Val)
+}
+
+// Interface returns the value as plain Go data: strings, json.Number for numbers, bools,
+// nil for null, []interface{} for lists, sets and tuples, and map[string]interface{} for
+// maps and objects. Object and tuple constructors that cannot be evaluated as a whole, such
+// as { name = var.name, size = 3 }, are returned as a map or a list of their items, so that
+// their constant items compare and diff like those of a constant collection. Other
+// expressions are returned as the Value itself.
+func (v Value) Interface() interface{} {
+	if v.IsExpression() {
+		if items, ok := constructorItems(v.Source); ok {
+			return items
+		}
+		return v
+	}
+	return ctyToInterface(v.Val)
+}
+
+// String formats the value for display: strings and numbers as is, collections as JSON and
+// expressions in interpolation syntax
+func (v Value) String() string {
+	switch {
+	case v.IsExpression():
+		return "${" + normalizeSource(v.Source) + "}"
+	case v.Val.IsNull():
+		return "null"
+	}
+	switch data := v.Interface().(type) {
+	case string:
+		return data
+	case json.Number:
+		return data.String()
+	case bool:
+		return fmt.Sprint(data)
+	default:
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return "<complex_expression>"
+		}
+		return string(encoded)
+	}
+}
+
+// MarshalJSON encodes the cty JSON type and the value, such as {"type":"number","value":443},
+// or the source of an expression as {"type":"dynamic","source":"aws_subnet.a.id"}. Diff
+// elements and snapshots use this encoding; attribute changes encode plain values instead.
+func (v Value) MarshalJSON() ([]byte, error) {
+	if v.IsExpression() {
+		return json.Marshal(valueJSON{Type: json.RawMessage(`"dynamic"`), Source: v.Source})
+	}
+	typ, err := ctyjson.MarshalType(v.Val.Type())
+	if err != nil {
+		return nil, err
+	}
+	value := json.RawMessage("null")
+	if !v.Val.IsNull() {
+		if value, err = ctyjson.Marshal(v.Val, v.Val.Type()); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(valueJSON{Type: typ, Value: value, Source: v.Source})
+}
+
+// UnmarshalJSON decodes a value encoded by MarshalJSON
+func (v *Value) UnmarshalJSON(data []byte) error {
+	var encoded valueJSON
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	if encoded.Source != "" {
+		*v = Value{Val: cty.DynamicVal, Source: encoded.Source}
+		return nil
+	}
+	typ, err := ctyjson.UnmarshalType(encoded.Type)
+	if err != nil {
+		return fmt.Errorf("invalid value type: %w", err)
+	}
+	if len(encoded.Value) == 0 || bytes.Equal(encoded.Value, []byte("null")) {
+		*v = Value{Val: cty.NullVal(typ)}
+		return nil
+	}
+	val, err := ctyjson.Unmarshal(encoded.Value, typ)
+	if err != nil {
+		return fmt.Errorf("invalid value: %w", err)
+	}
+	*v = Value{Val: val}
+	return nil
+}
+
+// constructorItems converts the source text of an object or tuple constructor to a map or a
+// list of the plain data of its items. Other expressions, and objects with keys that are not
+// constant, are not converted.
+func constructorItems(source string) (interface{}, bool) {
+	content := []byte(source)
+	expr, diags := hclsyntax.ParseExpression(content, "value.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, false
+	}
+
+	switch e := expr.(type) {
+	case *hclsyntax.TupleConsExpr:
+		items := make([]interface{}, 0, len(e.Exprs))
+		for _, item := range e.Exprs {
+			items = append(items, expressionValue(item, content).Interface())
+		}
+		return items, true
+	case *hclsyntax.ObjectConsExpr:
+		items := make(map[string]interface{}, len(e.Items))
+		for _, item := range e.Items {
+			key, diags := item.KeyExpr.Value(nil)
+			if diags.HasErrors() || !key.IsWhollyKnown() || key.IsNull() {
+				return nil, false
+			}
+			var name string
+			switch key.Type() {
+			case cty.String:
+				name = key.AsString()
+			case cty.Number:
+				name = numberString(key)
+			default:
+				return nil, false
+			}
+			items[name] = expressionValue(item.ValueExpr, content).Interface()
+		}
+		return items, true
+	default:
+		return nil, false
+	}
+}
+
+// ctyToInterface converts a known cty value to plain Go data
+func ctyToInterface(val cty.Value) interface{} {
+	if val.IsNull() || !val.IsKnown() {
+		return nil
+	}
+
+	typ := val.Type()
+	switch {
+	case typ == cty.String:
+		return val.AsString()
+	case typ == cty.Number:
+		return json.Number(numberString(val))
+	case typ == cty.Bool:
+		return val.True()
+	case typ.IsObjectType() || typ.IsMapType():
+		result := make(map[string]interface{}, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			key, item := it.Element()
+			result[key.AsString()] = ctyToInterface(item)
+		}
+		return result
+	case typ.IsListType() || typ.IsSetType() || typ.IsTupleType():
+		result := make([]interface{}, 0, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			_, item := it.Element()
+			result = append(result, ctyToInterface(item))
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+// numberString formats a cty number exactly, without an exponent
+func numberString(val cty.Value) string {
+	return val.AsBigFloat().Text('f', -1)
+}
+
+// normalizeSource collapses the whitespace of expression source text
+func normalizeSource(source string) string {
+	return strings.Join(strings.Fields(source), " ")
+}
+
+// valueMapsEqual compares two value maps at an attribute path, skipping ignored keys
+func valueMapsEqual(left, right map[string]Value, at attributePath) bool {
+	left, right = withoutIgnoredValues(left, at), withoutIgnoredValues(right, at)
+	if len(left) != len(right) {
+		return false
+	}
+	for key, leftValue := range left {
+		rightValue, exists := right[key]
+		if !exists || !valuesEqualAt(leftValue, rightValue, at.child(key)) {
+			return false
+		}
+	}
+	return true
+}
+
+// optionalValuesEqual compares two optional values, where nil is unset
+func optionalValuesEqual(left, right *Value) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+	return left.Equal(*right)
+}
@@ -0,0 +1,293 @@
+package tfdiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// AttributeChange is a change of an attribute value, or of a key or an element of a
+// collection value. Paths extend attribute names with map keys (tags.Environment) and list
+// indices (private_subnets[2]); removed list elements are indexed on the left side and added
+// ones on the right side.
+type AttributeChange struct {
+	Path   string      `json:"path"`
+	Action DiffType    `json:"action"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// MarshalJSON encodes the before and after values as plain JSON data, where strings,
+// numbers, bools and null keep their JSON types. A null value is encoded as null rather than
+// omitted.
+func (c AttributeChange) MarshalJSON() ([]byte, error) {
+	var encoded struct {
+		Path   string       `json:"path"`
+		Action DiffType     `json:"action"`
+		Before *interface{} `json:"before,omitempty"`
+		After  *interface{} `json:"after,omitempty"`
+	}
+	encoded.Path, encoded.Action = c.Path, c.Action
+	if c.Action != DiffTypeAdded {
+		before := plainValue(c.Before)
+		encoded.Before = &before
+	}
+	if c.Action != DiffTypeRemoved {
+		after := plainValue(c.After)
+		encoded.After = &after
+	}
+	return json.Marshal(encoded)
+}
+
+// plainValue converts the Values of a change value, including those of a nested block
+// config, to plain data. Expressions stay Values.
+func plainValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case Value:
+		return v.Interface()
+	case map[string][]map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for blockType, blocks := range v {
+			list := make([]interface{}, 0, len(blocks))
+			for _, block := range blocks {
+				list = append(list, plainValue(block))
+			}
+			result[blockType] = list
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = plainValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			result = append(result, plainValue(item))
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// decodeCollection decodes a collection Value or a JSON object or array string into a map or
+// a list. Other values are returned unchanged.
+func decodeCollection(value interface{}) interface{} {
+	if v, ok := value.(Value); ok {
+		if data := v.Interface(); isCollectionPair(data, data) {
+			return data
+		}
+		return value
+	}
+	s, ok := value.(string)
+	if !ok || !isJSON(s) {
+		return value
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(s), &decoded); err != nil {
+		return value
+	}
+	switch decoded.(type) {
+	case map[string]interface{}, []interface{}:
+		return decoded
+	default:
+		return value
+	}
+}
+
+// isCollectionPair reports whether two decoded values are both maps or both lists
+func isCollectionPair(before, after interface{}) bool {
+	switch before.(type) {
+	case map[string]interface{}:
+		_, ok := after.(map[string]interface{})
+		return ok
+	case []interface{}:
+		_, ok := after.([]interface{})
+		return ok
+	default:
+		return false
+	}
+}
+
+// collectionChanges diffs two collection values structurally: map keys are added, removed
+// or changed, and list elements are inserted or deleted, matched by their longest common
+// subsequence or, for lists configured as sets, regardless of order. Values that are not
+// both maps or both lists are reported as one change of the whole value.
+func collectionChanges(path string, before, after interface{}, at attributePath) []AttributeChange {
+	before, after = decodeCollection(before), decodeCollection(after)
+
+	switch b := before.(type) {
+	case map[string]interface{}:
+		a, ok := after.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make(map[string]bool)
+		for key := range b {
+			keys[key] = true
+		}
+		for key := range a {
+			keys[key] = true
+		}
+		var changes []AttributeChange
+		for _, key := range sortedKeys(keys) {
+			if at.child(key).ignored() {
+				continue
+			}
+			bv, bOk := b[key]
+			av, aOk := a[key]
+			switch {
+			case !aOk:
+				changes = append(changes, AttributeChange{Path: joinAttributePath(path, key), Action: DiffTypeRemoved, Before: bv})
+			case !bOk:
+				changes = append(changes, AttributeChange{Path: joinAttributePath(path, key), Action: DiffTypeAdded, After: av})
+			case !valuesEqualAt(bv, av, at.child(key)):
+				changes = append(changes, collectionChanges(joinAttributePath(path, key), bv, av, at.child(key))...)
+			}
+		}
+		return changes
+	case []interface{}:
+		a, ok := after.([]interface{})
+		if !ok {
+			break
+		}
+		if at.isSet() {
+			var changes []AttributeChange
+			for _, i := range unmatchedElements(b, a, at) {
+				changes = append(changes, AttributeChange{Path: fmt.Sprintf("%s[%d]", path, i), Action: DiffTypeRemoved, Before: b[i]})
+			}
+			for _, i := range unmatchedElements(a, b, at) {
+				changes = append(changes, AttributeChange{Path: fmt.Sprintf("%s[%d]", path, i), Action: DiffTypeAdded, After: a[i]})
+			}
+			return changes
+		}
+		return listChanges(path, b, a, at)
+	}
+
+	return []AttributeChange{{Path: path, Action: DiffTypeModified, Before: before, After: after}}
+}
+
+// listChanges lists the deletions and insertions that turn one list into another, keeping
+// their longest common subsequence
+func listChanges(path string, before, after []interface{}, at attributePath) []AttributeChange {
+	// lcs[i][j] is the length of the longest common subsequence of before[i:] and after[j:]
+	lcs := make([][]int, len(before)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if valuesEqualAt(before[i], after[j], at) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var changes []AttributeChange
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && valuesEqualAt(before[i], after[j], at):
+			i++
+			j++
+		case j == len(after) || (i < len(before) && lcs[i+1][j] >= lcs[i][j+1]):
+			changes = append(changes, AttributeChange{Path: fmt.Sprintf("%s[%d]", path, i), Action: DiffTypeRemoved, Before: before[i]})
+			i++
+		default:
+			changes = append(changes, AttributeChange{Path: fmt.Sprintf("%s[%d]", path, j), Action: DiffTypeAdded, After: after[j]})
+			j++
+		}
+	}
+	return changes
+}
+
+// unmatchedElements returns the indices of the elements of a list that have no equal
+// element in another list, pairing each element at most once
+func unmatchedElements(list, other []interface{}, at attributePath) []int {
+	matched := make([]bool, len(other))
+	var unmatched []int
+	for i, item := range list {
+		found := false
+		for j, candidate := range other {
+			if !matched[j] && valuesEqualAt(item, candidate, at) {
+				matched[j] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			unmatched = append(unmatched, i)
+		}
+	}
+	return unmatched
+}
+
+// formatCollectionChanges renders the changes of a collection attribute as nested lines.
+// Change paths start with the attribute name.
+func formatCollectionChanges(key string, changes []AttributeChange) []string {
+	open, close := "{", "}"
+	if strings.HasPrefix(strings.TrimPrefix(changes[0].Path, key), "[") {
+		open, close = "[", "]"
+	}
+
+	lines := []string{fmt.Sprintf("    %s = %s", key, open)}
+	for _, change := range relativeChanges(key, changes) {
+		path := change.Path
+		switch change.Action {
+		case DiffTypeRemoved:
+			lines = append(lines, fmt.Sprintf("  -   %s = %s", path, formatChangeValue(change.Before)))
+		case DiffTypeAdded:
+			lines = append(lines, fmt.Sprintf("  +   %s = %s", path, formatChangeValue(change.After)))
+		default:
+			lines = append(lines, fmt.Sprintf("  -   %s = %s", path, formatChangeValue(change.Before)))
+			lines = append(lines, fmt.Sprintf("  +   %s = %s", path, formatChangeValue(change.After)))
+		}
+	}
+	lines = append(lines, "    "+close)
+	return lines
+}
+
+// formatChangeValue formats a value of an attribute change as JSON, leaving characters such
+// as < and > in conditions unescaped
+func formatChangeValue(value interface{}) string {
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(displayData(decodeCollection(value))); err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSuffix(data.String(), "\n")
+}
+
+// displayData converts the Values of plain data for display, with expressions in
+// interpolation syntax
+func displayData(value interface{}) interface{} {
+	switch v := value.(type) {
+	case Value:
+		if _, ok := v.Interface().(Value); ok {
+			return v.String()
+		}
+		return displayData(v.Interface())
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = displayData(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			result = append(result, displayData(item))
+		}
+		return result
+	default:
+		return value
+	}
+}
@@ -0,0 +1,155 @@
+package tfdiff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCollectionChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		before interface{}
+		after  interface{}
+		sets   []string
+		ignore []string
+		want   []string
+	}{
+		{
+			name:   "map keys",
+			before: `{"Name":"web","Environment":"staging","Team":"platform"}`,
+			after:  `{"Name":"web","Environment":"production","Owner":"sre"}`,
+			want: []string{
+				"modified tags.Environment staging production",
+				"added tags.Owner <nil> sre",
+				"removed tags.Team platform <nil>",
+			},
+		},
+		{
+			name:   "nested map",
+			before: `{"labels":{"app":"web","tier":"frontend"}}`,
+			after:  `{"labels":{"app":"web","tier":"backend"}}`,
+			want:   []string{"modified tags.labels.tier frontend backend"},
+		},
+		{
+			name:   "ignored key",
+			before: `{"Name":"web","Environment":"staging"}`,
+			after:  `{"Name":"api","Environment":"production"}`,
+			ignore: []string{"*.tags.Environment"},
+			want:   []string{"modified tags.Name web api"},
+		},
+		{
+			name:   "list insertion and deletion",
+			before: `["10.0.1.0/24","10.0.2.0/24","10.0.3.0/24"]`,
+			after:  `["10.0.1.0/24","10.0.3.0/24","10.0.4.0/24"]`,
+			want: []string{
+				"removed tags[1] 10.0.2.0/24 <nil>",
+				"added tags[2] <nil> 10.0.4.0/24",
+			},
+		},
+		{
+			name:   "reordered list",
+			before: `["a","b"]`,
+			after:  `["b","a"]`,
+			want: []string{
+				"removed tags[0] a <nil>",
+				"added tags[1] <nil> a",
+			},
+		},
+		{
+			name:   "reordered set",
+			before: `["a","b"]`,
+			after:  `["b","a","c"]`,
+			sets:   []string{"resource.aws_vpc.*.tags"},
+			want:   []string{"added tags[2] <nil> c"},
+		},
+		{
+			name:   "map replaced by list",
+			before: `{"a":"b"}`,
+			after:  `["a"]`,
+			want:   []string{"modified tags map[a:b] [a]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := ComparisonConfig{IgnoreAttributes: tt.ignore, SetAttributes: tt.sets}
+			at := newAttributePath(config, "resource", "aws_vpc.main").child("tags")
+
+			var got []string
+			for _, change := range collectionChanges("tags", tt.before, tt.after, at) {
+				got = append(got, fmt.Sprintf("%s %s %v %v", change.Action, change.Path, change.Before, change.After))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("expected changes:\n%s\ngot:\n%s", strings.Join(tt.want, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestCompareModules_CollectionChanges(t *testing.T) {
+	left := parseTestModule(t, map[string]string{
+		"main.tf": `
+resource "aws_vpc" "main" {
+  cidr_block      = "10.0.0.0/16"
+  private_subnets = ["10.0.1.0/24", "10.0.2.0/24"]
+  tags = {
+    Name        = "main"
+    Environment = "staging"
+  }
+}
+`,
+	})
+	right := parseTestModule(t, map[string]string{
+		"main.tf": `
+resource "aws_vpc" "main" {
+  cidr_block      = "10.0.0.0/16"
+  private_subnets = ["10.0.2.0/24", "10.0.1.0/24", "10.0.3.0/24"]
+  tags = {
+    Name        = "main"
+    Environment = "production"
+  }
+}
+`,
+	})
+
+	config := ComparisonConfig{
+		Levels:        []ComparisonLevel{ComparisonLevelResources},
+		SetAttributes: []string{"*.private_subnets"},
+	}
+	result := CompareModules(left, right, config)
+	if len(result.Diffs) != 1 {
+		t.Fatalf("expected 1 diff, got %d", len(result.Diffs))
+	}
+
+	var paths []string
+	for _, change := range result.Diffs[0].Changes {
+		paths = append(paths, string(change.Action)+" "+change.Path)
+	}
+	want := "added private_subnets[2],modified tags.Environment"
+	if strings.Join(paths, ",") != want {
+		t.Errorf("expected changes %s, got %s", want, strings.Join(paths, ","))
+	}
+
+	output := FormatTextOutput(result, config, true)
+	for _, line := range []string{
+		"    private_subnets = [",
+		`  +   [2] = "10.0.3.0/24"`,
+		"    tags = {",
+		`  -   Environment = "staging"`,
+		`  +   Environment = "production"`,
+	} {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+	if strings.Contains(output, `"Name"`) || strings.Contains(output, `10.0.1.0/24`) {
+		t.Errorf("expected unchanged keys and elements to be hidden, got:\n%s", output)
+	}
+
+	// Without the set pattern the reordered list has no equal elements to keep in place
+	config.SetAttributes = nil
+	if result := CompareModules(left, right, config); len(result.Diffs[0].Changes) < 3 {
+		t.Errorf("expected ordered list changes, got %+v", result.Diffs[0].Changes)
+	}
+}
@@ -0,0 +1,358 @@
+package tfdiff
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// mustParseValue parses the source text of a value for test fixtures
+func mustParseValue(source string) *Value {
+	value, err := ParseValue(source)
+	if err != nil {
+		panic(err)
+	}
+	return &value
+}
+
+func TestValueEqual(t *testing.T) {
+	tests := []struct {
+		name        string
+		left, right string
+		want        bool
+	}{
+		{name: "same string", left: `"a"`, right: `"a"`, want: true},
+		{name: "string and number", left: `"1"`, right: `1`, want: false},
+		{name: "string and bool", left: `"true"`, right: `true`, want: false},
+		{name: "number spelling", left: `1.0`, right: `1`, want: true},
+		{name: "large numbers", left: `12345678901234567890`, right: `12345678901234567891`, want: false},
+		{name: "null and empty string", left: `null`, right: `""`, want: false},
+		{name: "null", left: `null`, right: `null`, want: true},
+		{name: "list element types", left: `["1", 2]`, right: `[1, 2]`, want: false},
+		{name: "objects", left: `{a = 1, b = "x"}`, right: `{b = "x", a = 1}`, want: true},
+		{name: "same expression", left: `aws_vpc.main.id`, right: `aws_vpc.main.id`, want: true},
+		{name: "expression whitespace", left: "merge(var.tags, { a = 1 })", right: "merge(var.tags,\n  { a = 1 })", want: true},
+		{name: "different expressions", left: `aws_vpc.main.id`, right: `aws_vpc.other.id`, want: false},
+		{name: "expression and literal", left: `var.name`, right: `"var.name"`, want: false},
+		{name: "constructor item order", left: `{a = var.x, b = 1}`, right: `{b = 1, a = var.x}`, want: true},
+		{name: "constructor items", left: `{a = var.x, b = 1}`, right: `{a = var.x, b = "1"}`, want: false},
+		{name: "constructor and literal", left: `[var.x]`, right: `["var.x"]`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			left, right := mustParseValue(tt.left), mustParseValue(tt.right)
+			if got := left.Equal(*right); got != tt.want {
+				t.Errorf("Equal(%s, %s) = %v, want %v", tt.left, tt.right, got, tt.want)
+			}
+			if got := valuesEqual(*left, *right); got != tt.want {
+				t.Errorf("valuesEqual(%s, %s) = %v, want %v", tt.left, tt.right, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValueString(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{`"web"`, "web"},
+		{`443`, "443"},
+		{`12345678901234567890`, "12345678901234567890"},
+		{`0.1`, "0.1"},
+		{`true`, "true"},
+		{`null`, "null"},
+		{`["a", 1]`, `["a",1]`},
+		{`{Name = "web"}`, `{"Name":"web"}`},
+		{"merge(\n  var.tags,\n  { Name = \"web\" },\n)", `${merge( var.tags, { Name = "web" }, )}`},
+	}
+
+	for _, tt := range tests {
+		if got := mustParseValue(tt.source).String(); got != tt.want {
+			t.Errorf("String() of %s = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestValueJSON(t *testing.T) {
+	for _, source := range []string{
+		`"web"`,
+		`12345678901234567890`,
+		`true`,
+		`null`,
+		`["a", 1, null]`,
+		`{Name = "web", Port = 443}`,
+		`aws_vpc.main.id`,
+	} {
+		value := mustParseValue(source)
+		data, err := json.Marshal(value)
+		if err != nil {
+			t.Fatalf("failed to marshal %s: %v", source, err)
+		}
+		var decoded Value
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("failed to unmarshal %s: %v", data, err)
+		}
+		if !decoded.Equal(*value) || decoded.IsExpression() != value.IsExpression() {
+			t.Errorf("round trip of %s gave %s", source, data)
+		}
+	}
+
+	data, _ := json.Marshal(mustParseValue(`12345678901234567890`))
+	if string(data) != `{"type":"number","value":12345678901234567890}` {
+		t.Errorf("unexpected number encoding %s", data)
+	}
+	data, _ = json.Marshal(mustParseValue(`var.name`))
+	if string(data) != `{"type":"dynamic","source":"var.name"}` {
+		t.Errorf("unexpected expression encoding %s", data)
+	}
+}
+
+func TestCompareModules_TypedValues(t *testing.T) {
+	left := parseTestModule(t, map[string]string{
+		"main.tf": `
+resource "aws_instance" "web" {
+  count     = "1"
+  tags      = null
+  subnet_id = aws_subnet.a.id
+  ami       = "ami-123"
+}
+
+module "vpc" {
+  source = "./vpc"
+  cidr   = var.cidr
+}
+
+variable "retries" {
+  default = 3
+}
+`,
+	})
+	right := parseTestModule(t, map[string]string{
+		"main.tf": `
+resource "aws_instance" "web" {
+  count     = 1
+  subnet_id = aws_subnet.b.id
+  ami       = "ami-123"
+}
+
+module "vpc" {
+  source = "./vpc"
+  cidr   = var.cidr
+}
+
+variable "retries" {
+  default = "3"
+}
+`,
+	})
+
+	config := ComparisonConfig{
+		Levels:          []ComparisonLevel{ComparisonLevelAll},
+		IgnoreArguments: false,
+	}
+	result := CompareModules(left, right, config)
+
+	changes := make(map[string]map[string]AttributeChange)
+	for _, diff := range result.Diffs {
+		changes[diff.Element] = make(map[string]AttributeChange)
+		for _, change := range diff.Changes {
+			changes[diff.Element][change.Path] = change
+		}
+	}
+
+	if _, ok := changes["vpc"]; ok {
+		t.Errorf("expected equal expressions to compare equal, got %+v", changes["vpc"])
+	}
+	web := changes["aws_instance.web"]
+	if change, ok := web["count"]; !ok || change.Action != DiffTypeModified {
+		t.Errorf("expected count to change from string to number, got %+v", web)
+	}
+	if change, ok := web["tags"]; !ok || change.Action != DiffTypeRemoved {
+		t.Errorf("expected the null tags to be removed, got %+v", web)
+	}
+	if change, ok := web["subnet_id"]; !ok || change.Action != DiffTypeModified {
+		t.Errorf("expected the subnet reference to change, got %+v", web)
+	}
+	if _, ok := web["ami"]; ok {
+		t.Errorf("expected ami to be unchanged, got %+v", web)
+	}
+	if _, ok := changes["retries"]["default"]; !ok {
+		t.Errorf("expected the default to change from number to string, got %+v", changes["retries"])
+	}
+
+	// Only strings are quoted, so values of different types read differently
+	output := FormatTextOutput(result, config, true)
+	for _, line := range []string{
+		`  - count = "1"`,
+		`  + count = 1`,
+		`-  default = 3`,
+		`+  default = "3"`,
+		`  - subnet_id = "${aws_subnet.a.id}"`,
+		`  + subnet_id = "${aws_subnet.b.id}"`,
+	} {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+
+	// JSON changes keep the JSON types of the values
+	data, err := json.Marshal(web["count"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"path":"count","action":"modified","before":"1","after":1}` {
+		t.Errorf("unexpected count change encoding %s", data)
+	}
+	data, _ = json.Marshal(web["tags"])
+	if string(data) != `{"path":"tags","action":"removed","before":null}` {
+		t.Errorf("unexpected tags change encoding %s", data)
+	}
+}
+
+func TestCompareModules_ModuleCallArguments(t *testing.T) {
+	left := parseTestModule(t, map[string]string{
+		"main.tf": `
+module "ecs" {
+  source = "./ecs"
+  cluster_configuration = {
+    logging   = "OVERRIDE"
+    log_group = aws_cloudwatch_log_group.app.name
+  }
+  subnets = [module.vpc.private_subnets[0], "subnet-b"]
+  tags    = { Environment = "staging" }
+}
+`,
+	})
+	right := parseTestModule(t, map[string]string{
+		"main.tf": `
+module "ecs" {
+  source = "./ecs"
+  cluster_configuration = {
+    log_group = aws_cloudwatch_log_group.app.name
+    logging   = "DEFAULT"
+  }
+  subnets = [module.vpc.private_subnets[0], "subnet-b", module.vpc.private_subnets[2]]
+  tags    = { Environment = "staging" }
+}
+
+module "cache" {
+  source = "./cache"
+  nodes  = [{ name = "a", size = 2 }]
+}
+`,
+	})
+
+	config := ComparisonConfig{
+		Levels:          []ComparisonLevel{ComparisonLevelModuleCalls},
+		IgnoreArguments: false,
+	}
+	result := CompareModules(left, right, config)
+
+	var ecs *Diff
+	for i := range result.Diffs {
+		if result.Diffs[i].Element == "ecs" {
+			ecs = &result.Diffs[i]
+		}
+	}
+	if ecs == nil || ecs.Type != DiffTypeModified {
+		t.Fatalf("expected the ecs module call to be modified, got %+v", result.Diffs)
+	}
+	var got []string
+	for _, change := range ecs.Changes {
+		got = append(got, string(change.Action)+" "+change.Path)
+	}
+	want := []string{"modified cluster_configuration.logging", "added subnets[2]"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected changes %v, got %v", want, got)
+	}
+
+	output := FormatTextOutput(result, config, true)
+	for _, line := range []string{
+		`  -   logging = "OVERRIDE"`,
+		`  +   logging = "DEFAULT"`,
+		`  +   [2] = "${module.vpc.private_subnets[2]}"`,
+		`+  nodes = [`,
+		`+    {`,
+		`+      name = "a"`,
+		`+      size = 2`,
+		`+    },`,
+		`+  ]`,
+	} {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+}
+
+func TestFormatTextOutput_TypedValues(t *testing.T) {
+	left := parseTestModule(t, map[string]string{
+		"main.tf": `
+variable "name" {
+  default = "1"
+}
+`,
+		"main.tfdeploy.hcl": `
+deployment "prod" {
+  inputs = {
+    weight = 40
+    region = "us-east-1"
+  }
+}
+`,
+	})
+	right := parseTestModule(t, map[string]string{
+		"main.tf": `
+variable "name" {
+  default = "1"
+}
+
+resource "aws_lb" "web" {
+  name            = "web"
+  internal        = false
+  security_groups = [aws_security_group.web.id, "sg-1"]
+  tags = {
+    Team = "platform"
+    Cost = 3
+  }
+}
+
+variable "retries" {
+  default = 1
+}
+`,
+		"main.tfdeploy.hcl": `
+deployment "prod" {
+  inputs = {
+    weight = 50
+    region = "us-east-1"
+  }
+}
+`,
+	})
+
+	config := ComparisonConfig{Levels: []ComparisonLevel{ComparisonLevelAll}}
+	output := FormatTextOutput(CompareModules(left, right, config), config, true)
+	for _, line := range []string{
+		`+  internal = false`,
+		`+  name = "web"`,
+		`+  security_groups = [`,
+		`+    "${aws_security_group.web.id}",`,
+		`+    "sg-1",`,
+		`+  tags = {`,
+		`+    Cost = 3`,
+		`+    Team = "platform"`,
+		`+  default = 1`,
+		`  -   weight = 40`,
+		`  +   weight = 50`,
+	} {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+	// Attributes are listed in sorted order
+	if strings.Index(output, "internal =") > strings.Index(output, "tags =") {
+		t.Errorf("expected attributes in sorted order, got:\n%s", output)
+	}
+}